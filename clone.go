@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/nu0ma/spemu/pkg/executor"
+)
+
+// cloneFlags are the flags of spemu clone
+type cloneFlags struct {
+	conn    *connectionFlags
+	targets listFlags
+	verbose *bool
+}
+
+func addCloneFlags(fs *flag.FlagSet) *cloneFlags {
+	f := &cloneFlags{conn: addConnectionFlags(fs)}
+	fs.Var(&f.targets, "to", "Database to create as a copy (required, repeatable or comma-separated)")
+	f.verbose = fs.Bool("verbose", false, "Enable verbose output")
+	return f
+}
+
+func runClone(args []string) {
+	fs := flag.NewFlagSet("clone", flag.ExitOnError)
+	f := addCloneFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: spemu clone [options] --to <database>[,<database>...]
+
+Copy the database to new databases in the same instance: each target is
+created with the schema of the database and all rows are copied table by
+table, parents and referenced tables first. Targets must not exist.
+
+Options:
+`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if len(f.targets) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cfg, err := f.conn.config()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, target := range f.targets {
+		if err := executor.CloneDatabase(cfg, target, *f.verbose); err != nil {
+			log.Fatalf("Failed to clone %s to %s: %v", cfg.DatabaseID, target, err)
+		}
+		fmt.Printf("Successfully cloned %s to %s\n", cfg.DatabaseID, target)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nu0ma/spemu/pkg/completion"
+)
+
+// command is a spemu subcommand. Commands with subcommands, such as schema,
+// dispatch on their first argument.
+type command struct {
+	name    string
+	summary string
+	// flags defines the flags of the command on fs. It is used to list the
+	// flags for shell completion.
+	flags       func(fs *flag.FlagSet)
+	run         func(args []string)
+	subcommands []*command
+}
+
+// commands are the subcommands of spemu. They are set in init, since the
+// help and completion commands refer to them.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{
+			name:    "seed",
+			summary: "Execute DML files against the database",
+			flags:   func(fs *flag.FlagSet) { addSeedFlags(fs) },
+			run:     runSeed,
+		},
+		{
+			name:    "schema",
+			summary: "Create, compare and export the database schema",
+			subcommands: []*command{
+				{
+					name:    "init",
+					summary: "Create the database from a schema file",
+					flags:   func(fs *flag.FlagSet) { addSchemaInitFlags(fs) },
+					run:     runSchemaInit,
+				},
+				{
+					name:    "diff",
+					summary: "Print the DDL that makes the database match a schema file",
+					flags:   func(fs *flag.FlagSet) { addSchemaDiffFlags(fs) },
+					run:     runSchemaDiff,
+				},
+				{
+					name:    "dump",
+					summary: "Write the DDL of the database",
+					flags:   func(fs *flag.FlagSet) { addSchemaDumpFlags(fs) },
+					run:     runSchemaDump,
+				},
+			},
+		},
+		{
+			name:    "reset",
+			summary: "Delete all rows, or recreate the database from a schema file",
+			flags:   func(fs *flag.FlagSet) { addResetFlags(fs) },
+			run:     runReset,
+		},
+		{
+			name:    "query",
+			summary: "Run a query and print the result",
+			flags:   func(fs *flag.FlagSet) { addQueryFlags(fs) },
+			run:     runQuery,
+		},
+		{
+			name:    "shell",
+			summary: "Start an interactive SQL shell",
+			flags:   func(fs *flag.FlagSet) { addConnectionFlags(fs) },
+			run:     runShell,
+		},
+		{
+			name:    "verify",
+			summary: "Check the database contents against expectations",
+			flags:   func(fs *flag.FlagSet) { addVerifyFlags(fs) },
+			run:     runVerify,
+		},
+		{
+			name:    "generate",
+			summary: "Generate synthetic rows from the schema",
+			flags:   func(fs *flag.FlagSet) { addGenerateFlags(fs) },
+			run:     runGenerate,
+		},
+		{
+			name:    "snapshot",
+			summary: "Save and restore the schema and rows of the database",
+			subcommands: []*command{
+				{
+					name:    "save",
+					summary: "Save the schema and rows of the database",
+					flags:   func(fs *flag.FlagSet) { addSnapshotFlags(fs) },
+					run:     runSnapshotSave,
+				},
+				{
+					name:    "restore",
+					summary: "Recreate the database from a snapshot",
+					flags:   func(fs *flag.FlagSet) { addSnapshotFlags(fs) },
+					run:     runSnapshotRestore,
+				},
+			},
+		},
+		{
+			name:    "clone",
+			summary: "Copy the database within the instance",
+			flags:   func(fs *flag.FlagSet) { addCloneFlags(fs) },
+			run:     runClone,
+		},
+		{
+			name:    "completion",
+			summary: "Print a shell completion script for bash, zsh or fish",
+			run:     runCompletion,
+		},
+		{
+			name:    "help",
+			summary: "Show help for a command",
+			run:     runHelp,
+		},
+		{
+			name:    "version",
+			summary: "Show version information",
+			run:     runVersion,
+		},
+	}
+}
+
+// findCommand returns the command named name, or nil.
+func findCommand(commands []*command, name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// execute runs the command. path is the command line that invokes it, e.g.
+// "spemu schema".
+func (c *command) execute(path string, args []string) {
+	if len(c.subcommands) == 0 {
+		c.run(args)
+		return
+	}
+
+	if len(args) == 0 {
+		c.usage(path)
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "-h", "-help", "--help":
+		c.usage(path)
+		return
+	}
+	sub := findCommand(c.subcommands, args[0])
+	if sub == nil {
+		fmt.Fprintf(os.Stderr, "Error: unknown %s command %q\n", c.name, args[0])
+		fmt.Fprintf(os.Stderr, "Run '%s --help' for the list of commands.\n", path)
+		os.Exit(1)
+	}
+	sub.execute(path+" "+sub.name, args[1:])
+}
+
+// usage prints the subcommands of a command.
+func (c *command) usage(path string) {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [options]\n\n%s.\n\nCommands:\n", path, c.summary)
+	for _, sub := range c.subcommands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", sub.name, sub.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> --help' for the options of a command.\n", path)
+}
+
+func runHelp(args []string) {
+	fs := flag.NewFlagSet("help", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: spemu help [command]\n\nShow the commands of spemu, or the usage and options of a command.\n")
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		showHelp()
+		return
+	}
+
+	cmds, path := commands, "spemu"
+	for i, name := range fs.Args() {
+		cmd := findCommand(cmds, name)
+		if cmd == nil {
+			fmt.Fprintf(os.Stderr, "Error: unknown command %q\n", path+" "+name)
+			fmt.Fprintf(os.Stderr, "Run 'spemu help' for the list of commands.\n")
+			os.Exit(1)
+		}
+		path += " " + name
+		if len(cmd.subcommands) == 0 || i == fs.NArg()-1 {
+			// The flag sets of the commands print their usage for -help
+			cmd.execute(path, []string{"-help"})
+			return
+		}
+		cmds = cmd.subcommands
+	}
+}
+
+func runVersion(args []string) {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: spemu version\n\nShow version information.\n")
+	}
+	fs.Parse(args)
+
+	fmt.Printf("spemu version %s\n", Version)
+}
+
+func runCompletion(args []string) {
+	fs := flag.NewFlagSet("completion", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: spemu completion <bash|zsh|fish>
+
+Print a script that completes the commands and flags of spemu in the given
+shell. To load it:
+
+  bash: source <(spemu completion bash)
+  zsh:  source <(spemu completion zsh)
+  fish: spemu completion fish > ~/.config/fish/completions/spemu.fish
+`)
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	if err := completion.Write(os.Stdout, fs.Arg(0), completionCommand()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// completionCommand describes spemu for shell completion. The flags of the
+// root command are those of the flag form of earlier versions.
+func completionCommand() *completion.Command {
+	fs := flag.NewFlagSet("spemu", flag.ContinueOnError)
+	addLegacyFlags(fs)
+	return &completion.Command{
+		Name:     "spemu",
+		Flags:    completion.FlagsOf(fs),
+		Commands: completionCommands(commands),
+	}
+}
+
+func completionCommands(commands []*command) []*completion.Command {
+	var completions []*completion.Command
+	for _, cmd := range commands {
+		c := &completion.Command{
+			Name:     cmd.name,
+			Summary:  cmd.summary,
+			Commands: completionCommands(cmd.subcommands),
+		}
+		if cmd.flags != nil {
+			fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+			cmd.flags(fs)
+			c.Flags = completion.FlagsOf(fs)
+		}
+		completions = append(completions, c)
+	}
+	return completions
+}
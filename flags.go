@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/nu0ma/spemu/pkg/config"
+	"github.com/nu0ma/spemu/pkg/params"
+	"github.com/nu0ma/spemu/pkg/parser"
+	"github.com/nu0ma/spemu/pkg/render"
+)
+
+// connectionFlags are the flags shared by commands that connect to the emulator
+type connectionFlags struct {
+	project  *string
+	instance *string
+	database *string
+	port     *string
+	dialect  *string
+}
+
+func addConnectionFlags(fs *flag.FlagSet) *connectionFlags {
+	return &connectionFlags{
+		project:  fs.String("project", "", "Spanner project ID (required)"),
+		instance: fs.String("instance", "", "Spanner instance ID (required)"),
+		database: fs.String("database", "", "Spanner database ID (required)"),
+		port:     fs.String("port", "9010", "Spanner emulator port"),
+		dialect:  fs.String("dialect", "googlesql", "SQL dialect of the database: googlesql or postgresql"),
+	}
+}
+
+// config validates the connection flags and builds the configuration
+func (c *connectionFlags) config() (*config.Config, error) {
+	if *c.project == "" {
+		return nil, fmt.Errorf("--project is required")
+	}
+	if *c.instance == "" {
+		return nil, fmt.Errorf("--instance is required")
+	}
+	if *c.database == "" {
+		return nil, fmt.Errorf("--database is required")
+	}
+	dialect, err := parser.ParseDialect(*c.dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	return &config.Config{
+		ProjectID:    *c.project,
+		InstanceID:   *c.instance,
+		DatabaseID:   *c.database,
+		EmulatorHost: fmt.Sprintf("localhost:%s", *c.port),
+		Dialect:      dialect.String(),
+	}, nil
+}
+
+// paramFlags collects repeated --param name=value:TYPE flags
+type paramFlags map[string]interface{}
+
+func (p paramFlags) String() string {
+	return ""
+}
+
+func (p paramFlags) Set(def string) error {
+	name, value, err := params.Parse(def)
+	if err != nil {
+		return err
+	}
+	p[name] = value
+	return nil
+}
+
+// varFlags collects repeated --var key=value flags for templated seed files
+type varFlags map[string]string
+
+func (v varFlags) String() string {
+	return ""
+}
+
+func (v varFlags) Set(def string) error {
+	key, value, err := render.ParseVar(def)
+	if err != nil {
+		return err
+	}
+	v[key] = value
+	return nil
+}
+
+// listFlags collects repeated flags that take one or more comma-separated
+// values, such as --tag
+type listFlags []string
+
+func (l *listFlags) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlags) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
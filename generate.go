@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nu0ma/spemu/pkg/executor"
+	"github.com/nu0ma/spemu/pkg/generate"
+	"github.com/nu0ma/spemu/pkg/parser"
+	"github.com/nu0ma/spemu/pkg/schema"
+)
+
+// generateFlags are the flags of spemu generate
+type generateFlags struct {
+	conn       *connectionFlags
+	rows       *string
+	seed       *int64
+	outputFile *string
+	verbose    *bool
+}
+
+func addGenerateFlags(fs *flag.FlagSet) *generateFlags {
+	return &generateFlags{
+		conn:       addConnectionFlags(fs),
+		rows:       fs.String("rows", "", "Rows to generate per table, e.g. users=1000,posts=5000 (required)"),
+		seed:       fs.Int64("seed", 0, "Random seed; the same seed and database produce the same rows (default: current time)"),
+		outputFile: fs.String("output", "", "Write INSERT statements to this file instead of inserting the rows"),
+		verbose:    fs.Bool("verbose", false, "Enable verbose output"),
+	}
+}
+
+func runGenerate(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	f := addGenerateFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: spemu generate --rows table=count[,table=count...] [options]
+
+Generate synthetic rows from the database schema. Values respect column
+types, STRING(n) lengths and NOT NULL constraints, and foreign key and
+interleaved columns reference existing or generated parent rows.
+
+Options:
+`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *f.rows == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	counts, err := generate.ParseCounts(*f.rows)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := f.conn.config()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg.Dialect == parser.PostgreSQL.String() {
+		fmt.Fprintf(os.Stderr, "Error: generate does not support the PostgreSQL dialect\n")
+		os.Exit(1)
+	}
+
+	isSet := false
+	fs.Visit(func(fl *flag.Flag) {
+		if fl.Name == "seed" {
+			isSet = true
+		}
+	})
+	if !isSet {
+		*f.seed = time.Now().UnixNano()
+	}
+
+	exec, err := executor.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create executor: %v", err)
+	}
+	defer exec.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	s, err := schema.Load(ctx, exec.Client())
+	if err != nil {
+		log.Fatalf("Failed to load schema: %v", err)
+	}
+
+	g := generate.New(s, *f.seed)
+	if err := g.LoadExisting(ctx, exec.Client(), counts); err != nil {
+		log.Fatalf("Failed to read existing rows: %v", err)
+	}
+
+	batches, err := g.Generate(counts)
+	if err != nil {
+		log.Fatalf("Failed to generate rows: %v", err)
+	}
+
+	if *f.verbose {
+		fmt.Printf("Generated rows with seed %d\n", *f.seed)
+		for _, batch := range batches {
+			fmt.Printf("  %s: %d rows\n", batch.Table, len(batch.Rows))
+		}
+	}
+
+	if *f.outputFile != "" {
+		if err := writeGenerated(*f.outputFile, batches); err != nil {
+			log.Fatalf("Failed to write %s: %v", *f.outputFile, err)
+		}
+		fmt.Printf("Successfully wrote %d generated rows to %s\n", totalRows(batches), *f.outputFile)
+		return
+	}
+
+	if err := generate.Insert(ctx, exec.Client(), batches, *f.verbose); err != nil {
+		log.Fatalf("Failed to insert rows: %v", err)
+	}
+	fmt.Printf("Successfully inserted %d generated rows\n", totalRows(batches))
+}
+
+func writeGenerated(path string, batches []*generate.Batch) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	if err := generate.WriteSQL(w, batches); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func totalRows(batches []*generate.Batch) int {
+	n := 0
+	for _, batch := range batches {
+		n += len(batch.Rows)
+	}
+	return n
+}
@@ -3,170 +3,187 @@ package main
 import (
 	"flag"
 	"fmt"
-	"log"
 	"os"
-
-	"github.com/nu0ma/spemu/pkg/config"
-	"github.com/nu0ma/spemu/pkg/executor"
-	"github.com/nu0ma/spemu/pkg/parser"
 )
 
 // Version is set during build time via ldflags
 var Version = "unknown"
 
 func main() {
-	var (
-		dryRun     = flag.Bool("dry-run", false, "Parse and validate DML without executing")
-		verbose    = flag.Bool("verbose", false, "Enable verbose output")
-		help       = flag.Bool("help", false, "Show help message")
-		version    = flag.Bool("version", false, "Show version information")
-		initSchema = flag.String("init-schema", "", "Initialize database with schema file (DDL)")
-		project    = flag.String("project", "", "Spanner project ID (required)")
-		instance   = flag.String("instance", "", "Spanner instance ID (required)")
-		database   = flag.String("database", "", "Spanner database ID (required)")
-		port       = flag.String("port", "9010", "Spanner emulator port (default: 9010)")
-	)
-	flag.Parse()
-
-	if *version {
+	if len(os.Args) > 1 {
+		if cmd := findCommand(commands, os.Args[1]); cmd != nil {
+			cmd.execute("spemu "+cmd.name, os.Args[2:])
+			return
+		}
+	}
+
+	// Without a command, spemu accepts the flag form of earlier versions
+	runLegacy(os.Args[1:])
+}
+
+// legacyFlags are the flags of the form spemu [options] <dml-file>..., which
+// is an alias for spemu seed, or spemu schema init with --init-schema
+type legacyFlags struct {
+	seed       *seedFlags
+	initSchema *string
+	help       *bool
+	version    *bool
+}
+
+func addLegacyFlags(fs *flag.FlagSet) *legacyFlags {
+	return &legacyFlags{
+		seed:       addSeedFlags(fs),
+		initSchema: fs.String("init-schema", "", "Initialize database with schema file (DDL)"),
+		help:       fs.Bool("help", false, "Show help message"),
+		version:    fs.Bool("version", false, "Show version information"),
+	}
+}
+
+func runLegacy(args []string) {
+	f := addLegacyFlags(flag.CommandLine)
+	flag.CommandLine.Parse(args)
+
+	if *f.version {
 		fmt.Printf("spemu version %s\n", Version)
 		return
 	}
 
-	if *help {
+	if *f.help {
 		showHelp()
 		return
 	}
 
-	// Handle schema initialization mode
-	if *initSchema != "" {
-		// In schema initialization mode, no DML file is required
-		if *project == "" || *instance == "" || *database == "" {
-			fmt.Fprintf(os.Stderr, "Error: --project, --instance, and --database are required for schema initialization\n")
-			os.Exit(1)
+	// Handle schema initialization mode. With --watch the schema is applied
+	// before every load instead.
+	if *f.initSchema != "" {
+		if !*f.seed.watch {
+			cfg, err := f.seed.conn.config()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			initSchema(cfg, *f.initSchema, *f.seed.verbose)
+			return
 		}
+		*f.seed.schemaFile = *f.initSchema
+	}
 
-		emulatorHost := fmt.Sprintf("localhost:%s", *port)
-		cfg := &config.Config{
-			ProjectID:    *project,
-			InstanceID:   *instance,
-			DatabaseID:   *database,
-			EmulatorHost: emulatorHost,
-		}
+	if flag.NArg() == 0 && !(*f.seed.watch && *f.seed.schemaFile != "") {
+		fmt.Fprintf(os.Stderr, "Usage: spemu <command> [options]\n")
+		fmt.Fprintf(os.Stderr, "       spemu [options] <dml-file>...\n")
+		fmt.Fprintf(os.Stderr, "Run 'spemu help' for more information.\n")
+		os.Exit(1)
+	}
+	seed(f.seed, flag.Args())
+}
 
-		if *verbose {
-			fmt.Printf("Initializing schema from: %s\n", *initSchema)
-			fmt.Printf("Configuration: %+v\n", cfg)
-		}
+func showHelp() {
+	fmt.Printf(`spemu - Spanner Emulator DML Inserter
 
-		err := executor.InitializeSchema(cfg, *initSchema, *verbose)
-		if err != nil {
-			log.Fatalf("Failed to initialize schema: %v", err)
-		}
+Usage:
+  spemu <command> [options] [arguments]
 
-		fmt.Printf("Schema initialization completed successfully\n")
-		return
+Commands:
+`)
+	for _, cmd := range commands {
+		if len(cmd.subcommands) == 0 {
+			fmt.Printf("  %-18s %s\n", cmd.name, cmd.summary)
+			continue
+		}
+		for _, sub := range cmd.subcommands {
+			fmt.Printf("  %-18s %s\n", cmd.name+" "+sub.name, sub.summary)
+		}
 	}
+	fmt.Printf(`
+Run 'spemu help <command>' for the usage and options of a command. All
+commands that connect to the emulator take --project, --instance,
+--database, --port and --dialect.
 
-	// Normal DML execution mode
-	args := flag.Args()
-	if len(args) != 1 {
-		fmt.Fprintf(os.Stderr, "Usage: spemu [options] <dml-file>\n")
-		fmt.Fprintf(os.Stderr, "       spemu [options] --init-schema <schema-file>\n")
-		fmt.Fprintf(os.Stderr, "Run 'spemu --help' for more information.\n")
-		os.Exit(1)
-	}
+The flag form of earlier versions is still supported:
+  spemu [options] <dml-file>...                 # Same as spemu seed
+  spemu [options] --init-schema <schema-file>   # Same as spemu schema init
 
-	dmlFile := args[0]
+Examples:
+  # Initialize database schema
+  spemu schema init --project=test-project --instance=test-instance --database=test-database ./schema.sql
 
-	// Validate required flags
-	if *project == "" {
-		fmt.Fprintf(os.Stderr, "Error: --project is required\n")
-		os.Exit(1)
-	}
-	if *instance == "" {
-		fmt.Fprintf(os.Stderr, "Error: --instance is required\n")
-		os.Exit(1)
-	}
-	if *database == "" {
-		fmt.Fprintf(os.Stderr, "Error: --database is required\n")
-		os.Exit(1)
-	}
+  # Execute DML statements
+  spemu seed --project=test-project --instance=test-instance --database=test-database ./seed.sql
+  spemu seed --project=my-proj --instance=my-inst --database=my-db --dry-run ./test.sql
+  spemu seed --project=test --instance=test --database=test --port=9020 ./users.sql
 
-	emulatorHost := fmt.Sprintf("localhost:%s", *port)
-	cfg := &config.Config{
-		ProjectID:    *project,
-		InstanceID:   *instance,
-		DatabaseID:   *database,
-		EmulatorHost: emulatorHost,
-	}
+  # Load independent fixture files concurrently
+  spemu seed --project=test-project --instance=test-instance --database=test-database --parallel=4 ./fixtures/*.sql
 
-	if *verbose {
-		fmt.Printf("Configuration: %+v\n", cfg)
-		fmt.Printf("DML file: %s\n", dmlFile)
-	}
+  # Retry longer when other tests hold the emulator and print a JSON summary
+  spemu seed --project=test-project --instance=test-instance --database=test-database \
+    --max-attempts=10 --format=json ./seed.sql
 
-	statements, err := parser.ParseDMLFile(dmlFile)
-	if err != nil {
-		log.Fatalf("Failed to parse DML file: %v", err)
-	}
+  # Apply plain INSERT statements as mutations
+  spemu seed --project=test-project --instance=test-instance --database=test-database --mode=mutations ./seed.sql
 
-	if *verbose {
-		fmt.Printf("Parsed %d DML statements\n", len(statements))
-	}
+  # Load fixtures organized by feature in foreign key order
+  spemu seed --project=test-project --instance=test-instance --database=test-database --reorder ./fixtures/*.sql
 
-	if *dryRun {
-		fmt.Printf("Dry run: %d statements would be executed\n", len(statements))
-		for i, stmt := range statements {
-			limit := 50
-			if len(stmt) < limit {
-				limit = len(stmt)
-			}
-			fmt.Printf("Statement %d: %s\n", i+1, stmt[:limit]+"...")
-		}
-		return
-	}
+  # Recreate the database and reload the fixtures on every change
+  spemu seed --project=test-project --instance=test-instance --database=test-database \
+    --schema=./schema.sql --watch ./seed.sql
 
-	exec, err := executor.New(cfg)
-	if err != nil {
-		log.Fatalf("Failed to create executor: %v", err)
-	}
-	defer exec.Close()
+  # Empty all tables between test runs, or recreate the database after a schema change
+  spemu reset --project=test-project --instance=test-instance --database=test-database
+  spemu reset --project=test-project --instance=test-instance --database=test-database --schema=./schema.sql
 
-	err = exec.ExecuteStatements(statements, *verbose)
-	if err != nil {
-		log.Fatalf("Failed to execute statements: %v", err)
-	}
+  # Create a PostgreSQL-dialect database and load it
+  spemu schema init --project=test-project --instance=test-instance --database=pg-database --dialect=postgresql ./pg_schema.sql
+  spemu seed --project=test-project --instance=test-instance --database=pg-database --dialect=postgresql ./pg_seed.sql
 
-	fmt.Printf("Successfully executed %d statements\n", len(statements))
-}
+  # Execute only the statements tagged users-seed
+  spemu seed --project=test-project --instance=test-instance --database=test-database --tag=users-seed ./seed.sql
 
-func showHelp() {
-	fmt.Printf(`spemu - Spanner Emulator DML Inserter
+  # Report every failing statement of a broken fixture
+  spemu seed --project=test-project --instance=test-instance --database=test-database --continue-on-error ./seed.sql
 
-Usage:
-  spemu [options] <dml-file>                    # Execute DML statements
-  spemu [options] --init-schema <schema-file>   # Initialize database with schema
-
-Options:
-  --project        Spanner project ID (required)
-  --instance       Spanner instance ID (required)
-  --database       Spanner database ID (required)
-  --port           Spanner emulator port (default: 9010)
-  --init-schema    Initialize database with schema file (DDL)
-  --dry-run        Parse and validate DML without executing
-  --verbose        Enable verbose output
-  --version        Show version information
-  --help           Show this help message
+  # Clean up large tables with partitioned DML
+  spemu seed --project=test-project --instance=test-instance --database=test-database --partitioned ./cleanup.sql
 
-Examples:
-  # Initialize database schema
-  spemu --project=test-project --instance=test-instance --database=test-database --init-schema=./schema.sql
+  # Bind @name parameters instead of inlining literals
+  spemu seed --project=test-project --instance=test-instance --database=test-database \
+    --params-file=./seed.params.yaml --param=id=1:INT64 ./seed.sql
 
-  # Execute DML statements
-  spemu --project=test-project --instance=test-instance --database=test-database ./seed.sql
-  spemu --project=my-proj --instance=my-inst --database=my-db --dry-run ./test.sql
-  spemu --project=test --instance=test --database=test --port=9020 ./users.sql
+  # Execute a templated seed file
+  spemu seed --project=test-project --instance=test-instance --database=test-database --var=tenant=acme ./seed.sql.tmpl
+
+  # Check what a seed produced
+  spemu query --project=test-project --instance=test-instance --database=test-database "SELECT * FROM users"
+  spemu query --project=test-project --instance=test-instance --database=test-database --format=json \
+    --param=id=1:INT64 "SELECT * FROM posts WHERE user_id = @id"
+
+  # Check the seeded data against expectations
+  spemu verify --project=test-project --instance=test-instance --database=test-database ./expect.yaml
+
+  # Generate synthetic data, inserting it directly or writing a seed file
+  spemu generate --project=test-project --instance=test-instance --database=test-database --rows=users=1000,posts=5000
+  spemu generate --project=test-project --instance=test-instance --database=test-database --rows=users=100 \
+    --seed=42 --output=./generated.sql
+
+  # Print and apply the DDL that brings the database in line with a schema file
+  spemu schema diff --project=test-project --instance=test-instance --database=test-database --schema=./schema.sql --apply
+
+  # Snapshot the schema of a database built up over several runs
+  spemu schema dump --project=test-project --instance=test-instance --database=test-database --output=./schema.sql
+
+  # Save a seeded baseline once and restore it before every test run
+  spemu snapshot save --project=test-project --instance=test-instance --database=test-database baseline
+  spemu snapshot restore --project=test-project --instance=test-instance --database=test-database baseline
+
+  # Create copies of a seeded database for parallel test shards
+  spemu clone --project=test-project --instance=test-instance --database=test-database --to=test-db-2,test-db-3
+
+  # Explore data interactively
+  spemu shell --project=test-project --instance=test-instance --database=test-database
+
+  # Enable shell completion
+  source <(spemu completion bash)
 
 `)
 }
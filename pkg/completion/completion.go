@@ -0,0 +1,283 @@
+// Package completion generates shell completion scripts for bash, zsh and
+// fish from a description of the commands and flags of a program.
+//
+// The scripts complete command names, subcommand names and flags for the
+// command typed so far, and fall back to file names for arguments.
+package completion
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Command describes a command, its flags and its subcommands. The root
+// command is the program itself.
+type Command struct {
+	Name     string
+	Summary  string
+	Flags    []Flag
+	Commands []*Command
+}
+
+// Flag describes a flag of a command.
+type Flag struct {
+	Name  string
+	Usage string
+}
+
+// Option returns the flag as typed on the command line, e.g. --verbose.
+func (f Flag) Option() string {
+	if len(f.Name) == 1 {
+		return "-" + f.Name
+	}
+	return "--" + f.Name
+}
+
+// FlagsOf returns the flags defined in fs, sorted by name.
+func FlagsOf(fs *flag.FlagSet) []Flag {
+	var flags []Flag
+	fs.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, Flag{Name: f.Name, Usage: f.Usage})
+	})
+	return flags
+}
+
+// Shells are the shells completion scripts can be generated for.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// Write writes the completion script of root for the named shell.
+func Write(w io.Writer, shell string, root *Command) error {
+	switch shell {
+	case "bash":
+		return Bash(w, root)
+	case "zsh":
+		return Zsh(w, root)
+	case "fish":
+		return Fish(w, root)
+	}
+	return fmt.Errorf("unsupported shell %q (expected %s)", shell, strings.Join(Shells, ", "))
+}
+
+// node is a command together with the words that invoke it, e.g.
+// "spemu schema diff"
+type node struct {
+	path string
+	cmd  *Command
+}
+
+// walk returns root and all its subcommands, parents before children.
+func walk(root *Command) []node {
+	var nodes []node
+	var visit func(path string, cmd *Command)
+	visit = func(path string, cmd *Command) {
+		nodes = append(nodes, node{path, cmd})
+		for _, sub := range cmd.Commands {
+			visit(path+" "+sub.Name, sub)
+		}
+	}
+	visit(root.Name, root)
+	return nodes
+}
+
+// subcommandPaths returns the paths of all commands but the root.
+func subcommandPaths(root *Command) []string {
+	var paths []string
+	for _, n := range walk(root)[1:] {
+		paths = append(paths, n.path)
+	}
+	return paths
+}
+
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// function returns the name of the completion function for root.
+func function(root *Command) string {
+	return "_" + nonIdentifier.ReplaceAllString(root.Name, "_")
+}
+
+// quote quotes s for the shell with single quotes.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// quoteFish quotes s for fish, which escapes single quotes with a backslash.
+func quoteFish(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// Bash writes a bash completion script for root.
+func Bash(w io.Writer, root *Command) error {
+	var b strings.Builder
+	fn := function(root)
+
+	fmt.Fprintf(&b, "# bash completion for %s\n\n", root.Name)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	fmt.Fprintf(&b, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\" cmd=%s i\n", quote(root.Name))
+	if paths := subcommandPaths(root); len(paths) > 0 {
+		b.WriteString("    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+		b.WriteString("        case \"$cmd ${COMP_WORDS[i]}\" in\n")
+		fmt.Fprintf(&b, "            %s)\n", casePattern(paths, quote))
+		b.WriteString("                cmd=\"$cmd ${COMP_WORDS[i]}\"\n")
+		b.WriteString("                ;;\n")
+		b.WriteString("        esac\n")
+		b.WriteString("    done\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("    local commands=\"\" flags=\"\"\n")
+	b.WriteString("    case \"$cmd\" in\n")
+	for _, n := range walk(root) {
+		fmt.Fprintf(&b, "        %s)\n", quote(n.path))
+		if len(n.cmd.Commands) > 0 {
+			names := make([]string, len(n.cmd.Commands))
+			for i, sub := range n.cmd.Commands {
+				names[i] = sub.Name
+			}
+			fmt.Fprintf(&b, "            commands=%s\n", quote(strings.Join(names, " ")))
+		}
+		if len(n.cmd.Flags) > 0 {
+			options := make([]string, len(n.cmd.Flags))
+			for i, f := range n.cmd.Flags {
+				options[i] = f.Option()
+			}
+			fmt.Fprintf(&b, "            flags=%s\n", quote(strings.Join(options, " ")))
+		}
+		b.WriteString("            ;;\n")
+	}
+	b.WriteString("    esac\n\n")
+
+	// Without matches, -o default completes file names
+	b.WriteString("    if [[ \"$cur\" == -* ]]; then\n")
+	b.WriteString("        COMPREPLY=($(compgen -W \"$flags\" -- \"$cur\"))\n")
+	b.WriteString("    elif [[ -n \"$commands\" ]]; then\n")
+	b.WriteString("        COMPREPLY=($(compgen -W \"$commands\" -- \"$cur\"))\n")
+	b.WriteString("    fi\n")
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "complete -o default -F %s %s\n", fn, root.Name)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Zsh writes a zsh completion script for root. The script can be sourced
+// or installed as _<name> in a directory of $fpath.
+func Zsh(w io.Writer, root *Command) error {
+	var b strings.Builder
+	fn := function(root)
+
+	fmt.Fprintf(&b, "#compdef %s\n\n", root.Name)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	fmt.Fprintf(&b, "  local cmd=%s i\n", quote(root.Name))
+	if paths := subcommandPaths(root); len(paths) > 0 {
+		b.WriteString("  for ((i = 2; i < CURRENT; i++)); do\n")
+		b.WriteString("    case \"$cmd ${words[i]}\" in\n")
+		fmt.Fprintf(&b, "      (%s)\n", casePattern(paths, quote))
+		b.WriteString("        cmd=\"$cmd ${words[i]}\"\n")
+		b.WriteString("        ;;\n")
+		b.WriteString("    esac\n")
+		b.WriteString("  done\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("  local -a commands flags\n")
+	b.WriteString("  case \"$cmd\" in\n")
+	for _, n := range walk(root) {
+		fmt.Fprintf(&b, "    (%s)\n", quote(n.path))
+		if len(n.cmd.Commands) > 0 {
+			b.WriteString("      commands=(\n")
+			for _, sub := range n.cmd.Commands {
+				fmt.Fprintf(&b, "        %s\n", quote(sub.Name+":"+sub.Summary))
+			}
+			b.WriteString("      )\n")
+		}
+		if len(n.cmd.Flags) > 0 {
+			b.WriteString("      flags=(\n")
+			for _, f := range n.cmd.Flags {
+				fmt.Fprintf(&b, "        %s\n", quote(f.Option()+":"+f.Usage))
+			}
+			b.WriteString("      )\n")
+		}
+		b.WriteString("      ;;\n")
+	}
+	b.WriteString("  esac\n\n")
+
+	b.WriteString("  if [[ $PREFIX == -* ]]; then\n")
+	b.WriteString("    _describe -t flags 'flag' flags\n")
+	b.WriteString("  elif (( $#commands )); then\n")
+	b.WriteString("    _describe -t commands 'command' commands || _files\n")
+	b.WriteString("  else\n")
+	b.WriteString("    _files\n")
+	b.WriteString("  fi\n")
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(&b, "if [[ $funcstack[1] == %s ]]; then\n", fn)
+	fmt.Fprintf(&b, "  %s \"$@\"\n", fn)
+	b.WriteString("else\n")
+	fmt.Fprintf(&b, "  compdef %s %s\n", fn, root.Name)
+	b.WriteString("fi\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Fish writes a fish completion script for root.
+func Fish(w io.Writer, root *Command) error {
+	var b strings.Builder
+	fn := "_" + function(root)
+
+	fmt.Fprintf(&b, "# fish completion for %s\n\n", root.Name)
+	fmt.Fprintf(&b, "function %s_command\n", fn)
+	b.WriteString("    set -l words (commandline -opc)\n")
+	b.WriteString("    set -e words[1]\n")
+	fmt.Fprintf(&b, "    set -l cmd %s\n", quoteFish(root.Name))
+	if paths := subcommandPaths(root); len(paths) > 0 {
+		b.WriteString("    for word in $words\n")
+		b.WriteString("        switch \"$cmd $word\"\n")
+		fmt.Fprintf(&b, "            case %s\n", strings.Join(quoteAll(paths, quoteFish), " "))
+		b.WriteString("                set cmd \"$cmd $word\"\n")
+		b.WriteString("        end\n")
+		b.WriteString("    end\n")
+	}
+	b.WriteString("    echo $cmd\n")
+	b.WriteString("end\n\n")
+
+	fmt.Fprintf(&b, "function %s_using\n", fn)
+	fmt.Fprintf(&b, "    test (%s_command) = \"$argv\"\n", fn)
+	b.WriteString("end\n")
+
+	for _, n := range walk(root) {
+		b.WriteString("\n")
+		condition := quoteFish(fn + "_using " + n.path)
+		for _, sub := range n.cmd.Commands {
+			fmt.Fprintf(&b, "complete -c %s -n %s -a %s -d %s\n",
+				root.Name, condition, quoteFish(sub.Name), quoteFish(sub.Summary))
+		}
+		for _, f := range n.cmd.Flags {
+			option := "-l"
+			if len(f.Name) == 1 {
+				option = "-o"
+			}
+			fmt.Fprintf(&b, "complete -c %s -n %s %s %s -d %s\n",
+				root.Name, condition, option, quoteFish(f.Name), quoteFish(f.Usage))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// casePattern returns a case pattern matching any of the values.
+func casePattern(values []string, quote func(string) string) string {
+	return strings.Join(quoteAll(values, quote), "|")
+}
+
+func quoteAll(values []string, quote func(string) string) []string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = quote(v)
+	}
+	return quoted
+}
@@ -0,0 +1,165 @@
+package completion
+
+import (
+	"flag"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testCommand() *Command {
+	return &Command{
+		Name:  "spemu",
+		Flags: []Flag{{Name: "verbose", Usage: "Enable verbose output"}},
+		Commands: []*Command{
+			{
+				Name:    "seed",
+				Summary: "Execute DML files",
+				Flags: []Flag{
+					{Name: "dry-run", Usage: "Parse without executing"},
+					{Name: "f", Usage: "Read the file"},
+				},
+			},
+			{
+				Name:    "schema",
+				Summary: "Manage the schema",
+				Commands: []*Command{
+					{
+						Name:    "diff",
+						Summary: "Print the database's changes",
+						Flags:   []Flag{{Name: "apply", Usage: "Apply the statements"}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestFlagsOf(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Bool("verbose", false, "Enable verbose output")
+	fs.String("f", "", "Read the file")
+	fs.Int("parallel", 0, "Concurrency")
+
+	expected := []Flag{
+		{Name: "f", Usage: "Read the file"},
+		{Name: "parallel", Usage: "Concurrency"},
+		{Name: "verbose", Usage: "Enable verbose output"},
+	}
+	if got := FlagsOf(fs); !reflect.DeepEqual(got, expected) {
+		t.Errorf("FlagsOf() = %v, want %v", got, expected)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		shell    string
+		expected []string
+		wantErr  bool
+	}{
+		{
+			shell: "bash",
+			expected: []string{
+				"_spemu() {",
+				"'spemu seed'|'spemu schema'|'spemu schema diff')",
+				"commands='seed schema'",
+				"flags='--verbose'",
+				"'spemu seed')\n            flags='--dry-run -f'",
+				"'spemu schema')\n            commands='diff'",
+				"'spemu schema diff')\n            flags='--apply'",
+				"complete -o default -F _spemu spemu",
+			},
+		},
+		{
+			shell: "zsh",
+			expected: []string{
+				"#compdef spemu",
+				"('spemu seed'|'spemu schema'|'spemu schema diff')",
+				"'seed:Execute DML files'",
+				"'--verbose:Enable verbose output'",
+				"'-f:Read the file'",
+				`'diff:Print the database'\''s changes'`,
+				"compdef _spemu spemu",
+			},
+		},
+		{
+			shell: "fish",
+			expected: []string{
+				"function __spemu_command",
+				"case 'spemu seed' 'spemu schema' 'spemu schema diff'",
+				"complete -c spemu -n '__spemu_using spemu' -a 'seed' -d 'Execute DML files'",
+				"complete -c spemu -n '__spemu_using spemu' -l 'verbose' -d 'Enable verbose output'",
+				"complete -c spemu -n '__spemu_using spemu seed' -o 'f' -d 'Read the file'",
+				`complete -c spemu -n '__spemu_using spemu schema' -a 'diff' -d 'Print the database\'s changes'`,
+			},
+		},
+		{
+			shell:   "tcsh",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var b strings.Builder
+			err := Write(&b, tt.shell, testCommand())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Write() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			script := b.String()
+			for _, want := range tt.expected {
+				if !strings.Contains(script, want) {
+					t.Errorf("script does not contain %q:\n%s", want, script)
+				}
+			}
+		})
+	}
+}
+
+// TestScriptSyntax checks the scripts with the shells that are installed
+func TestScriptSyntax(t *testing.T) {
+	shells := map[string][]string{
+		"bash": {"bash", "-n"},
+		"zsh":  {"zsh", "-n"},
+		"fish": {"fish", "--no-execute"},
+	}
+
+	for _, shell := range Shells {
+		t.Run(shell, func(t *testing.T) {
+			command := shells[shell]
+			path, err := exec.LookPath(command[0])
+			if err != nil {
+				t.Skipf("%s is not installed", command[0])
+			}
+
+			var b strings.Builder
+			if err := Write(&b, shell, testCommand()); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			cmd := exec.Command(path, command[1:]...)
+			cmd.Stdin = strings.NewReader(b.String())
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Errorf("%s rejected the script: %v\n%s", shell, err, out)
+			}
+		})
+	}
+}
+
+func TestBash_WithoutSubcommands(t *testing.T) {
+	var b strings.Builder
+	err := Bash(&b, &Command{Name: "tool", Flags: []Flag{{Name: "verbose"}}})
+	if err != nil {
+		t.Fatalf("Bash() error = %v", err)
+	}
+	if strings.Contains(b.String(), "for ((") {
+		t.Errorf("script walks subcommands of a command without any:\n%s", b.String())
+	}
+}
@@ -7,6 +7,9 @@ type Config struct {
 	ProjectID    string
 	InstanceID   string
 	DatabaseID   string
+	// Dialect is the SQL dialect of the database, googlesql (the default)
+	// or postgresql.
+	Dialect string
 }
 
 func (c *Config) DatabasePath() string {
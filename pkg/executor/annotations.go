@@ -0,0 +1,153 @@
+package executor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"github.com/nu0ma/spemu/pkg/parser"
+	"google.golang.org/grpc/codes"
+)
+
+// Annotations are the execution controls of a statement, written as
+// directives before it:
+//
+//	-- @skip                        the statement is not executed
+//	-- @tag users-seed              the statement belongs to a tag, see Executor.Tags
+//	-- @expect-rows 3               the statement must modify exactly 3 rows
+//	-- @expect-error ALREADY_EXISTS the statement must fail with the gRPC code
+//
+// The code of @expect-error is optional; without it any error is expected.
+type Annotations struct {
+	Skip bool
+	Tags []string
+	// ExpectRows is the expected number of modified rows, or -1.
+	ExpectRows int64
+	// ExpectError is set if the statement is expected to fail, with
+	// ExpectCode the expected code or codes.OK for any error.
+	ExpectError bool
+	ExpectCode  codes.Code
+}
+
+// ParseAnnotations reads the annotations of stmt.
+func ParseAnnotations(stmt parser.Statement) (*Annotations, error) {
+	a := &Annotations{ExpectRows: -1}
+	for _, d := range stmt.Annotations {
+		switch d.Name {
+		case "skip":
+			a.Skip = true
+		case "tag":
+			tags := strings.FieldsFunc(d.Arg, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+			if len(tags) == 0 {
+				return nil, fmt.Errorf("%s: @tag requires a tag name", directivePosition(stmt, d))
+			}
+			a.Tags = append(a.Tags, tags...)
+		case "expect-rows":
+			n, err := strconv.ParseInt(d.Arg, 10, 64)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("%s: @expect-rows requires a row count, got %q", directivePosition(stmt, d), d.Arg)
+			}
+			a.ExpectRows = n
+		case "expect-error":
+			a.ExpectError = true
+			a.ExpectCode = codes.OK
+			if d.Arg == "" {
+				continue
+			}
+			code, err := parseCode(d.Arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: @expect-error: %w", directivePosition(stmt, d), err)
+			}
+			a.ExpectCode = code
+		case "partitioned", "group", "depends-on":
+			// Read by IsPartitioned and when grouping files
+		default:
+			return nil, fmt.Errorf("%s: unknown annotation @%s", directivePosition(stmt, d), d.Name)
+		}
+	}
+
+	if a.ExpectError && a.ExpectRows >= 0 {
+		return nil, fmt.Errorf("%s: @expect-error and @expect-rows cannot be combined", stmt.Position())
+	}
+	return a, nil
+}
+
+// directivePosition returns the position of a directive of stmt as
+// file:line, or the position of the statement if the line is not known.
+func directivePosition(stmt parser.Statement, d parser.Directive) string {
+	if d.Line == 0 {
+		return stmt.Position()
+	}
+	return parser.Statement{File: stmt.File, Line: d.Line}.Position()
+}
+
+// codeNames maps gRPC code names, upper-cased and without underscores, to
+// their codes. The Go names of the codes, e.g. AlreadyExists, and the
+// canonical names, e.g. ALREADY_EXISTS, normalize to the same key.
+var codeNames = func() map[string]codes.Code {
+	names := map[string]codes.Code{
+		// The canonical name of Canceled is spelled CANCELLED
+		"CANCELLED": codes.Canceled,
+	}
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		names[normalizeCode(c.String())] = c
+	}
+	return names
+}()
+
+func normalizeCode(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "_", ""))
+}
+
+// parseCode parses a gRPC code name such as ALREADY_EXISTS or AlreadyExists.
+func parseCode(name string) (codes.Code, error) {
+	code, ok := codeNames[normalizeCode(name)]
+	if !ok || code == codes.OK {
+		return code, fmt.Errorf("unknown error code %s", name)
+	}
+	return code, nil
+}
+
+// Selected reports whether a statement with these annotations is executed
+// when only statements with one of tags are selected. An empty tags list
+// selects all statements.
+func (a *Annotations) Selected(tags []string) bool {
+	if a.Skip {
+		return false
+	}
+	if len(tags) == 0 {
+		return true
+	}
+	for _, tag := range a.Tags {
+		for _, selected := range tags {
+			if tag == selected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkRows verifies the row count a statement reported.
+func (a *Annotations) checkRows(count int64) error {
+	if a.ExpectRows >= 0 && count != a.ExpectRows {
+		return fmt.Errorf("expected %d modified rows (@expect-rows), got %d", a.ExpectRows, count)
+	}
+	return nil
+}
+
+// checkError verifies the outcome of a statement expected to fail.
+func (a *Annotations) checkError(err error) error {
+	want := "an error"
+	if a.ExpectCode != codes.OK {
+		want = a.ExpectCode.String()
+	}
+	if err == nil {
+		return fmt.Errorf("expected %s (@expect-error), but the statement succeeded", want)
+	}
+	if code := spanner.ErrCode(err); a.ExpectCode != codes.OK && code != a.ExpectCode {
+		return fmt.Errorf("expected %s (@expect-error), got %s: %w", want, code, err)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nu0ma/spemu/pkg/parser"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseAnnotations(t *testing.T) {
+	directive := func(name, arg string) parser.Directive {
+		return parser.Directive{Name: name, Arg: arg, Line: 1}
+	}
+
+	tests := []struct {
+		name        string
+		annotations []parser.Directive
+		expected    *Annotations
+		message     string
+	}{
+		{name: "none", expected: &Annotations{ExpectRows: -1}},
+		{
+			name:        "all",
+			annotations: []parser.Directive{directive("skip", ""), directive("tag", "users-seed, smoke"), directive("tag", "nightly"), directive("expect-rows", "3")},
+			expected:    &Annotations{Skip: true, Tags: []string{"users-seed", "smoke", "nightly"}, ExpectRows: 3},
+		},
+		{
+			name:        "expect any error",
+			annotations: []parser.Directive{directive("expect-error", "")},
+			expected:    &Annotations{ExpectRows: -1, ExpectError: true, ExpectCode: codes.OK},
+		},
+		{
+			name:        "expect error code",
+			annotations: []parser.Directive{directive("expect-error", "ALREADY_EXISTS")},
+			expected:    &Annotations{ExpectRows: -1, ExpectError: true, ExpectCode: codes.AlreadyExists},
+		},
+		{
+			name:        "expect error Go code name",
+			annotations: []parser.Directive{directive("expect-error", "FailedPrecondition")},
+			expected:    &Annotations{ExpectRows: -1, ExpectError: true, ExpectCode: codes.FailedPrecondition},
+		},
+		{name: "unknown code", annotations: []parser.Directive{directive("expect-error", "NOPE")}, message: "seed.sql:1: @expect-error: unknown error code NOPE"},
+		{name: "unknown annotation", annotations: []parser.Directive{directive("expect-eror", "")}, message: "seed.sql:1: unknown annotation @expect-eror"},
+		{
+			name:        "annotations read elsewhere",
+			annotations: []parser.Directive{directive("partitioned", ""), directive("group", "users"), directive("depends-on", "users.sql")},
+			expected:    &Annotations{ExpectRows: -1},
+		},
+		{name: "invalid row count", annotations: []parser.Directive{directive("expect-rows", "three")}, message: "seed.sql:1: @expect-rows requires a row count"},
+		{name: "empty tag", annotations: []parser.Directive{directive("tag", "")}, message: "seed.sql:1: @tag requires a tag name"},
+		{
+			name:        "error and rows",
+			annotations: []parser.Directive{directive("expect-error", ""), directive("expect-rows", "1")},
+			message:     "seed.sql:2: @expect-error and @expect-rows cannot be combined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseAnnotations(parser.Statement{SQL: "DELETE FROM t WHERE true", File: "seed.sql", Line: 2, Annotations: tt.annotations})
+			if tt.message != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.message) {
+					t.Errorf("ParseAnnotations() error = %v, expected it to contain %q", err, tt.message)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAnnotations() error = %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseAnnotations() = %+v, expected %+v", result, tt.expected)
+			}
+		})
+	}
+
+	// Content that was not read from a file has no file name
+	stmt := parser.Statement{SQL: "DELETE FROM t WHERE true", Line: 2, Annotations: []parser.Directive{directive("expect-eror", "")}}
+	if _, err := ParseAnnotations(stmt); err == nil || !strings.HasPrefix(err.Error(), "line 1: unknown annotation") {
+		t.Errorf("ParseAnnotations() error = %v, expected it to start with %q", err, "line 1: unknown annotation")
+	}
+}
+
+func TestParseCode(t *testing.T) {
+	tests := []struct {
+		code  codes.Code
+		names []string
+	}{
+		{codes.Canceled, []string{"CANCELLED", "CANCELED", "Canceled", "cancelled"}},
+		{codes.Unknown, []string{"UNKNOWN", "Unknown", "unknown"}},
+		{codes.InvalidArgument, []string{"INVALID_ARGUMENT", "InvalidArgument", "invalid_argument"}},
+		{codes.DeadlineExceeded, []string{"DEADLINE_EXCEEDED", "DeadlineExceeded", "deadline_exceeded"}},
+		{codes.NotFound, []string{"NOT_FOUND", "NotFound", "not_found"}},
+		{codes.AlreadyExists, []string{"ALREADY_EXISTS", "AlreadyExists", "already_exists"}},
+		{codes.PermissionDenied, []string{"PERMISSION_DENIED", "PermissionDenied", "permission_denied"}},
+		{codes.ResourceExhausted, []string{"RESOURCE_EXHAUSTED", "ResourceExhausted", "resource_exhausted"}},
+		{codes.FailedPrecondition, []string{"FAILED_PRECONDITION", "FailedPrecondition", "failed_precondition"}},
+		{codes.Aborted, []string{"ABORTED", "Aborted", "aborted"}},
+		{codes.OutOfRange, []string{"OUT_OF_RANGE", "OutOfRange", "out_of_range"}},
+		{codes.Unimplemented, []string{"UNIMPLEMENTED", "Unimplemented", "unimplemented"}},
+		{codes.Internal, []string{"INTERNAL", "Internal", "internal"}},
+		{codes.Unavailable, []string{"UNAVAILABLE", "Unavailable", "unavailable"}},
+		{codes.DataLoss, []string{"DATA_LOSS", "DataLoss", "data_loss"}},
+		{codes.Unauthenticated, []string{"UNAUTHENTICATED", "Unauthenticated", "unauthenticated"}},
+	}
+
+	for _, tt := range tests {
+		for _, name := range tt.names {
+			t.Run(name, func(t *testing.T) {
+				code, err := parseCode(name)
+				if err != nil {
+					t.Fatalf("parseCode(%q) error = %v", name, err)
+				}
+				if code != tt.code {
+					t.Errorf("parseCode(%q) = %v, expected %v", name, code, tt.code)
+				}
+			})
+		}
+	}
+
+	// OK is not an error, and @expect-error without a code expects any error
+	for _, name := range []string{"OK", "Ok", "ok", "NOPE", "ALREADY-EXISTS", ""} {
+		t.Run("invalid "+name, func(t *testing.T) {
+			if _, err := parseCode(name); err == nil {
+				t.Errorf("parseCode(%q) expected error, got nil", name)
+			}
+		})
+	}
+}
+
+func TestAnnotations_Selected(t *testing.T) {
+	tagged := &Annotations{Tags: []string{"users-seed"}, ExpectRows: -1}
+	untagged := &Annotations{ExpectRows: -1}
+	skipped := &Annotations{Skip: true, Tags: []string{"users-seed"}, ExpectRows: -1}
+
+	if !tagged.Selected(nil) || !untagged.Selected(nil) {
+		t.Error("Selected(nil) = false, expected all statements to be selected")
+	}
+	if !tagged.Selected([]string{"other", "users-seed"}) {
+		t.Error("tagged statement is not selected by its tag")
+	}
+	if untagged.Selected([]string{"users-seed"}) {
+		t.Error("untagged statement is selected by a tag")
+	}
+	if skipped.Selected(nil) || skipped.Selected([]string{"users-seed"}) {
+		t.Error("@skip statement is selected")
+	}
+}
+
+func TestAnnotations_Check(t *testing.T) {
+	a := &Annotations{ExpectRows: 2}
+	if err := a.checkRows(2); err != nil {
+		t.Errorf("checkRows(2) error = %v", err)
+	}
+	if err := a.checkRows(3); err == nil || !strings.Contains(err.Error(), "expected 2 modified rows (@expect-rows), got 3") {
+		t.Errorf("checkRows(3) error = %v", err)
+	}
+
+	exists := fmt.Errorf("failed: %w", status.Error(codes.AlreadyExists, "row exists"))
+	a = &Annotations{ExpectRows: -1, ExpectError: true, ExpectCode: codes.AlreadyExists}
+	if err := a.checkError(exists); err != nil {
+		t.Errorf("checkError(AlreadyExists) error = %v", err)
+	}
+	if err := a.checkError(status.Error(codes.InvalidArgument, "syntax")); err == nil || !strings.Contains(err.Error(), "expected AlreadyExists (@expect-error), got InvalidArgument") {
+		t.Errorf("checkError(InvalidArgument) error = %v", err)
+	}
+	if err := a.checkError(nil); err == nil || !strings.Contains(err.Error(), "the statement succeeded") {
+		t.Errorf("checkError(nil) error = %v", err)
+	}
+
+	a = &Annotations{ExpectRows: -1, ExpectError: true}
+	if err := a.checkError(errors.New("any failure")); err != nil {
+		t.Errorf("checkError() error = %v, expected any error to match", err)
+	}
+}
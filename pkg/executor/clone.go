@@ -0,0 +1,143 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/nu0ma/spemu/pkg/config"
+	"github.com/nu0ma/spemu/pkg/parser"
+	"github.com/nu0ma/spemu/pkg/schema"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CloneDatabase creates the database target in the instance of cfg with the
+// schema of the database described by cfg and copies all rows into it. Rows
+// are read in a single read-only transaction and inserted table by table,
+// parents and referenced tables first, with mutations committed in chunks
+// that stay within Spanner's mutation limit. The target must not exist; if
+// applying the schema or copying the rows fails, it is dropped again. Only
+// GoogleSQL databases can be cloned.
+func CloneDatabase(cfg *config.Config, target string, verbose bool) error {
+	if dialect, _ := parser.ParseDialect(cfg.Dialect); dialect == parser.PostgreSQL {
+		return fmt.Errorf("cloning is not supported with the PostgreSQL dialect")
+	}
+	if strings.EqualFold(target, cfg.DatabaseID) {
+		return fmt.Errorf("cannot clone database %s onto itself", cfg.DatabaseID)
+	}
+
+	ddl, err := GetDatabaseDDL(cfg)
+	if err != nil {
+		return err
+	}
+	s, err := schema.ParseDDL(ddl)
+	if err != nil {
+		return fmt.Errorf("failed to parse database schema: %w", err)
+	}
+	ddl, err = s.DDL()
+	if err != nil {
+		return err
+	}
+	statements := make([]parser.Statement, len(ddl))
+	for i, stmt := range ddl {
+		statements[i] = parser.Statement{SQL: stmt, Line: i + 1}
+	}
+	tables, err := s.Order()
+	if err != nil {
+		return fmt.Errorf("failed to order tables: %w", err)
+	}
+
+	targetCfg := *cfg
+	targetCfg.DatabaseID = target
+	// The schema is only loaded when the target has to be created
+	created := false
+	err = initializeSchema(&targetCfg, func(parser.Dialect) ([]parser.Statement, error) {
+		created = true
+		return statements, nil
+	}, verbose)
+	if status.Code(err) == codes.AlreadyExists || err == nil && !created {
+		return fmt.Errorf("database %s already exists", target)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := copyDatabase(cfg, &targetCfg, tables, verbose); err != nil {
+		// Drop the partial copy, so that the clone can be retried
+		if dropErr := DropDatabase(&targetCfg); dropErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to drop partial clone %s: %w", target, dropErr))
+		}
+		return err
+	}
+
+	return nil
+}
+
+// copyDatabase copies the rows of tables from the database of cfg to the
+// database of target.
+func copyDatabase(cfg, target *config.Config, tables []*schema.Table, verbose bool) error {
+	source, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	dest, err := New(target)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	txn := source.client.ReadOnlyTransaction()
+	defer txn.Close()
+
+	for _, t := range tables {
+		rows, err := copyTable(ctx, txn, dest.client, t)
+		if err != nil {
+			return err
+		}
+		if verbose {
+			fmt.Printf("Copied %d rows into %s\n", rows, t.Name)
+		}
+	}
+
+	return nil
+}
+
+// copyTable inserts the rows of a table read in txn into the database of
+// client and returns the number of rows. Generated columns are skipped.
+func copyTable(ctx context.Context, txn *spanner.ReadOnlyTransaction, client *spanner.Client, t *schema.Table) (int64, error) {
+	var columns []string
+	for _, c := range t.Columns {
+		if !c.Generated {
+			columns = append(columns, c.Name)
+		}
+	}
+
+	ins := NewInserter(client, t.Name, columns)
+	err := txn.Read(ctx, t.Name, spanner.AllKeys(), columns).Do(func(row *spanner.Row) error {
+		values := make([]interface{}, row.Size())
+		for i := range values {
+			var v spanner.GenericColumnValue
+			if err := row.Column(i, &v); err != nil {
+				return err
+			}
+			values[i] = v
+		}
+		return ins.Add(ctx, values)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy rows of %s: %w", t.Name, err)
+	}
+	if err := ins.Flush(ctx); err != nil {
+		return 0, err
+	}
+
+	return ins.Inserted, nil
+}
@@ -0,0 +1,37 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nu0ma/spemu/pkg/config"
+)
+
+func TestCloneDatabase_InvalidTarget(t *testing.T) {
+	cfg := &config.Config{
+		ProjectID:  "test-project",
+		InstanceID: "test-instance",
+		DatabaseID: "test-database",
+	}
+
+	tests := []struct {
+		name     string
+		dialect  string
+		target   string
+		expected string
+	}{
+		{"same database", "googlesql", "Test-Database", "cannot clone database test-database onto itself"},
+		{"postgresql", "postgresql", "copy", "not supported with the PostgreSQL dialect"},
+		{"postgresql alias", "pg", "copy", "not supported with the PostgreSQL dialect"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := *cfg
+			c.Dialect = tt.dialect
+			if err := CloneDatabase(&c, tt.target, false); err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("CloneDatabase() error = %v, expected %q", err, tt.expected)
+			}
+		})
+	}
+}
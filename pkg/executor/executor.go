@@ -2,9 +2,12 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/spanner"
@@ -13,13 +16,52 @@ import (
 	instance "cloud.google.com/go/spanner/admin/instance/apiv1"
 	"cloud.google.com/go/spanner/admin/instance/apiv1/instancepb"
 	"github.com/nu0ma/spemu/pkg/config"
+	"github.com/nu0ma/spemu/pkg/params"
+	"github.com/nu0ma/spemu/pkg/parser"
+	"github.com/nu0ma/spemu/pkg/schema"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Executor struct {
 	client *spanner.Client
+
+	// Params are the values of @name query parameters. Each statement is
+	// bound to the parameters it references. PostgreSQL statements reference
+	// them as $1, $2, ..., bound to the parameters p1, p2, ....
+	Params map[string]interface{}
+	// Dialect is the SQL dialect of the database, which statements are
+	// written in.
+	Dialect parser.Dialect
+	// Retry controls retries of aborted and unavailable transactions.
+	Retry RetryPolicy
+	// Partitioned executes all UPDATE and DELETE statements as partitioned
+	// DML, as if they were annotated with -- @partitioned.
+	Partitioned bool
+	// Mode selects whether INSERT statements are applied as mutations.
+	Mode Mode
+	// ContinueOnError executes each statement in a transaction of its own
+	// and continues after failures, which are collected in Result.Failures.
+	ContinueOnError bool
+	// Tags limits execution to statements annotated with one of the tags
+	// (-- @tag name). Other statements are skipped. All statements are
+	// executed if it is empty.
+	Tags []string
+	// Reorder sorts INSERT statements by the foreign keys and interleaving of
+	// their tables before executing them (see ReorderInserts).
+	Reorder bool
+	// Schema types the values of mutations and orders INSERT statements. It
+	// is read from the database when needed if not set.
+	Schema   *schema.Schema
+	schemaMu sync.Mutex
 }
 
 func New(cfg *config.Config) (*Executor, error) {
+	dialect, err := parser.ParseDialect(cfg.Dialect)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -32,7 +74,12 @@ func New(cfg *config.Config) (*Executor, error) {
 		return nil, fmt.Errorf("failed to create Spanner client: %w", err)
 	}
 
-	return &Executor{client: client}, nil
+	return &Executor{client: client, Retry: DefaultRetryPolicy(), Dialect: dialect}, nil
+}
+
+// Client returns the underlying Spanner client
+func (e *Executor) Client() *spanner.Client {
+	return e.client
 }
 
 func (e *Executor) Close() {
@@ -41,41 +88,493 @@ func (e *Executor) Close() {
 	}
 }
 
+// ExecuteStatements executes DML statements in a single read-write
+// transaction.
 func (e *Executor) ExecuteStatements(statements []string, verbose bool) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	parsed := make([]parser.Statement, len(statements))
+	for i, stmt := range statements {
+		parsed[i] = parser.Statement{SQL: stmt}
+	}
 
-	_, err := e.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
-		for i, stmt := range statements {
+	_, err := e.Execute(parsed, verbose)
+	return err
+}
+
+// Result describes the execution of a list of statements.
+type Result struct {
+	// Statements are the statements that were executed or skipped.
+	Statements []StatementResult
+	// Failures are the statements that failed with ContinueOnError.
+	Failures []Failure
+	// Retries is the number of times transactions were retried.
+	Retries int
+}
+
+// Failure is a statement that failed.
+type Failure struct {
+	Statement parser.Statement
+	// Index is the position of the statement in the list passed to Execute.
+	Index int
+	// Code is the gRPC code of the error, or codes.Unknown for errors that
+	// were not returned by Spanner, e.g. invalid annotations.
+	Code codes.Code
+	Err  error
+}
+
+// StatementResult describes the execution of a single statement.
+type StatementResult struct {
+	Statement parser.Statement
+	// RowCount is the number of rows the statement modified. For partitioned
+	// DML it is a lower bound.
+	RowCount int64
+	// Path is the way the statement was executed.
+	Path Path
+	// Fallback is the reason an INSERT statement was executed as DML in
+	// ModeMutations.
+	Fallback string
+	// Error is the error of a statement annotated with -- @expect-error.
+	Error string
+}
+
+// RowCount returns the total number of modified rows.
+func (r *Result) RowCount() int64 {
+	var total int64
+	for _, stmt := range r.Statements {
+		total += stmt.RowCount
+	}
+	return total
+}
+
+// Execute executes DML statements in a single read-write transaction. The
+// transaction is retried according to the executor's retry policy. Errors
+// name the source position of the failing statement.
+//
+// Statements annotated with -- @partitioned, or all UPDATE and DELETE
+// statements if Partitioned is set, are executed as partitioned DML outside
+// the transaction. In ModeMutations, consecutive INSERT statements that can
+// be converted to mutations are applied in commits of their own, and
+// statements annotated with -- @expect-error run in transactions of their
+// own that are rolled back. All of these split the statements into several
+// commits that are made in order, so statements before a failing one may
+// already have been committed. See Annotations for the other annotations.
+//
+// With ContinueOnError, every statement is committed on its own and failing
+// statements are collected in the result's Failures. The result is returned
+// along with an error if any statement failed. With Reorder, INSERT
+// statements are sorted by their tables' dependencies first.
+func (e *Executor) Execute(statements []parser.Statement, verbose bool) (*Result, error) {
+	if e.Reorder {
+		reordered, err := e.ReorderInserts(statements)
+		if err != nil {
+			return nil, err
+		}
+		if verbose {
+			if moved := countMoved(statements, reordered); moved > 0 {
+				fmt.Printf("Reordered %d INSERT statements by table dependencies\n", moved)
+			}
+		}
+		statements = reordered
+	}
+
+	steps, err := e.plan(statements)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	fail := func(i int, err error) {
+		result.Failures = append(result.Failures, Failure{Statement: statements[i], Index: i, Code: spanner.ErrCode(err), Err: err})
+		if verbose {
+			fmt.Printf("Failed %s: %v\n", describe(i, statements[i]), err)
+		}
+	}
+
+	for start := 0; start < len(statements); {
+		if steps[start].err != nil {
+			fail(start, steps[start].err)
+			start++
+			continue
+		}
+		if steps[start].path == PathSkipped {
 			if verbose {
-				limit := 100
-				if len(stmt) < limit {
-					limit = len(stmt)
-				}
-				fmt.Printf("Executing statement %d/%d: %s\n", i+1, len(statements), stmt[:limit]+"...")
+				fmt.Printf("Skipping %s\n", describe(start, statements[start]))
 			}
+			result.Statements = append(result.Statements, StatementResult{Statement: statements[start], Path: PathSkipped})
+			start++
+			continue
+		}
 
-			_, err := txn.Update(ctx, spanner.Statement{SQL: stmt})
-			if err != nil {
-				return fmt.Errorf("failed to execute statement %d: %w\nStatement: %s", i+1, err, stmt)
+		// Skipped statements do not end a transaction
+		end := start + 1
+		switch {
+		case e.ContinueOnError, steps[start].annotations.ExpectError:
+		case steps[start].path == PathDML:
+			for end < len(statements) && (steps[end].path == PathDML && !steps[end].annotations.ExpectError || steps[end].path == PathSkipped) {
+				end++
+			}
+		case steps[start].path == PathMutations:
+			// Keep each commit within the mutation limit
+			count := steps[start].values
+			for end < len(statements) && (steps[end].path == PathMutations || steps[end].path == PathSkipped) && count+steps[end].values <= MaxMutationsPerCommit {
+				count += steps[end].values
+				end++
 			}
 		}
-		return nil
-	})
 
+		var segment *Result
+		attempts, err := retry(e.Retry, verbose, func() error {
+			var err error
+			switch {
+			case steps[start].annotations.ExpectError:
+				segment, err = e.executeExpectingError(statements, steps, start, verbose)
+			case steps[start].path == PathPartitioned:
+				segment, err = e.executePartitioned(statements, steps, start, verbose)
+			case steps[start].path == PathMutations:
+				segment, err = e.applyMutations(statements, steps, start, end, verbose)
+			default:
+				segment, err = e.executeTransaction(statements, steps, start, end, verbose)
+			}
+			return err
+		})
+		result.Retries += attempts - 1
+		if err != nil && e.ContinueOnError {
+			fail(start, err)
+			start = end
+			continue
+		}
+		if err != nil {
+			if committed := len(result.Statements); committed > 0 {
+				return nil, fmt.Errorf("transaction failed: %w\n%d statements before it were already committed", err, committed)
+			}
+			return nil, fmt.Errorf("transaction failed: %w", err)
+		}
+
+		result.Statements = append(result.Statements, segment.Statements...)
+		start = end
+	}
+
+	if len(result.Failures) > 0 {
+		return result, fmt.Errorf("%d of %d statements failed", len(result.Failures), len(statements))
+	}
+	return result, nil
+}
+
+// step describes how a single statement is executed.
+type step struct {
+	path        Path
+	annotations *Annotations
+	bound       spanner.Statement
+	mutations   []*spanner.Mutation
+	// values is the number of values of the mutations
+	values int
+	// fallback is the reason an INSERT statement is executed as DML in
+	// ModeMutations
+	fallback string
+	// err is the reason the statement cannot be executed, with
+	// ContinueOnError
+	err error
+}
+
+// plan decides how each statement is executed and validates annotations and
+// parameters before anything is executed. With ContinueOnError, invalid
+// statements are marked as failed instead.
+func (e *Executor) plan(statements []parser.Statement) ([]step, error) {
+	// The schema is needed for any INSERT statement in ModeMutations
+	var s *schema.Schema
+	if e.Mode == ModeMutations {
+		if e.Dialect == parser.PostgreSQL {
+			return nil, fmt.Errorf("--mode mutations is not supported with the PostgreSQL dialect")
+		}
+		var err error
+		if s, err = e.loadSchema(); err != nil {
+			return nil, err
+		}
+	}
+
+	steps := make([]step, len(statements))
+	for i, stmt := range statements {
+		var err error
+		if steps[i], err = e.planStatement(stmt, s); err != nil {
+			err = fmt.Errorf("%s: %w", describe(i, stmt), err)
+			if !e.ContinueOnError {
+				return nil, err
+			}
+			steps[i] = step{err: err}
+		}
+	}
+
+	return steps, nil
+}
+
+func (e *Executor) planStatement(stmt parser.Statement, s *schema.Schema) (step, error) {
+	a, err := ParseAnnotations(stmt)
 	if err != nil {
-		return fmt.Errorf("transaction failed: %w", err)
+		return step{}, err
+	}
+	st := step{path: PathDML, annotations: a}
+	if !a.Selected(e.Tags) {
+		st.path = PathSkipped
+		return st, nil
 	}
 
-	return nil
+	partitioned, err := IsPartitioned(stmt, e.Dialect, e.Partitioned)
+	if err != nil {
+		return step{}, err
+	}
+	switch {
+	case partitioned:
+		if a.ExpectError || a.ExpectRows >= 0 {
+			return step{}, fmt.Errorf("@expect-rows and @expect-error cannot be used with partitioned DML, which reports lower bounds of row counts")
+		}
+		st.path = PathPartitioned
+	case s != nil && isInsert(stmt.SQL, e.Dialect) && !a.ExpectError:
+		if st.mutations, st.values, err = toMutations(stmt.SQL, s); err == nil {
+			st.path = PathMutations
+			return st, nil
+		}
+		st.fallback = err.Error()
+	}
+
+	if st.bound, err = e.bind(stmt.SQL); err != nil {
+		return step{}, err
+	}
+	return st, nil
+}
+
+// IsPartitioned reports whether stmt is executed as partitioned DML: if it
+// is annotated with -- @partitioned, or if all is set and it is an UPDATE or
+// DELETE statement. Annotated statements that are not eligible for
+// partitioned DML (see parser.CheckPartitioned) are reported as errors.
+func IsPartitioned(stmt parser.Statement, d parser.Dialect, all bool) (bool, error) {
+	if _, ok := stmt.Annotation("partitioned"); !ok {
+		if !all || isInsert(stmt.SQL, d) {
+			return false, nil
+		}
+	}
+
+	if err := d.CheckPartitioned(stmt.SQL); err != nil {
+		return false, fmt.Errorf("cannot execute as partitioned DML: %w", err)
+	}
+	return true, nil
+}
+
+// isInsert reports whether stmt is an INSERT statement of the dialect,
+// including one with statement hints.
+func isInsert(stmt string, d parser.Dialect) bool {
+	parsed, err := d.ParseDML(stmt)
+	if err != nil {
+		return false
+	}
+	_, ok := parsed.(*parser.Insert)
+	return ok
+}
+
+// partitionedTimeout bounds a single partitioned DML statement, which may
+// modify large tables.
+const partitionedTimeout = 10 * time.Minute
+
+// executePartitioned executes statements[i] as partitioned DML. The row count
+// it reports is a lower bound of the modified rows.
+func (e *Executor) executePartitioned(statements []parser.Statement, steps []step, i int, verbose bool) (*Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), partitionedTimeout)
+	defer cancel()
+
+	stmt := statements[i]
+	if verbose {
+		fmt.Printf("Executing statement %d/%d as partitioned DML: %s\n", i+1, len(statements), truncate(stmt.SQL, 100)+"...")
+	}
+
+	count, err := e.client.PartitionedUpdate(ctx, steps[i].bound)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s as partitioned DML: %w\nStatement: %s", describe(i, stmt), err, stmt.SQL)
+	}
+
+	if verbose {
+		fmt.Printf("Partitioned DML modified at least %d rows\n", count)
+	}
+
+	return &Result{Statements: []StatementResult{{Statement: stmt, RowCount: count, Path: PathPartitioned}}}, nil
+}
+
+// executeTransaction makes a single attempt at executing statements[start:end]
+// in a read-write transaction.
+func (e *Executor) executeTransaction(statements []parser.Statement, steps []step, start, end int, verbose bool) (*Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	txn, err := spanner.NewReadWriteStmtBasedTransaction(ctx, e.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	result := &Result{}
+	for i := start; i < end; i++ {
+		stmt := statements[i]
+		if steps[i].path == PathSkipped {
+			result.Statements = append(result.Statements, StatementResult{Statement: stmt, Path: PathSkipped})
+			continue
+		}
+		if verbose {
+			fmt.Printf("Executing statement %d/%d: %s\n", i+1, len(statements), truncate(stmt.SQL, 100)+"...")
+		}
+
+		count, err := txn.Update(ctx, steps[i].bound)
+		if err == nil {
+			err = steps[i].annotations.checkRows(count)
+		}
+		if err != nil {
+			txn.Rollback(ctx)
+			return nil, fmt.Errorf("failed to execute %s: %w\nStatement: %s", describe(i, stmt), err, stmt.SQL)
+		}
+		result.Statements = append(result.Statements, StatementResult{Statement: stmt, RowCount: count, Path: PathDML, Fallback: steps[i].fallback})
+	}
+
+	if _, err := txn.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// executeExpectingError makes a single attempt at executing statements[i],
+// which is annotated with -- @expect-error, in a transaction of its own. The
+// transaction is rolled back when the statement fails. If it succeeds, it is
+// committed so that errors detected at commit are seen.
+func (e *Executor) executeExpectingError(statements []parser.Statement, steps []step, i int, verbose bool) (*Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stmt := statements[i]
+	if verbose {
+		fmt.Printf("Executing statement %d/%d, expecting an error: %s\n", i+1, len(statements), truncate(stmt.SQL, 100)+"...")
+	}
+
+	txn, err := spanner.NewReadWriteStmtBasedTransaction(ctx, e.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if _, err = txn.Update(ctx, steps[i].bound); err != nil {
+		txn.Rollback(ctx)
+	} else {
+		_, err = txn.Commit(ctx)
+	}
+
+	if checkErr := steps[i].annotations.checkError(err); checkErr != nil {
+		if IsRetryable(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w\nStatement: %s", describe(i, stmt), checkErr, stmt.SQL)
+	}
+
+	if verbose {
+		fmt.Printf("Statement failed as expected: %v\n", err)
+	}
+	return &Result{Statements: []StatementResult{{Statement: stmt, Path: PathDML, Error: err.Error()}}}, nil
+}
+
+func truncate(s string, limit int) string {
+	if len(s) < limit {
+		return s
+	}
+	return s[:limit]
+}
+
+// describe names a statement by its index and, if known, its source position.
+func describe(i int, stmt parser.Statement) string {
+	if stmt.Line == 0 {
+		return fmt.Sprintf("statement %d", i+1)
+	}
+	return fmt.Sprintf("statement %d (%s)", i+1, stmt.Position())
+}
+
+// bind builds a statement bound to the parameters stmt references.
+func (e *Executor) bind(stmt string) (spanner.Statement, error) {
+	names := e.Dialect.ParamNames(stmt)
+	if len(names) == 0 {
+		return spanner.Statement{SQL: stmt}, nil
+	}
+
+	bound := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		value, ok := e.Params[name]
+		if !ok {
+			if e.Dialect == parser.PostgreSQL {
+				return spanner.Statement{}, fmt.Errorf("undefined parameter $%s (--param %s=...)", strings.TrimPrefix(name, "p"), name)
+			}
+			return spanner.Statement{}, fmt.Errorf("undefined parameter @%s", name)
+		}
+		if e.Dialect == parser.PostgreSQL {
+			value = params.PostgreSQL(value)
+		}
+		bound[name] = value
+	}
+
+	return spanner.Statement{SQL: stmt, Params: bound}, nil
 }
 
 // InitializeSchema creates instance and database with the given schema
 func InitializeSchema(cfg *config.Config, schemaFile string, verbose bool) error {
+	return initializeSchema(cfg, func(dialect parser.Dialect) ([]parser.Statement, error) {
+		// An empty path creates a database without tables
+		if schemaFile == "" {
+			return nil, nil
+		}
+
+		schemaContent, err := os.ReadFile(schemaFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema file: %w", err)
+		}
+
+		return dialect.ParseDDL(string(schemaContent), schemaFile)
+	}, verbose)
+}
+
+// InitializeSchemaFS is like InitializeSchema but reads the schema from fsys.
+// The pattern may be a glob (see fs.Glob); matching files are applied in
+// lexical order.
+func InitializeSchemaFS(cfg *config.Config, fsys fs.FS, pattern string, verbose bool) error {
+	return initializeSchema(cfg, func(dialect parser.Dialect) ([]parser.Statement, error) {
+		return readDDLFS(fsys, pattern, dialect)
+	}, verbose)
+}
+
+func readDDLFS(fsys fs.FS, pattern string, dialect parser.Dialect) ([]parser.Statement, error) {
+	matches, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema pattern %s: %w", pattern, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no schema files match %s", pattern)
+	}
+
+	var ddlStatements []parser.Statement
+	for _, name := range matches {
+		schemaContent, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema file %s: %w", name, err)
+		}
+		statements, err := dialect.ParseDDL(string(schemaContent), name)
+		if err != nil {
+			return nil, err
+		}
+		ddlStatements = append(ddlStatements, statements...)
+	}
+
+	return ddlStatements, nil
+}
+
+// initializeSchema creates the instance and database if they do not exist.
+// loadSchema is only called when the database has to be created.
+func initializeSchema(cfg *config.Config, loadSchema func(parser.Dialect) ([]parser.Statement, error), verbose bool) error {
 	if cfg.EmulatorHost != "" {
 		os.Setenv("SPANNER_EMULATOR_HOST", cfg.EmulatorHost)
 	}
 
+	dialect, err := parser.ParseDialect(cfg.Dialect)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
@@ -117,14 +616,13 @@ func InitializeSchema(cfg *config.Config, schemaFile string, verbose bool) error
 				NodeCount:   1,
 			},
 		})
-		if err != nil {
-			return fmt.Errorf("failed to create instance: %w", err)
+		if err == nil {
+			// Wait for instance creation to complete
+			_, err = createInstanceOp.Wait(ctx)
 		}
-
-		// Wait for instance creation to complete
-		_, err = createInstanceOp.Wait(ctx)
-		if err != nil {
-			return fmt.Errorf("instance creation failed: %w", err)
+		// Another process may have created the instance concurrently
+		if err != nil && status.Code(err) != codes.AlreadyExists {
+			return fmt.Errorf("failed to create instance: %w", err)
 		}
 
 		if verbose {
@@ -148,33 +646,17 @@ func InitializeSchema(cfg *config.Config, schemaFile string, verbose bool) error
 			fmt.Printf("Creating database: %s\n", databasePath)
 		}
 
-		// Read schema file
-		schemaContent, err := os.ReadFile(schemaFile)
+		ddlStatements, err := loadSchema(dialect)
 		if err != nil {
-			return fmt.Errorf("failed to read schema file: %w", err)
+			return err
 		}
 
-		// Parse DDL statements
-		ddlStatements := parseDDLStatements(string(schemaContent))
-
 		if verbose {
 			fmt.Printf("Found %d DDL statements\n", len(ddlStatements))
 		}
 
-		// Create database with schema
-		createOp, err := databaseAdminClient.CreateDatabase(ctx, &databasepb.CreateDatabaseRequest{
-			Parent:          instancePath,
-			CreateStatement: fmt.Sprintf("CREATE DATABASE `%s`", cfg.DatabaseID),
-			ExtraStatements: ddlStatements,
-		})
-		if err != nil {
-			return fmt.Errorf("failed to create database: %w", err)
-		}
-
-		// Wait for database creation to complete
-		_, err = createOp.Wait(ctx)
-		if err != nil {
-			return fmt.Errorf("database creation failed: %w", err)
+		if err := createDatabaseWithSchema(ctx, databaseAdminClient, cfg, dialect, ddlStatements); err != nil {
+			return err
 		}
 
 		if verbose {
@@ -189,31 +671,196 @@ func InitializeSchema(cfg *config.Config, schemaFile string, verbose bool) error
 	return nil
 }
 
-// parseDDLStatements parses DDL statements from schema content
-func parseDDLStatements(content string) []string {
-	// Remove comment lines
-	lines := strings.Split(content, "\n")
-	var cleanLines []string
+// createDatabaseWithSchema creates the database described by cfg and applies
+// the DDL statements to it. The statements are applied once the database
+// exists, so that a failing statement can be reported at its position. If
+// creating the database or applying the statements fails, the database is
+// dropped again rather than left without its schema.
+func createDatabaseWithSchema(ctx context.Context, client *database.DatabaseAdminClient, cfg *config.Config, dialect parser.Dialect, statements []parser.Statement) error {
+	request := &databasepb.CreateDatabaseRequest{
+		Parent:          fmt.Sprintf("projects/%s/instances/%s", cfg.ProjectID, cfg.InstanceID),
+		CreateStatement: fmt.Sprintf("CREATE DATABASE `%s`", cfg.DatabaseID),
+	}
+	if dialect == parser.PostgreSQL {
+		request.CreateStatement = fmt.Sprintf(`CREATE DATABASE "%s"`, cfg.DatabaseID)
+		request.DatabaseDialect = databasepb.DatabaseDialect_POSTGRESQL
+	}
+	createOp, err := client.CreateDatabase(ctx, request)
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		// Skip empty lines and comment lines
-		if line != "" && !strings.HasPrefix(line, "--") {
-			cleanLines = append(cleanLines, line)
+	// drop removes a database that was created but could not be completed.
+	// It may not exist if creating it failed.
+	drop := func(err error) error {
+		dropErr := client.DropDatabase(ctx, &databasepb.DropDatabaseRequest{Database: cfg.DatabasePath()})
+		if dropErr != nil && status.Code(dropErr) != codes.NotFound {
+			return errors.Join(err, fmt.Errorf("failed to drop database %s: %w", cfg.DatabaseID, dropErr))
 		}
+		return err
+	}
+
+	if _, err := createOp.Wait(ctx); err != nil {
+		err = fmt.Errorf("database creation failed: %w", err)
+		// A database of the same name created concurrently is not ours
+		if status.Code(err) == codes.AlreadyExists {
+			return err
+		}
+		return drop(err)
+	}
+	if len(statements) == 0 {
+		return nil
+	}
+
+	if err := applyDDL(ctx, client, cfg.DatabasePath(), statements); err != nil {
+		return drop(err)
 	}
 
-	// Join back and split by semicolon
-	cleanContent := strings.Join(cleanLines, " ")
-	statements := strings.Split(cleanContent, ";")
-	var ddlStatements []string
+	return nil
+}
 
-	for _, stmt := range statements {
-		stmt = strings.TrimSpace(stmt)
-		if stmt != "" {
-			ddlStatements = append(ddlStatements, stmt)
+// applyDDL applies DDL statements to a database and waits for them to
+// complete. A failure is reported at the position of the failing statement.
+func applyDDL(ctx context.Context, client *database.DatabaseAdminClient, databasePath string, statements []parser.Statement) error {
+	updateOp, err := client.UpdateDatabaseDdl(ctx, &databasepb.UpdateDatabaseDdlRequest{
+		Database:   databasePath,
+		Statements: parser.SQL(statements),
+	})
+	if err != nil {
+		// Statements that cannot be parsed are rejected before any is applied
+		return ddlError(statements, -1, err)
+	}
+	if err := updateOp.Wait(ctx); err != nil {
+		applied := -1
+		if metadata, mdErr := updateOp.Metadata(); mdErr == nil && metadata != nil {
+			applied = len(metadata.CommitTimestamps)
 		}
+		return ddlError(statements, applied, err)
 	}
 
-	return ddlStatements
+	return nil
+}
+
+// ddlError reports a failed schema update at the position of the failing
+// statement: the first statement that was not applied or, if that is not
+// known, the statement quoted in the error message.
+func ddlError(statements []parser.Statement, applied int, err error) error {
+	failed := -1
+	if applied >= 0 && applied < len(statements) {
+		failed = applied
+	} else {
+		message := status.Convert(err).Message()
+		for i, stmt := range statements {
+			if strings.Contains(message, stmt.SQL) {
+				failed = i
+				break
+			}
+		}
+	}
+	if failed < 0 {
+		return fmt.Errorf("schema update failed: %w", err)
+	}
+
+	return fmt.Errorf("%s: schema update failed: %w", statements[failed].Position(), err)
+}
+
+// DropDatabase drops the database described by cfg
+func DropDatabase(cfg *config.Config) error {
+	if cfg.EmulatorHost != "" {
+		os.Setenv("SPANNER_EMULATOR_HOST", cfg.EmulatorHost)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	databaseAdminClient, err := database.NewDatabaseAdminClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create database admin client: %w", err)
+	}
+	defer databaseAdminClient.Close()
+
+	err = databaseAdminClient.DropDatabase(ctx, &databasepb.DropDatabaseRequest{
+		Database: cfg.DatabasePath(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to drop database: %w", err)
+	}
+
+	return nil
+}
+
+// RecreateDatabase drops the database described by cfg, if it exists, and
+// creates it again with the schema in schemaFile
+func RecreateDatabase(cfg *config.Config, schemaFile string, verbose bool) error {
+	if err := DropDatabase(cfg); err != nil && status.Code(err) != codes.NotFound {
+		return err
+	}
+	if verbose {
+		fmt.Printf("Dropped database: %s\n", cfg.DatabaseID)
+	}
+	if err := InitializeSchema(cfg, schemaFile, verbose); err != nil {
+		return fmt.Errorf("failed to initialize schema: %w", err)
+	}
+
+	return nil
+}
+
+// GetDatabaseDDL returns the DDL statements of the database described by cfg
+func GetDatabaseDDL(cfg *config.Config) ([]string, error) {
+	if cfg.EmulatorHost != "" {
+		os.Setenv("SPANNER_EMULATOR_HOST", cfg.EmulatorHost)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	databaseAdminClient, err := database.NewDatabaseAdminClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database admin client: %w", err)
+	}
+	defer databaseAdminClient.Close()
+
+	resp, err := databaseAdminClient.GetDatabaseDdl(ctx, &databasepb.GetDatabaseDdlRequest{
+		Database: cfg.DatabasePath(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database schema: %w", err)
+	}
+
+	return resp.Statements, nil
+}
+
+// UpdateDDL applies DDL statements to the database described by cfg and
+// waits for them to complete
+func UpdateDDL(cfg *config.Config, statements []string, verbose bool) error {
+	if cfg.EmulatorHost != "" {
+		os.Setenv("SPANNER_EMULATOR_HOST", cfg.EmulatorHost)
+	}
+
+	// Schema changes may backfill indexes and columns, so allow more time
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	databaseAdminClient, err := database.NewDatabaseAdminClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create database admin client: %w", err)
+	}
+	defer databaseAdminClient.Close()
+
+	if verbose {
+		fmt.Printf("Applying %d DDL statements\n", len(statements))
+	}
+
+	updateOp, err := databaseAdminClient.UpdateDatabaseDdl(ctx, &databasepb.UpdateDatabaseDdlRequest{
+		Database:   cfg.DatabasePath(),
+		Statements: statements,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update database schema: %w", err)
+	}
+	if err := updateOp.Wait(ctx); err != nil {
+		return fmt.Errorf("schema update failed: %w", err)
+	}
+
+	return nil
 }
@@ -2,9 +2,16 @@ package executor
 
 import (
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
 
+	"cloud.google.com/go/spanner"
 	"github.com/nu0ma/spemu/pkg/config"
+	"github.com/nu0ma/spemu/pkg/parser"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestNew(t *testing.T) {
@@ -89,6 +96,40 @@ func TestExecuteStatements_Integration(t *testing.T) {
 	}
 }
 
+func TestReadDDLFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"schema/01_users.sql": {Data: []byte(`-- users
+CREATE TABLE users (
+  id INT64 NOT NULL,
+) PRIMARY KEY (id);`)},
+		"schema/02_posts.sql": {Data: []byte(`CREATE TABLE posts (id INT64 NOT NULL) PRIMARY KEY (id);
+CREATE INDEX posts_by_id ON posts (id);`)},
+	}
+
+	statements, err := readDDLFS(fsys, "schema/*.sql", parser.GoogleSQL)
+	if err != nil {
+		t.Fatalf("readDDLFS() unexpected error: %v", err)
+	}
+
+	expected := []parser.Statement{
+		{SQL: "CREATE TABLE users (\n  id INT64 NOT NULL,\n) PRIMARY KEY (id)", File: "schema/01_users.sql", Line: 2},
+		{SQL: "CREATE TABLE posts (id INT64 NOT NULL) PRIMARY KEY (id)", File: "schema/02_posts.sql", Line: 1},
+		{SQL: "CREATE INDEX posts_by_id ON posts (id)", File: "schema/02_posts.sql", Line: 2},
+	}
+	if !reflect.DeepEqual(statements, expected) {
+		t.Errorf("readDDLFS() = %v, expected %v", statements, expected)
+	}
+
+	if _, err := readDDLFS(fsys, "missing/*.sql", parser.GoogleSQL); err == nil {
+		t.Error("readDDLFS() expected error when no files match")
+	}
+
+	fsys["schema/03_seed.sql"] = &fstest.MapFile{Data: []byte("INSERT INTO users (id) VALUES (1);")}
+	if _, err := readDDLFS(fsys, "schema/*.sql", parser.GoogleSQL); err == nil || !strings.Contains(err.Error(), "schema/03_seed.sql:1: invalid DDL statement") {
+		t.Errorf("readDDLFS() error = %v, expected an invalid DDL statement", err)
+	}
+}
+
 func TestMin(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -141,3 +182,182 @@ func BenchmarkExecuteStatements(b *testing.B) {
 		_ = executor.ExecuteStatements(statements, false)
 	}
 }
+
+func TestBind(t *testing.T) {
+	e := &Executor{Params: map[string]interface{}{
+		"id":   int64(1),
+		"data": []byte{0x00, 0xff},
+		"name": "unused",
+	}}
+
+	stmt, err := e.bind("INSERT INTO t (id, data) VALUES (@id, @data)")
+	if err != nil {
+		t.Fatalf("bind() error = %v", err)
+	}
+	expected := map[string]interface{}{"id": int64(1), "data": []byte{0x00, 0xff}}
+	if !reflect.DeepEqual(stmt.Params, expected) {
+		t.Errorf("bind() params = %v, expected %v", stmt.Params, expected)
+	}
+
+	stmt, err = e.bind("DELETE FROM t WHERE name = '@id'")
+	if err != nil {
+		t.Fatalf("bind() error = %v", err)
+	}
+	if stmt.Params != nil {
+		t.Errorf("bind() params = %v, expected none", stmt.Params)
+	}
+
+	if _, err := e.bind("DELETE FROM t WHERE id = @missing"); err == nil {
+		t.Error("bind() expected error for an undefined parameter")
+	}
+}
+
+func TestBind_PostgreSQL(t *testing.T) {
+	e := &Executor{Dialect: parser.PostgreSQL, Params: map[string]interface{}{
+		"p1": int64(1),
+		"p2": spanner.NullJSON{Value: map[string]interface{}{"a": "b"}, Valid: true},
+	}}
+
+	stmt, err := e.bind("UPDATE t SET data = $2 WHERE id = $1")
+	if err != nil {
+		t.Fatalf("bind() error = %v", err)
+	}
+	expected := map[string]interface{}{
+		"p1": int64(1),
+		"p2": spanner.PGJsonB{Value: map[string]interface{}{"a": "b"}, Valid: true},
+	}
+	if !reflect.DeepEqual(stmt.Params, expected) {
+		t.Errorf("bind() params = %v, expected %v", stmt.Params, expected)
+	}
+
+	if _, err := e.bind("DELETE FROM t WHERE id = $3"); err == nil {
+		t.Error("bind() expected error for an undefined parameter")
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	if result := describe(0, parser.Statement{SQL: "DELETE FROM t WHERE true"}); result != "statement 1" {
+		t.Errorf("describe() = %q, expected %q", result, "statement 1")
+	}
+
+	stmt := parser.Statement{SQL: "DELETE FROM t WHERE true", File: "shared/users.sql", Line: 12}
+	if result := describe(2, stmt); result != "statement 3 (shared/users.sql:12)" {
+		t.Errorf("describe() = %q, expected %q", result, "statement 3 (shared/users.sql:12)")
+	}
+}
+
+func TestIsPartitioned(t *testing.T) {
+	annotated := []parser.Directive{{Name: "partitioned", Line: 1}}
+
+	tests := []struct {
+		name     string
+		stmt     parser.Statement
+		all      bool
+		expected bool
+		wantErr  bool
+	}{
+		{name: "plain", stmt: parser.Statement{SQL: "DELETE FROM t WHERE true"}},
+		{name: "annotated", stmt: parser.Statement{SQL: "DELETE FROM t WHERE true", Annotations: annotated}, expected: true},
+		{name: "annotated insert", stmt: parser.Statement{SQL: "INSERT INTO t (id) VALUES (1)", Annotations: annotated}, wantErr: true},
+		{name: "all", stmt: parser.Statement{SQL: "UPDATE t SET a = 1 WHERE true"}, all: true, expected: true},
+		{name: "all keeps inserts in transactions", stmt: parser.Statement{SQL: "INSERT INTO t (id) VALUES (1)"}, all: true},
+		{name: "all keeps hinted inserts in transactions", stmt: parser.Statement{SQL: "@{PDML_MAX_PARALLELISM=2} INSERT INTO t (id) VALUES (1)"}, all: true},
+		{name: "all rejects non-idempotent updates", stmt: parser.Statement{SQL: "UPDATE t SET a = a + 1 WHERE true"}, all: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := IsPartitioned(tt.stmt, parser.GoogleSQL, tt.all)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IsPartitioned() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result != tt.expected {
+				t.Errorf("IsPartitioned() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExecute_ContinueOnErrorPlan(t *testing.T) {
+	statements := []parser.Statement{
+		{SQL: "DELETE FROM t WHERE id = @missing", File: "seed.sql", Line: 1},
+		{SQL: "DELETE FROM t WHERE true", File: "seed.sql", Line: 3, Annotations: []parser.Directive{{Name: "skip"}}},
+		{SQL: "DELETE FROM t WHERE true", File: "seed.sql", Line: 5, Annotations: []parser.Directive{{Name: "expect-rows", Arg: "many"}}},
+	}
+
+	// Without ContinueOnError the first invalid statement stops execution
+	if _, err := (&Executor{}).Execute(statements, false); err == nil || !strings.Contains(err.Error(), "statement 1 (seed.sql:1): undefined parameter @missing") {
+		t.Errorf("Execute() error = %v", err)
+	}
+
+	// Invalid statements are reported as failures without executing anything
+	e := &Executor{ContinueOnError: true}
+	result, err := e.Execute(statements, false)
+	if err == nil || err.Error() != "2 of 3 statements failed" {
+		t.Errorf("Execute() error = %v, expected 2 of 3 statements failed", err)
+	}
+	if result == nil || len(result.Failures) != 2 {
+		t.Fatalf("Execute() result = %+v, expected 2 failures", result)
+	}
+	for i, expected := range []struct {
+		index   int
+		message string
+	}{
+		{0, "undefined parameter @missing"},
+		{2, "@expect-rows requires a row count"},
+	} {
+		f := result.Failures[i]
+		if f.Index != expected.index || f.Code != codes.Unknown || !strings.Contains(f.Err.Error(), expected.message) {
+			t.Errorf("failure %d = %+v, expected statement %d with %q", i, f, expected.index+1, expected.message)
+		}
+	}
+	if len(result.Statements) != 1 || result.Statements[0].Path != PathSkipped {
+		t.Errorf("Execute() statements = %+v, expected the skipped statement", result.Statements)
+	}
+}
+
+func TestDDLError(t *testing.T) {
+	statements := []parser.Statement{
+		{SQL: "CREATE TABLE users (id INT64) PRIMARY KEY (id)", File: "schema.sql", Line: 1},
+		{SQL: "CREATE INDEX idx ON missing (id)", File: "schema.sql", Line: 3},
+		{SQL: "CREATE TABLE posts (id INT64) PRIMARY KEY (id)", File: "schema.sql", Line: 5},
+	}
+
+	tests := []struct {
+		name     string
+		applied  int
+		err      error
+		expected string
+	}{
+		{
+			name:     "applied statements",
+			applied:  1,
+			err:      status.Error(codes.NotFound, "Table not found: missing"),
+			expected: "schema.sql:3: schema update failed: ",
+		},
+		{
+			name:     "statement in message",
+			applied:  -1,
+			err:      status.Error(codes.InvalidArgument, "Error parsing Spanner DDL statement: CREATE TABLE posts (id INT64) PRIMARY KEY (id) : Syntax error"),
+			expected: "schema.sql:5: schema update failed: ",
+		},
+		{
+			name:     "unknown statement",
+			applied:  -1,
+			err:      status.Error(codes.Internal, "internal error"),
+			expected: "schema update failed: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ddlError(statements, tt.applied, tt.err)
+			if !strings.HasPrefix(err.Error(), tt.expected) {
+				t.Errorf("ddlError() = %v, expected it to start with %q", err, tt.expected)
+			}
+			if status.Code(err) != status.Code(tt.err) {
+				t.Errorf("ddlError() code = %v, expected %v", status.Code(err), status.Code(tt.err))
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/spanner"
+)
+
+// MaxMutationsPerCommit keeps commits well below Spanner's limit of 80,000
+// mutations, where every column value counts as one mutation.
+const MaxMutationsPerCommit = 20000
+
+// Inserter inserts rows into a table with mutations, committing in chunks
+// that stay within MaxMutationsPerCommit. Rows are buffered by Add and the
+// last chunk is committed by Flush.
+type Inserter struct {
+	client        *spanner.Client
+	table         string
+	columns       []string
+	rowsPerCommit int
+	mutations     []*spanner.Mutation
+
+	// Inserted is the number of rows committed so far.
+	Inserted int64
+	// OnCommit is called after every commit, e.g. to report progress.
+	OnCommit func(inserted int64)
+}
+
+// NewInserter creates an Inserter for the given columns of table.
+func NewInserter(client *spanner.Client, table string, columns []string) *Inserter {
+	return &Inserter{
+		client:        client,
+		table:         table,
+		columns:       columns,
+		rowsPerCommit: max(1, MaxMutationsPerCommit/max(1, len(columns))),
+	}
+}
+
+// Add buffers a row, committing the buffered rows once a chunk is full.
+func (ins *Inserter) Add(ctx context.Context, values []interface{}) error {
+	ins.mutations = append(ins.mutations, spanner.Insert(ins.table, ins.columns, values))
+	if len(ins.mutations) < ins.rowsPerCommit {
+		return nil
+	}
+	return ins.Flush(ctx)
+}
+
+// Flush commits the buffered rows.
+func (ins *Inserter) Flush(ctx context.Context) error {
+	if len(ins.mutations) == 0 {
+		return nil
+	}
+	if _, err := ins.client.Apply(ctx, ins.mutations); err != nil {
+		return fmt.Errorf("failed to insert rows into %s: %w", ins.table, err)
+	}
+	ins.Inserted += int64(len(ins.mutations))
+	ins.mutations = ins.mutations[:0]
+	if ins.OnCommit != nil {
+		ins.OnCommit(ins.Inserted)
+	}
+	return nil
+}
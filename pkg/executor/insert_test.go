@@ -0,0 +1,33 @@
+package executor
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewInserter(t *testing.T) {
+	tests := []struct {
+		columns  int
+		expected int
+	}{
+		{columns: 0, expected: MaxMutationsPerCommit},
+		{columns: 1, expected: MaxMutationsPerCommit},
+		{columns: 8, expected: MaxMutationsPerCommit / 8},
+		{columns: MaxMutationsPerCommit + 1, expected: 1},
+	}
+
+	for _, tt := range tests {
+		ins := NewInserter(nil, "users", make([]string, tt.columns))
+		if ins.rowsPerCommit != tt.expected {
+			t.Errorf("NewInserter() with %d columns commits %d rows, expected %d", tt.columns, ins.rowsPerCommit, tt.expected)
+		}
+	}
+}
+
+func TestInserter_FlushWithoutRows(t *testing.T) {
+	ins := NewInserter(nil, "users", []string{"id"})
+	ins.OnCommit = func(int64) { t.Error("OnCommit called without rows") }
+	if err := ins.Flush(context.Background()); err != nil {
+		t.Errorf("Flush() error = %v", err)
+	}
+}
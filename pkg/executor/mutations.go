@@ -0,0 +1,270 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/nu0ma/spemu/pkg/parser"
+	"github.com/nu0ma/spemu/pkg/schema"
+)
+
+// Mode selects how INSERT statements are executed.
+type Mode int
+
+const (
+	// ModeDML executes all statements as DML.
+	ModeDML Mode = iota
+	// ModeMutations applies INSERT statements with literal values as
+	// mutations, which is much faster than DML for large seeds. Statements
+	// that cannot be converted are executed as DML.
+	ModeMutations
+)
+
+// ParseMode parses the name of a mode: dml or mutations.
+func ParseMode(name string) (Mode, error) {
+	switch strings.ToLower(name) {
+	case "dml":
+		return ModeDML, nil
+	case "mutations":
+		return ModeMutations, nil
+	}
+	return ModeDML, fmt.Errorf("unknown mode %q (expected dml or mutations)", name)
+}
+
+// Path is the way a statement was executed.
+type Path string
+
+const (
+	PathDML         Path = "dml"
+	PathPartitioned Path = "partitioned"
+	PathMutations   Path = "mutations"
+	// PathSkipped is reported for statements that were not executed, see
+	// Annotations.
+	PathSkipped Path = "skipped"
+)
+
+// loadSchema reads the schema used to type mutation values, once.
+func (e *Executor) loadSchema() (*schema.Schema, error) {
+	e.schemaMu.Lock()
+	defer e.schemaMu.Unlock()
+
+	if e.Schema == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		s, err := schema.Load(ctx, e.client)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load schema: %w", err)
+		}
+		e.Schema = s
+	}
+	return e.Schema, nil
+}
+
+// toMutations converts an INSERT statement with literal values into insert
+// mutations, typing the values by the columns of s. It also returns the
+// number of values, which Spanner counts as mutations. The error describes
+// why a statement cannot be converted.
+func toMutations(stmt string, s *schema.Schema) ([]*spanner.Mutation, int, error) {
+	ins, err := parser.ParseInsertValues(stmt)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	table := s.Table(ins.Table)
+	if table == nil {
+		return nil, 0, fmt.Errorf("table %s not found in schema", ins.Table)
+	}
+	columns := make([]*schema.Column, len(ins.Columns))
+	for i, name := range ins.Columns {
+		if columns[i] = table.Column(name); columns[i] == nil {
+			return nil, 0, fmt.Errorf("column %s.%s not found in schema", table.Name, name)
+		}
+		if columns[i].Generated {
+			return nil, 0, fmt.Errorf("column %s.%s is generated", table.Name, columns[i].Name)
+		}
+	}
+
+	insert := spanner.Insert
+	if ins.OrUpdate {
+		insert = spanner.InsertOrUpdate
+	}
+
+	mutations := make([]*spanner.Mutation, 0, len(ins.Rows))
+	for _, row := range ins.Rows {
+		values := make([]interface{}, len(row))
+		for i, v := range row {
+			if values[i], err = mutationValue(columns[i], v); err != nil {
+				return nil, 0, fmt.Errorf("%s.%s: %w", table.Name, columns[i].Name, err)
+			}
+		}
+		mutations = append(mutations, insert(table.Name, ins.Columns, values))
+	}
+
+	return mutations, len(ins.Rows) * len(ins.Columns), nil
+}
+
+// parseInt64 parses an INT64 literal the way Spanner does: decimal, or
+// hexadecimal with a 0x prefix. Leading zeros do not make a literal octal.
+func parseInt64(text string) (int64, error) {
+	sign, digits := "", text
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		sign, digits = digits[:1], digits[1:]
+	}
+	if len(digits) > 2 && digits[0] == '0' && (digits[1] == 'x' || digits[1] == 'X') {
+		return strconv.ParseInt(sign+digits[2:], 16, 64)
+	}
+	return strconv.ParseInt(text, 10, 64)
+}
+
+// mutationValue converts a literal to the Go value for a column, accepting
+// the same literals that DML coerces to the column type.
+func mutationValue(c *schema.Column, v parser.Value) (interface{}, error) {
+	if v.Kind == parser.ValueNull {
+		return nil, nil
+	}
+
+	typ := c.BaseType()
+	text, kind := v.Text, v.Kind
+	if kind == parser.ValueTyped {
+		if v.Type != typ {
+			return nil, fmt.Errorf("%s literal for a %s column", v.Type, typ)
+		}
+		// A typed literal is read like a string literal of the column type
+		kind = parser.ValueString
+	}
+
+	switch {
+	case typ == "INT64" && kind == parser.ValueNumber:
+		n, err := parseInt64(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid INT64 %s", text)
+		}
+		return n, nil
+	case typ == "FLOAT64" && kind == parser.ValueNumber:
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FLOAT64 %s", text)
+		}
+		return f, nil
+	case typ == "FLOAT32" && kind == parser.ValueNumber:
+		f, err := strconv.ParseFloat(text, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FLOAT32 %s", text)
+		}
+		return float32(f), nil
+	case typ == "NUMERIC" && (kind == parser.ValueNumber || kind == parser.ValueString):
+		r, ok := new(big.Rat).SetString(text)
+		if !ok {
+			return nil, fmt.Errorf("invalid NUMERIC %s", text)
+		}
+		return r, nil
+	case typ == "BOOL" && kind == parser.ValueBool:
+		return text == "TRUE", nil
+	case typ == "STRING" && kind == parser.ValueString:
+		return text, nil
+	case typ == "BYTES" && kind == parser.ValueBytes:
+		return []byte(text), nil
+	case typ == "DATE" && kind == parser.ValueString:
+		d, err := civil.ParseDate(strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("invalid DATE %s", text)
+		}
+		return d, nil
+	case typ == "TIMESTAMP" && kind == parser.ValueCommitTimestamp:
+		return spanner.CommitTimestamp, nil
+	case typ == "TIMESTAMP" && kind == parser.ValueString:
+		return parseTimestamp(text)
+	case typ == "JSON" && kind == parser.ValueString:
+		if !json.Valid([]byte(text)) {
+			return nil, fmt.Errorf("invalid JSON %s", text)
+		}
+		return spanner.NullJSON{Value: json.RawMessage(text), Valid: true}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported %s value for a %s column", describeValue(v), c.Type)
+}
+
+// timestampLayouts are the accepted timestamp formats. Timestamps without a
+// time zone are interpreted in the database's default time zone by DML, so
+// they are left to DML.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999Z07",
+}
+
+func parseTimestamp(text string) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("timestamp " + text + " has no explicit time zone or an unsupported format")
+}
+
+func describeValue(v parser.Value) string {
+	switch v.Kind {
+	case parser.ValueBool:
+		return "BOOL"
+	case parser.ValueNumber:
+		return "numeric"
+	case parser.ValueString:
+		return "string"
+	case parser.ValueBytes:
+		return "bytes"
+	case parser.ValueTyped:
+		return v.Type
+	case parser.ValueCommitTimestamp:
+		return "PENDING_COMMIT_TIMESTAMP()"
+	}
+	return "NULL"
+}
+
+// applyMutations makes a single attempt at applying the mutations of
+// statements[start:end] in one commit.
+func (e *Executor) applyMutations(statements []parser.Statement, steps []step, start, end int, verbose bool) (*Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var all []*spanner.Mutation
+	result := &Result{}
+	for i := start; i < end; i++ {
+		stmt := statements[i]
+		if steps[i].path == PathSkipped {
+			result.Statements = append(result.Statements, StatementResult{Statement: stmt, Path: PathSkipped})
+			continue
+		}
+		count := int64(len(steps[i].mutations))
+		if err := steps[i].annotations.checkRows(count); err != nil {
+			return nil, fmt.Errorf("%s: %w\nStatement: %s", describe(i, stmt), err, stmt.SQL)
+		}
+
+		all = append(all, steps[i].mutations...)
+		result.Statements = append(result.Statements, StatementResult{Statement: stmt, RowCount: count, Path: PathMutations})
+	}
+
+	if verbose {
+		fmt.Printf("Applying statements %d-%d/%d as %d mutations\n", start+1, end, len(statements), len(all))
+	}
+
+	if _, err := e.client.Apply(ctx, all); err != nil {
+		first := describe(start, statements[start])
+		if end-start > 1 {
+			first += " to " + describe(end-1, statements[end-1])
+		}
+		return nil, fmt.Errorf("failed to apply mutations of %s: %w", first, err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,124 @@
+package executor
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/nu0ma/spemu/pkg/parser"
+	"github.com/nu0ma/spemu/pkg/schema"
+)
+
+func TestParseMode(t *testing.T) {
+	if mode, err := ParseMode("mutations"); err != nil || mode != ModeMutations {
+		t.Errorf("ParseMode(mutations) = %v, %v", mode, err)
+	}
+	if mode, err := ParseMode("DML"); err != nil || mode != ModeDML {
+		t.Errorf("ParseMode(DML) = %v, %v", mode, err)
+	}
+	if _, err := ParseMode("batch"); err == nil {
+		t.Error("ParseMode(batch) expected error")
+	}
+}
+
+func TestToMutations(t *testing.T) {
+	s := &schema.Schema{Tables: []*schema.Table{{
+		Name: "Users",
+		Columns: []*schema.Column{
+			{Name: "Id", Type: "INT64", NotNull: true},
+			{Name: "Name", Type: "STRING(100)"},
+			{Name: "CreatedAt", Type: "TIMESTAMP"},
+			{Name: "NameLength", Type: "INT64", Generated: true},
+		},
+	}}}
+
+	mutations, values, err := toMutations("INSERT INTO users (id, name, createdat) VALUES (1, 'John', '2024-01-01T00:00:00Z'), (2, NULL, PENDING_COMMIT_TIMESTAMP())", s)
+	if err != nil {
+		t.Fatalf("toMutations() error = %v", err)
+	}
+	columns := []string{"id", "name", "createdat"}
+	expected := []*spanner.Mutation{
+		spanner.Insert("Users", columns, []interface{}{int64(1), "John", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}),
+		spanner.Insert("Users", columns, []interface{}{int64(2), nil, spanner.CommitTimestamp}),
+	}
+	if !reflect.DeepEqual(mutations, expected) {
+		t.Errorf("toMutations() = %v, expected %v", mutations, expected)
+	}
+	if values != 6 {
+		t.Errorf("toMutations() values = %d, expected 6", values)
+	}
+
+	tests := []struct {
+		stmt    string
+		message string
+	}{
+		{"INSERT INTO users (id) VALUES (@id)", "unsupported value expression"},
+		{"INSERT INTO accounts (id) VALUES (1)", "table accounts not found in schema"},
+		{"INSERT INTO users (id, email) VALUES (1, 'a@example.com')", "column Users.email not found in schema"},
+		{"INSERT INTO users (id, namelength) VALUES (1, 4)", "column Users.NameLength is generated"},
+		{"INSERT INTO users (id, name) VALUES ('1', 'John')", "Users.Id: unsupported string value for a INT64 column"},
+		{"INSERT INTO users (id, createdat) VALUES (1, '2024-01-01 00:00:00')", "has no explicit time zone"},
+	}
+	for _, tt := range tests {
+		_, _, err := toMutations(tt.stmt, s)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("toMutations(%q) error = %v, expected it to contain %q", tt.stmt, err, tt.message)
+		}
+	}
+}
+
+func TestMutationValue(t *testing.T) {
+	tests := []struct {
+		typ      string
+		value    parser.Value
+		expected interface{}
+		wantErr  bool
+	}{
+		{typ: "INT64", value: parser.Value{Kind: parser.ValueNumber, Text: "-42"}, expected: int64(-42)},
+		{typ: "INT64", value: parser.Value{Kind: parser.ValueNumber, Text: "0x10"}, expected: int64(16)},
+		{typ: "INT64", value: parser.Value{Kind: parser.ValueNumber, Text: "0XfF"}, expected: int64(255)},
+		{typ: "INT64", value: parser.Value{Kind: parser.ValueNumber, Text: "-0x10"}, expected: int64(-16)},
+		{typ: "INT64", value: parser.Value{Kind: parser.ValueNumber, Text: "010"}, expected: int64(10)},
+		{typ: "INT64", value: parser.Value{Kind: parser.ValueNumber, Text: "0b101"}, wantErr: true},
+		{typ: "INT64", value: parser.Value{Kind: parser.ValueNumber, Text: "0o17"}, wantErr: true},
+		{typ: "INT64", value: parser.Value{Kind: parser.ValueNumber, Text: "1_000"}, wantErr: true},
+		{typ: "INT64", value: parser.Value{Kind: parser.ValueNumber, Text: "0x"}, wantErr: true},
+		{typ: "INT64", value: parser.Value{Kind: parser.ValueNumber, Text: "1.5"}, wantErr: true},
+		{typ: "FLOAT64", value: parser.Value{Kind: parser.ValueNumber, Text: "1.5e3"}, expected: 1500.0},
+		{typ: "FLOAT32", value: parser.Value{Kind: parser.ValueNumber, Text: "0.5"}, expected: float32(0.5)},
+		{typ: "NUMERIC", value: parser.Value{Kind: parser.ValueTyped, Type: "NUMERIC", Text: "12.34"}, expected: big.NewRat(1234, 100)},
+		{typ: "BOOL", value: parser.Value{Kind: parser.ValueBool, Text: "TRUE"}, expected: true},
+		{typ: "STRING(MAX)", value: parser.Value{Kind: parser.ValueString, Text: "x"}, expected: "x"},
+		{typ: "STRING(MAX)", value: parser.Value{Kind: parser.ValueBytes, Text: "x"}, wantErr: true},
+		{typ: "BYTES(MAX)", value: parser.Value{Kind: parser.ValueBytes, Text: "\x00"}, expected: []byte{0}},
+		{typ: "DATE", value: parser.Value{Kind: parser.ValueString, Text: "2024-02-29"}, expected: civil.Date{Year: 2024, Month: 2, Day: 29}},
+		{typ: "DATE", value: parser.Value{Kind: parser.ValueTyped, Type: "TIMESTAMP", Text: "2024-02-29T00:00:00Z"}, wantErr: true},
+		{typ: "TIMESTAMP", value: parser.Value{Kind: parser.ValueString, Text: "2024-01-01 09:00:00+09"}, expected: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{typ: "JSON", value: parser.Value{Kind: parser.ValueTyped, Type: "JSON", Text: `{"a": 1}`}, expected: spanner.NullJSON{Value: json.RawMessage(`{"a": 1}`), Valid: true}},
+		{typ: "JSON", value: parser.Value{Kind: parser.ValueString, Text: `{"a":`}, wantErr: true},
+		{typ: "ARRAY<INT64>", value: parser.Value{Kind: parser.ValueNull}, expected: nil},
+		{typ: "ARRAY<INT64>", value: parser.Value{Kind: parser.ValueNumber, Text: "1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		result, err := mutationValue(&schema.Column{Name: "c", Type: tt.typ}, tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("mutationValue(%s, %+v) error = %v, wantErr %v", tt.typ, tt.value, err, tt.wantErr)
+			continue
+		}
+		if tm, ok := result.(time.Time); ok {
+			if !tm.Equal(tt.expected.(time.Time)) {
+				t.Errorf("mutationValue(%s, %+v) = %v, expected %v", tt.typ, tt.value, result, tt.expected)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("mutationValue(%s, %+v) = %#v, expected %#v", tt.typ, tt.value, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nu0ma/spemu/pkg/parser"
+)
+
+// Unit is a list of statements that is executed in its own transaction by
+// ExecuteParallel, e.g. the statements of one seed file.
+type Unit struct {
+	Name       string
+	Statements []parser.Statement
+	// DependsOn names the units that must be executed successfully before
+	// this unit starts, e.g. units that insert foreign key parents.
+	DependsOn []string
+}
+
+// ExecuteParallel executes units in separate transactions using up to workers
+// concurrent transactions over the executor's client. A unit starts once all
+// of its dependencies have completed; units whose dependencies fail are
+// skipped. Errors are joined in unit order, so reporting does not depend on
+// scheduling. Results are returned in unit order as well.
+func (e *Executor) ExecuteParallel(units []Unit, workers int, verbose bool) (*Result, error) {
+	return executeParallel(units, workers, verbose, func(u Unit) (*Result, error) {
+		return e.Execute(u.Statements, verbose)
+	})
+}
+
+// executeParallel schedules units for ExecuteParallel, executing each with run.
+func executeParallel(units []Unit, workers int, verbose bool, run func(Unit) (*Result, error)) (*Result, error) {
+	if _, err := OrderUnits(units); err != nil {
+		return nil, err
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	index := make(map[string]int, len(units))
+	for i, u := range units {
+		index[u.Name] = i
+	}
+	pending := make([]int, len(units))
+	dependents := make([][]int, len(units))
+	for i, u := range units {
+		for _, dep := range u.DependsOn {
+			pending[i]++
+			dependents[index[dep]] = append(dependents[index[dep]], i)
+		}
+	}
+
+	var ready []int
+	for i := range units {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	type outcome struct {
+		unit   int
+		result *Result
+		err    error
+	}
+	work := make(chan int)
+	finished := make(chan outcome)
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range work {
+				if verbose {
+					fmt.Printf("Starting %s (%d statements)\n", units[i].Name, len(units[i].Statements))
+				}
+				result, err := run(units[i])
+				finished <- outcome{unit: i, result: result, err: err}
+			}
+		}()
+	}
+	defer close(work)
+
+	results := make([]*Result, len(units))
+	errs := make([]error, len(units))
+	done := make([]bool, len(units))
+	completed, running := 0, 0
+
+	// skip marks the units depending on a failed unit as failed as well
+	var skip func(failed int)
+	skip = func(failed int) {
+		for _, j := range dependents[failed] {
+			if done[j] {
+				continue
+			}
+			done[j] = true
+			completed++
+			errs[j] = fmt.Errorf("%s: skipped because %s failed", units[j].Name, units[failed].Name)
+			skip(j)
+		}
+	}
+
+	for completed < len(units) {
+		for len(ready) > 0 && running < workers {
+			work <- ready[0]
+			ready = ready[1:]
+			running++
+		}
+
+		o := <-finished
+		running--
+		completed++
+		done[o.unit] = true
+
+		if o.err != nil {
+			// Keep partial results, e.g. the failures of ContinueOnError
+			results[o.unit] = o.result
+			errs[o.unit] = fmt.Errorf("%s: %w", units[o.unit].Name, o.err)
+			skip(o.unit)
+			continue
+		}
+
+		results[o.unit] = o.result
+		if verbose {
+			fmt.Printf("Finished %s\n", units[o.unit].Name)
+		}
+		for _, j := range dependents[o.unit] {
+			pending[j]--
+			if pending[j] == 0 && !done[j] {
+				ready = append(ready, j)
+			}
+		}
+		// Start ready units in unit order
+		sort.Ints(ready)
+	}
+
+	combined := &Result{}
+	for _, result := range results {
+		if result != nil {
+			combined.Statements = append(combined.Statements, result.Statements...)
+			combined.Failures = append(combined.Failures, result.Failures...)
+			combined.Retries += result.Retries
+		}
+	}
+
+	return combined, errors.Join(errs...)
+}
+
+// OrderUnits returns units ordered so that every unit follows its
+// dependencies, keeping the given order where dependencies allow. It reports
+// duplicate names, unknown dependencies and dependency cycles.
+func OrderUnits(units []Unit) ([]Unit, error) {
+	index := make(map[string]int, len(units))
+	for i, u := range units {
+		if _, ok := index[u.Name]; ok {
+			return nil, fmt.Errorf("duplicate unit %s", u.Name)
+		}
+		index[u.Name] = i
+	}
+	for _, u := range units {
+		for _, dep := range u.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return nil, fmt.Errorf("%s depends on %s, which is not being loaded", u.Name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(units))
+	var ordered []Unit
+	var path []string
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			start := 0
+			for start < len(path) && path[start] != units[i].Name {
+				start++
+			}
+			cycle := append(append([]string{}, path[start:]...), units[i].Name)
+			return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+
+		state[i] = visiting
+		path = append(path, units[i].Name)
+		for _, dep := range units[i].DependsOn {
+			if err := visit(index[dep]); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		ordered = append(ordered, units[i])
+		return nil
+	}
+
+	for i := range units {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
@@ -0,0 +1,204 @@
+package executor
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nu0ma/spemu/pkg/parser"
+)
+
+func TestOrderUnits(t *testing.T) {
+	units := []Unit{
+		{Name: "posts.sql", DependsOn: []string{"users.sql"}},
+		{Name: "tags.sql"},
+		{Name: "users.sql"},
+		{Name: "comments.sql", DependsOn: []string{"posts.sql", "users.sql"}},
+	}
+
+	ordered, err := OrderUnits(units)
+	if err != nil {
+		t.Fatalf("OrderUnits() error = %v", err)
+	}
+
+	var names []string
+	for _, u := range ordered {
+		names = append(names, u.Name)
+	}
+	expected := []string{"users.sql", "posts.sql", "tags.sql", "comments.sql"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("OrderUnits() = %v, expected %v", names, expected)
+	}
+}
+
+func TestOrderUnits_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		units   []Unit
+		message string
+	}{
+		{
+			name:    "cycle",
+			units:   []Unit{{Name: "a", DependsOn: []string{"b"}}, {Name: "b", DependsOn: []string{"a"}}},
+			message: "dependency cycle: a -> b -> a",
+		},
+		{
+			name:    "unknown dependency",
+			units:   []Unit{{Name: "a", DependsOn: []string{"missing"}}},
+			message: "a depends on missing",
+		},
+		{
+			name:    "duplicate",
+			units:   []Unit{{Name: "a"}, {Name: "a"}},
+			message: "duplicate unit a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := OrderUnits(tt.units)
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("OrderUnits() error = %v, expected it to contain %q", err, tt.message)
+			}
+		})
+	}
+}
+
+func unitResult(u Unit) *Result {
+	result := &Result{}
+	for _, stmt := range u.Statements {
+		result.Statements = append(result.Statements, StatementResult{Statement: stmt, RowCount: 1})
+	}
+	return result
+}
+
+func TestExecuteParallel(t *testing.T) {
+	units := []Unit{
+		{Name: "users", Statements: []parser.Statement{{SQL: "INSERT INTO users (id) VALUES (1)"}}},
+		{Name: "posts", Statements: []parser.Statement{{SQL: "INSERT INTO posts (id) VALUES (1)"}}, DependsOn: []string{"users"}},
+		{Name: "tags", Statements: []parser.Statement{{SQL: "INSERT INTO tags (id) VALUES (1)"}}},
+		{Name: "roles", Statements: []parser.Statement{{SQL: "INSERT INTO roles (id) VALUES (1)"}}},
+	}
+
+	var mu sync.Mutex
+	finished := make(map[string]bool)
+	running, maxRunning := 0, 0
+
+	result, err := executeParallel(units, 2, false, func(u Unit) (*Result, error) {
+		mu.Lock()
+		for _, dep := range u.DependsOn {
+			if !finished[dep] {
+				t.Errorf("%s started before its dependency %s finished", u.Name, dep)
+			}
+		}
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		finished[u.Name] = true
+		mu.Unlock()
+		return unitResult(u), nil
+	})
+	if err != nil {
+		t.Fatalf("executeParallel() error = %v", err)
+	}
+
+	if maxRunning > 2 {
+		t.Errorf("%d units ran concurrently, expected at most 2", maxRunning)
+	}
+	if maxRunning < 2 {
+		t.Errorf("units did not run concurrently")
+	}
+
+	// Results are in unit order regardless of completion order
+	var sqls []string
+	for _, stmt := range result.Statements {
+		sqls = append(sqls, stmt.Statement.SQL)
+	}
+	expected := []string{
+		"INSERT INTO users (id) VALUES (1)",
+		"INSERT INTO posts (id) VALUES (1)",
+		"INSERT INTO tags (id) VALUES (1)",
+		"INSERT INTO roles (id) VALUES (1)",
+	}
+	if !reflect.DeepEqual(sqls, expected) {
+		t.Errorf("result statements = %v, expected %v", sqls, expected)
+	}
+	if result.RowCount() != 4 {
+		t.Errorf("RowCount() = %d, expected 4", result.RowCount())
+	}
+}
+
+func TestExecuteParallel_Errors(t *testing.T) {
+	units := []Unit{
+		{Name: "users"},
+		{Name: "posts", DependsOn: []string{"users"}},
+		{Name: "comments", DependsOn: []string{"posts"}},
+		{Name: "tags"},
+		{Name: "roles"},
+	}
+
+	var mu sync.Mutex
+	var started []string
+	_, err := executeParallel(units, 3, false, func(u Unit) (*Result, error) {
+		mu.Lock()
+		started = append(started, u.Name)
+		mu.Unlock()
+
+		switch u.Name {
+		case "roles":
+			return nil, errors.New("roles failed")
+		case "users":
+			// Finish last so that errors are not reported in completion order
+			time.Sleep(20 * time.Millisecond)
+			return nil, errors.New("users failed")
+		}
+		return unitResult(u), nil
+	})
+	if err == nil {
+		t.Fatal("executeParallel() expected error")
+	}
+
+	expected := "users: users failed\n" +
+		"posts: skipped because users failed\n" +
+		"comments: skipped because posts failed\n" +
+		"roles: roles failed"
+	if err.Error() != expected {
+		t.Errorf("executeParallel() error =\n%s\nexpected\n%s", err, expected)
+	}
+
+	for _, name := range started {
+		if name == "posts" || name == "comments" {
+			t.Errorf("%s was executed although its dependency failed", name)
+		}
+	}
+}
+
+func TestExecuteParallel_PartialResults(t *testing.T) {
+	units := []Unit{
+		{Name: "users", Statements: []parser.Statement{{SQL: "INSERT INTO users (id) VALUES (1)"}}},
+		{Name: "posts", Statements: []parser.Statement{{SQL: "INSERT INTO posts (id) VALUES (1)"}}},
+	}
+
+	result, err := executeParallel(units, 2, false, func(u Unit) (*Result, error) {
+		if u.Name == "posts" {
+			// Units executed with ContinueOnError return their failures
+			failure := Failure{Statement: u.Statements[0], Err: errors.New("row exists")}
+			return &Result{Failures: []Failure{failure}}, errors.New("1 of 1 statements failed")
+		}
+		return unitResult(u), nil
+	})
+	if err == nil {
+		t.Fatal("executeParallel() expected error")
+	}
+	if len(result.Statements) != 1 || len(result.Failures) != 1 {
+		t.Errorf("executeParallel() result = %+v, expected 1 statement and 1 failure", result)
+	}
+}
@@ -0,0 +1,167 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nu0ma/spemu/pkg/parser"
+	"github.com/nu0ma/spemu/pkg/schema"
+)
+
+// ReorderInserts sorts INSERT statements so that rows are inserted after the
+// rows their foreign keys and interleaving parents reference, using the
+// schema of the database. Only consecutive INSERT statements are reordered:
+// other statements stay in place and are never crossed. Statements keep
+// their order where the schema allows it, and always within a table.
+func (e *Executor) ReorderInserts(statements []parser.Statement) ([]parser.Statement, error) {
+	if e.Dialect == parser.PostgreSQL {
+		return nil, fmt.Errorf("reordering is not supported with the PostgreSQL dialect")
+	}
+	s, err := e.loadSchema()
+	if err != nil {
+		return nil, err
+	}
+	return reorderInserts(statements, s, e.Dialect)
+}
+
+func reorderInserts(statements []parser.Statement, s *schema.Schema, d parser.Dialect) ([]parser.Statement, error) {
+	ordered := make([]parser.Statement, 0, len(statements))
+	var run []parser.Statement
+	var tables []string
+
+	flush := func() error {
+		sorted, err := sortRun(run, tables, s)
+		if err != nil {
+			return err
+		}
+		ordered = append(ordered, sorted...)
+		run, tables = nil, nil
+		return nil
+	}
+
+	for _, stmt := range statements {
+		dml, err := d.ParseDML(stmt.SQL)
+		if ins, ok := dml.(*parser.Insert); err == nil && ok {
+			run = append(run, stmt)
+			tables = append(tables, ins.Table)
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		ordered = append(ordered, stmt)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return ordered, nil
+}
+
+// sortRun sorts a run of INSERT statements into the given tables. A
+// statement is emitted once all statements into the tables its table depends
+// on have been, taking the earliest such statement first.
+func sortRun(run []parser.Statement, tables []string, s *schema.Schema) ([]parser.Statement, error) {
+	remaining := make(map[string]int)
+	for _, table := range tables {
+		remaining[strings.ToLower(table)]++
+	}
+	deps := make([][]string, len(run))
+	for i, table := range tables {
+		if t := s.Table(table); t != nil {
+			for _, dep := range t.Dependencies() {
+				if remaining[strings.ToLower(dep)] > 0 {
+					deps[i] = append(deps[i], strings.ToLower(dep))
+				}
+			}
+		}
+	}
+
+	ready := func(i int) bool {
+		for _, dep := range deps[i] {
+			if remaining[dep] > 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	sorted := make([]parser.Statement, 0, len(run))
+	emitted := make([]bool, len(run))
+	for len(sorted) < len(run) {
+		progress := false
+		for i := range run {
+			if emitted[i] || !ready(i) {
+				continue
+			}
+			emitted[i] = true
+			sorted = append(sorted, run[i])
+			progress = true
+
+			// Statements that were waiting for this table may come earlier
+			table := strings.ToLower(tables[i])
+			if remaining[table]--; remaining[table] == 0 {
+				break
+			}
+		}
+		if !progress {
+			return nil, cycleError(run, tables, emitted, s)
+		}
+	}
+
+	return sorted, nil
+}
+
+// cycleError describes a dependency cycle between the tables of the
+// statements that could not be emitted.
+func cycleError(run []parser.Statement, tables []string, emitted []bool, s *schema.Schema) error {
+	pending := make(map[string]parser.Statement)
+	var first string
+	for i, table := range tables {
+		key := strings.ToLower(table)
+		if _, ok := pending[key]; !emitted[i] && !ok {
+			pending[key] = run[i]
+			if first == "" {
+				first = key
+			}
+		}
+	}
+
+	// Every pending table waits for another pending table, so following
+	// dependencies from any of them leads into a cycle
+	var path []string
+	seen := make(map[string]int)
+	for table := first; ; {
+		if start, ok := seen[table]; ok {
+			path = append(path[start:], table)
+			break
+		}
+		seen[table] = len(path)
+		path = append(path, table)
+		for _, dep := range s.Table(table).Dependencies() {
+			if _, ok := pending[strings.ToLower(dep)]; ok {
+				table = strings.ToLower(dep)
+				break
+			}
+		}
+	}
+
+	names := make([]string, len(path))
+	for i, table := range path {
+		names[i] = s.Table(table).Name
+	}
+	return fmt.Errorf("cannot reorder INSERT statements: dependency cycle between tables %s (first INSERT at %s)",
+		strings.Join(names, " -> "), pending[path[0]].Position())
+}
+
+// countMoved returns the number of statements whose position differs between
+// statements and reordered.
+func countMoved(statements, reordered []parser.Statement) int {
+	moved := 0
+	for i := range statements {
+		if statements[i].SQL != reordered[i].SQL || statements[i].Line != reordered[i].Line || statements[i].File != reordered[i].File {
+			moved++
+		}
+	}
+	return moved
+}
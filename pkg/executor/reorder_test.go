@@ -0,0 +1,130 @@
+package executor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nu0ma/spemu/pkg/parser"
+	"github.com/nu0ma/spemu/pkg/schema"
+)
+
+// blogSchema mirrors test/schema.sql: posts reference users, and comments
+// reference posts and users.
+func blogSchema() *schema.Schema {
+	return &schema.Schema{Tables: []*schema.Table{
+		{Name: "comments", ForeignKeys: []*schema.ForeignKey{
+			{ReferencedTable: "posts"}, {ReferencedTable: "users"},
+		}},
+		{Name: "posts", ForeignKeys: []*schema.ForeignKey{{ReferencedTable: "users"}}},
+		{Name: "users"},
+		{Name: "tags"},
+	}}
+}
+
+func TestReorderInserts(t *testing.T) {
+	tests := []struct {
+		name       string
+		statements []string
+		expected   []string
+	}{
+		{
+			name: "parents first",
+			statements: []string{
+				"INSERT INTO comments (id) VALUES (1)",
+				"INSERT INTO posts (id) VALUES (1)",
+				"INSERT INTO users (id) VALUES (1)",
+			},
+			expected: []string{
+				"INSERT INTO users (id) VALUES (1)",
+				"INSERT INTO posts (id) VALUES (1)",
+				"INSERT INTO comments (id) VALUES (1)",
+			},
+		},
+		{
+			name: "stable within a table and for independent tables",
+			statements: []string{
+				"INSERT INTO tags (id) VALUES (1)",
+				"INSERT INTO posts (id) VALUES (1)",
+				"INSERT INTO users (id) VALUES (1)",
+				"INSERT INTO posts (id) VALUES (2)",
+				"INSERT INTO users (id) VALUES (2)",
+				"INSERT INTO tags (id) VALUES (2)",
+			},
+			expected: []string{
+				"INSERT INTO tags (id) VALUES (1)",
+				"INSERT INTO users (id) VALUES (1)",
+				"INSERT INTO users (id) VALUES (2)",
+				"INSERT INTO posts (id) VALUES (1)",
+				"INSERT INTO posts (id) VALUES (2)",
+				"INSERT INTO tags (id) VALUES (2)",
+			},
+		},
+		{
+			name: "other statements are not crossed",
+			statements: []string{
+				"INSERT INTO posts (id) VALUES (1)",
+				"DELETE FROM tags WHERE true",
+				"INSERT INTO comments (id) VALUES (1)",
+				"INSERT INTO users (id) VALUES (1)",
+			},
+			expected: []string{
+				"INSERT INTO posts (id) VALUES (1)",
+				"DELETE FROM tags WHERE true",
+				"INSERT INTO users (id) VALUES (1)",
+				"INSERT INTO comments (id) VALUES (1)",
+			},
+		},
+		{
+			name: "unknown tables keep their place",
+			statements: []string{
+				"INSERT INTO audit (id) VALUES (1)",
+				"INSERT INTO `Posts` (id) VALUES (1)",
+				"INSERT INTO Users (id) VALUES (1)",
+			},
+			expected: []string{
+				"INSERT INTO audit (id) VALUES (1)",
+				"INSERT INTO Users (id) VALUES (1)",
+				"INSERT INTO `Posts` (id) VALUES (1)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements := make([]parser.Statement, len(tt.statements))
+			for i, sql := range tt.statements {
+				statements[i] = parser.Statement{SQL: sql, Line: i + 1}
+			}
+
+			result, err := reorderInserts(statements, blogSchema(), parser.GoogleSQL)
+			if err != nil {
+				t.Fatalf("reorderInserts() error = %v", err)
+			}
+			if sql := parser.SQL(result); !reflect.DeepEqual(sql, tt.expected) {
+				t.Errorf("reorderInserts() = %q, expected %q", sql, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReorderInserts_Cycle(t *testing.T) {
+	s := &schema.Schema{Tables: []*schema.Table{
+		{Name: "a", ForeignKeys: []*schema.ForeignKey{{ReferencedTable: "b"}}},
+		{Name: "b", ForeignKeys: []*schema.ForeignKey{{ReferencedTable: "a"}}},
+	}}
+	statements := []parser.Statement{
+		{SQL: "INSERT INTO a (id) VALUES (1)", File: "seed.sql", Line: 1},
+		{SQL: "INSERT INTO b (id) VALUES (1)", File: "seed.sql", Line: 2},
+	}
+
+	_, err := reorderInserts(statements, s, parser.GoogleSQL)
+	if err == nil || !strings.Contains(err.Error(), "cycle between tables a -> b -> a (first INSERT at seed.sql:1)") {
+		t.Errorf("reorderInserts() error = %v, expected a cycle", err)
+	}
+
+	// A cycle is only an error when both tables are inserted into
+	if _, err := reorderInserts(statements[:1], s, parser.GoogleSQL); err != nil {
+		t.Errorf("reorderInserts() error = %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/nu0ma/spemu/pkg/parser"
+	"github.com/nu0ma/spemu/pkg/schema"
+)
+
+// ResetDatabase deletes all rows from all tables, keeping the schema. The
+// rows are deleted in a single transaction, which is much faster than
+// recreating the database.
+func (e *Executor) ResetDatabase(verbose bool) error {
+	if e.Dialect == parser.PostgreSQL {
+		return fmt.Errorf("resetting is not supported with the PostgreSQL dialect")
+	}
+	s, err := e.loadSchema()
+	if err != nil {
+		return err
+	}
+
+	tables := deletionOrder(s)
+	mutations := make([]*spanner.Mutation, len(tables))
+	for i, table := range tables {
+		mutations[i] = spanner.Delete(table, spanner.AllKeys())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	if _, err := e.client.Apply(ctx, mutations); err != nil {
+		return fmt.Errorf("failed to delete rows: %w", err)
+	}
+	if verbose {
+		fmt.Printf("Deleted all rows from %d tables\n", len(tables))
+	}
+	return nil
+}
+
+// deletionOrder returns the names of the tables of s, tables before the
+// tables they depend on. If the dependencies form a cycle, the tables are
+// returned in reverse schema order.
+func deletionOrder(s *schema.Schema) []string {
+	tables, err := s.Order()
+	if err != nil {
+		tables = s.Tables
+	}
+
+	names := make([]string, len(tables))
+	for i, t := range tables {
+		names[len(tables)-1-i] = t.Name
+	}
+	return names
+}
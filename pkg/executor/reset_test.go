@@ -0,0 +1,21 @@
+package executor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nu0ma/spemu/pkg/schema"
+)
+
+func TestDeletionOrder(t *testing.T) {
+	s := &schema.Schema{Tables: []*schema.Table{
+		{Name: "posts", ForeignKeys: []*schema.ForeignKey{{ReferencedTable: "users"}}},
+		{Name: "users"},
+		{Name: "comments", Parent: "posts"},
+	}}
+
+	expected := []string{"comments", "posts", "users"}
+	if result := deletionOrder(s); !reflect.DeepEqual(result, expected) {
+		t.Errorf("deletionOrder() = %v, expected %v", result, expected)
+	}
+}
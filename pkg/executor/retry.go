@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"google.golang.org/grpc/codes"
+)
+
+// RetryPolicy controls how transactions that fail with ABORTED or
+// UNAVAILABLE are retried. The emulator supports only one read-write
+// transaction at a time and aborts concurrent ones, so seeding from parallel
+// tests relies on retries.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first.
+	// Values below 1 mean a single attempt.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the delay grows by after each retry.
+	Multiplier float64
+}
+
+// DefaultRetryPolicy returns the retry policy used by New.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+	}
+}
+
+// BusyError is returned when a transaction is still aborted or unavailable
+// after all attempts, which usually means another read-write transaction
+// holds the emulator. It is distinct from errors caused by the statements.
+type BusyError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *BusyError) Error() string {
+	return fmt.Sprintf("emulator busy: transaction failed with %s after %d attempts (another read-write transaction may be running against the emulator): %v",
+		spanner.ErrCode(e.Err), e.Attempts, e.Err)
+}
+
+func (e *BusyError) Unwrap() error {
+	return e.Err
+}
+
+// IsBusy reports whether err is a BusyError.
+func IsBusy(err error) bool {
+	var busy *BusyError
+	return errors.As(err, &busy)
+}
+
+// IsRetryable reports whether err is a transient error that is worth
+// retrying the transaction for.
+func IsRetryable(err error) bool {
+	switch spanner.ErrCode(err) {
+	case codes.Aborted, codes.Unavailable:
+		return true
+	}
+	return false
+}
+
+// sleep is replaced in tests.
+var sleep = time.Sleep
+
+// retry runs attempt until it succeeds, fails with an error that is not
+// retryable or the policy's attempts are used up. It returns the number of
+// attempts made.
+func retry(policy RetryPolicy, verbose bool, attempt func() error) (int, error) {
+	maxAttempts := max(1, policy.MaxAttempts)
+	backoff := policy.InitialBackoff
+
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil {
+			return n, nil
+		}
+		if !IsRetryable(err) {
+			return n, err
+		}
+		if n >= maxAttempts {
+			return n, &BusyError{Attempts: n, Err: err}
+		}
+
+		// Jitter keeps concurrent retries from colliding again
+		delay := time.Duration(float64(backoff) * (0.8 + 0.4*rand.Float64()))
+		if verbose {
+			fmt.Printf("Transaction failed with %s (attempt %d/%d), retrying in %s\n",
+				spanner.ErrCode(err), n, maxAttempts, delay.Round(time.Millisecond))
+		}
+		sleep(delay)
+
+		if policy.Multiplier > 1 {
+			backoff = time.Duration(float64(backoff) * policy.Multiplier)
+		}
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetry(t *testing.T) {
+	var delays []time.Duration
+	sleep = func(d time.Duration) { delays = append(delays, d) }
+	defer func() { sleep = time.Sleep }()
+
+	policy := RetryPolicy{MaxAttempts: 5, InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Multiplier: 2}
+	aborted := fmt.Errorf("failed to commit transaction: %w", status.Error(codes.Aborted, "transaction aborted"))
+
+	tests := []struct {
+		name      string
+		errs      []error
+		attempts  int
+		busy      bool
+		retryable bool
+	}{
+		{name: "success", errs: []error{nil}, attempts: 1},
+		{name: "aborted then success", errs: []error{aborted, aborted, nil}, attempts: 3},
+		{name: "unavailable then success", errs: []error{status.Error(codes.Unavailable, "unavailable"), nil}, attempts: 2},
+		{name: "bad SQL is not retried", errs: []error{status.Error(codes.InvalidArgument, "syntax error")}, attempts: 1},
+		{name: "aborted until attempts run out", errs: []error{aborted, aborted, aborted, aborted, aborted}, attempts: 5, busy: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delays = nil
+			calls := 0
+			attempts, err := retry(policy, false, func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+
+			if attempts != tt.attempts || calls != tt.attempts {
+				t.Errorf("retry() attempts = %d (calls %d), expected %d", attempts, calls, tt.attempts)
+			}
+			if IsBusy(err) != tt.busy {
+				t.Errorf("IsBusy(%v) = %v, expected %v", err, IsBusy(err), tt.busy)
+			}
+			if want := tt.errs[len(tt.errs)-1]; want != nil && !errors.Is(err, want) {
+				t.Errorf("retry() error = %v, expected it to wrap %v", err, want)
+			}
+			if len(delays) != tt.attempts-1 {
+				t.Errorf("slept %d times, expected %d", len(delays), tt.attempts-1)
+			}
+		})
+	}
+
+	// Backoff grows by the multiplier up to the maximum, with jitter
+	bases := []time.Duration{100, 200, 300, 300}
+	for i, d := range delays {
+		base := bases[i] * time.Millisecond
+		if d < base*8/10 || d > base*12/10 {
+			t.Errorf("delay %d = %s, expected about %s", i+1, d, base)
+		}
+	}
+}
+
+func TestBusyError(t *testing.T) {
+	err := fmt.Errorf("transaction failed: %w", &BusyError{Attempts: 5, Err: status.Error(codes.Aborted, "aborted")})
+
+	if !IsBusy(err) {
+		t.Error("IsBusy() = false, expected true")
+	}
+	if !strings.Contains(err.Error(), "emulator busy") || !strings.Contains(err.Error(), "after 5 attempts") {
+		t.Errorf("unexpected message: %v", err)
+	}
+	if IsBusy(errors.New("syntax error")) {
+		t.Error("IsBusy() = true for an unrelated error")
+	}
+}
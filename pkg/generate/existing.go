@@ -0,0 +1,152 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+)
+
+// LoadExisting reads the keys of existing rows of the tables in counts and
+// the tables they reference, so that generated rows can reference existing
+// parents and do not collide with existing primary keys. INT64 key
+// sequences continue after the largest existing key.
+func (g *Generator) LoadExisting(ctx context.Context, client *spanner.Client, counts map[string]int) error {
+	needed := make(map[string][]string)
+	add := func(table string, columns []string) {
+		table = strings.ToLower(table)
+		for _, c := range columns {
+			if indexOf(needed[table], c) == -1 {
+				needed[table] = append(needed[table], c)
+			}
+		}
+	}
+
+	for name := range counts {
+		t := g.schema.Table(name)
+		if t == nil {
+			return fmt.Errorf("table %s does not exist", name)
+		}
+		add(t.Name, t.PrimaryKey)
+
+		refs, err := g.references(t)
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			add(ref.referencedTable, ref.referencedColumns)
+		}
+	}
+
+	for name := range needed {
+		t := g.schema.Table(name)
+		if t == nil {
+			continue
+		}
+		// Referenced tables need their keys as well, to continue sequences
+		// even when foreign keys reference other columns
+		add(t.Name, t.PrimaryKey)
+		columns := needed[name]
+		if len(columns) == 0 {
+			continue
+		}
+
+		rows, err := readRows(ctx, client, t.Name, columns, func(column string) string {
+			return t.Column(column).BaseType()
+		})
+		if err != nil {
+			return err
+		}
+		g.AddRows(t.Name, columns, rows)
+
+		for _, key := range t.PrimaryKey {
+			if t.Column(key).BaseType() != "INT64" {
+				continue
+			}
+			idx := indexOf(columns, key)
+			if idx < 0 {
+				continue
+			}
+			var maxID int64
+			for _, row := range rows {
+				if id := row[idx].(int64); id > maxID {
+					maxID = id
+				}
+			}
+			g.SetSequence(t.Name, key, maxID+1)
+		}
+	}
+
+	return nil
+}
+
+// readRows reads the given columns of all rows of a table. Rows with a NULL
+// in any of the columns are skipped, as they cannot be referenced.
+func readRows(ctx context.Context, client *spanner.Client, table string, columns []string, typeOf func(string) string) ([][]interface{}, error) {
+	iter := client.Single().Read(ctx, table, spanner.AllKeys(), columns)
+
+	var rows [][]interface{}
+	err := iter.Do(func(row *spanner.Row) error {
+		values := make([]interface{}, len(columns))
+		for i, column := range columns {
+			v, ok, err := decode(row, i, typeOf(column))
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", table, column, err)
+			}
+			if !ok {
+				return nil
+			}
+			values[i] = v
+		}
+		rows = append(rows, values)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read existing rows of %s: %w", table, err)
+	}
+
+	return rows, nil
+}
+
+// decode reads a key column value as the Go type the generator uses for the
+// Spanner type, and reports false for NULL.
+func decode(row *spanner.Row, i int, typ string) (interface{}, bool, error) {
+	switch typ {
+	case "INT64":
+		var v spanner.NullInt64
+		err := row.Column(i, &v)
+		return v.Int64, v.Valid, err
+	case "STRING":
+		var v spanner.NullString
+		err := row.Column(i, &v)
+		return v.StringVal, v.Valid, err
+	case "FLOAT64":
+		var v spanner.NullFloat64
+		err := row.Column(i, &v)
+		return v.Float64, v.Valid, err
+	case "BOOL":
+		var v spanner.NullBool
+		err := row.Column(i, &v)
+		return v.Bool, v.Valid, err
+	case "BYTES":
+		var v []byte
+		err := row.Column(i, &v)
+		return v, v != nil, err
+	case "DATE":
+		var v spanner.NullDate
+		err := row.Column(i, &v)
+		return v.Date, v.Valid, err
+	case "TIMESTAMP":
+		var v spanner.NullTime
+		err := row.Column(i, &v)
+		return v.Time, v.Valid, err
+	case "NUMERIC":
+		var v spanner.NullNumeric
+		err := row.Column(i, &v)
+		return new(big.Rat).Set(&v.Numeric), v.Valid, err
+	}
+
+	return nil, false, fmt.Errorf("unsupported key type %s", typ)
+}
@@ -0,0 +1,566 @@
+// Package generate produces synthetic rows that satisfy a database schema.
+package generate
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/nu0ma/spemu/pkg/schema"
+)
+
+// nullRatio is the share of NULL values generated for nullable columns.
+const nullRatio = 0.1
+
+// maxKeyAttempts bounds retries when a generated primary key collides.
+const maxKeyAttempts = 100
+
+// baseTime is the start of the range timestamps and dates are generated in.
+// A fixed value keeps output reproducible for a given seed.
+var baseTime = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Batch holds generated rows for a table.
+type Batch struct {
+	Table   string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// rowSet is a set of known rows of a table.
+type rowSet struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+// Generator generates rows for the tables of a schema. Foreign key and
+// interleaved columns reference rows that were generated earlier or
+// registered with AddRows.
+type Generator struct {
+	schema   *schema.Schema
+	rand     *rand.Rand
+	known    map[string][]rowSet
+	sequence map[string]int64
+	keys     map[string]map[string]bool
+}
+
+// New creates a generator. Generators with the same schema, seed and inputs
+// produce the same rows.
+func New(s *schema.Schema, seed int64) *Generator {
+	return &Generator{
+		schema:   s,
+		rand:     rand.New(rand.NewSource(seed)),
+		known:    make(map[string][]rowSet),
+		sequence: make(map[string]int64),
+		keys:     make(map[string]map[string]bool),
+	}
+}
+
+// AddRows registers rows that already exist in a table, so that foreign keys
+// can reference them and generated primary keys do not collide with them.
+func (g *Generator) AddRows(table string, columns []string, rows [][]interface{}) {
+	table = strings.ToLower(table)
+	g.known[table] = append(g.known[table], rowSet{columns: columns, rows: rows})
+
+	t := g.schema.Table(table)
+	if t == nil {
+		return
+	}
+	for _, row := range rows {
+		if key, ok := keyOf(t, columns, row); ok {
+			g.keySet(table)[key] = true
+		}
+	}
+}
+
+// SetSequence sets the next value used for a generated INT64 key column,
+// e.g. to continue after the largest existing key.
+func (g *Generator) SetSequence(table, column string, next int64) {
+	g.sequence[strings.ToLower(table+"."+column)] = next
+}
+
+// Generate generates the given number of rows per table name. Tables are
+// generated parents first, so the returned batches can be inserted in order.
+func (g *Generator) Generate(counts map[string]int) ([]*Batch, error) {
+	remaining := make(map[string]int, len(counts))
+	for name, n := range counts {
+		if g.schema.Table(name) == nil {
+			return nil, fmt.Errorf("table %s does not exist", name)
+		}
+		remaining[strings.ToLower(name)] = n
+	}
+
+	ordered, err := g.schema.Order()
+	if err != nil {
+		return nil, err
+	}
+
+	var batches []*Batch
+	for _, t := range ordered {
+		n, ok := remaining[strings.ToLower(t.Name)]
+		if !ok || n <= 0 {
+			continue
+		}
+
+		batch, err := g.generateTable(t, n)
+		if err != nil {
+			return nil, err
+		}
+		g.known[strings.ToLower(t.Name)] = append(g.known[strings.ToLower(t.Name)], rowSet{columns: batch.Columns, rows: batch.Rows})
+		batches = append(batches, batch)
+	}
+
+	return batches, nil
+}
+
+// reference is a set of columns whose values must match a row of another
+// table: a foreign key or the key of an interleaved table's parent.
+type reference struct {
+	columns           []string
+	referencedTable   string
+	referencedColumns []string
+}
+
+func (g *Generator) references(t *schema.Table) ([]reference, error) {
+	var refs []reference
+
+	if t.Parent != "" {
+		parent := g.schema.Table(t.Parent)
+		if parent == nil {
+			return nil, fmt.Errorf("%s: parent table %s does not exist", t.Name, t.Parent)
+		}
+		if len(parent.PrimaryKey) > len(t.PrimaryKey) {
+			return nil, fmt.Errorf("%s: primary key does not start with the key of parent table %s", t.Name, parent.Name)
+		}
+		refs = append(refs, reference{
+			columns:           t.PrimaryKey[:len(parent.PrimaryKey)],
+			referencedTable:   parent.Name,
+			referencedColumns: parent.PrimaryKey,
+		})
+	}
+
+	for _, fk := range t.ForeignKeys {
+		// Self references are left to regular value generation
+		if strings.EqualFold(fk.ReferencedTable, t.Name) {
+			continue
+		}
+		refs = append(refs, reference{
+			columns:           fk.Columns,
+			referencedTable:   fk.ReferencedTable,
+			referencedColumns: fk.ReferencedColumns,
+		})
+	}
+
+	return refs, nil
+}
+
+func (g *Generator) generateTable(t *schema.Table, n int) (*Batch, error) {
+	batch := &Batch{Table: t.Name}
+	var columns []*schema.Column
+	for _, c := range t.Columns {
+		if !c.Generated {
+			columns = append(columns, c)
+			batch.Columns = append(batch.Columns, c.Name)
+		}
+	}
+
+	refs, err := g.references(t)
+	if err != nil {
+		return nil, err
+	}
+	pools := make([][][]interface{}, len(refs))
+	for i, ref := range refs {
+		pools[i] = g.pool(ref.referencedTable, ref.referencedColumns)
+		if len(pools[i]) > 0 {
+			continue
+		}
+		for _, name := range ref.columns {
+			if c := t.Column(name); c != nil && c.NotNull {
+				return nil, fmt.Errorf("%s: cannot generate rows because %s has no rows to reference", t.Name, ref.referencedTable)
+			}
+		}
+	}
+
+	keys := g.keySet(t.Name)
+	for len(batch.Rows) < n {
+		var row []interface{}
+		var key string
+		for attempt := 0; ; attempt++ {
+			row, err = g.generateRow(t, columns, refs, pools)
+			if err != nil {
+				return nil, err
+			}
+			var ok bool
+			if key, ok = keyOf(t, batch.Columns, row); !ok || !keys[key] {
+				break
+			}
+			if attempt == maxKeyAttempts {
+				return nil, fmt.Errorf("%s: could not generate %d rows with unique primary keys", t.Name, n)
+			}
+		}
+		keys[key] = true
+		batch.Rows = append(batch.Rows, row)
+	}
+
+	return batch, nil
+}
+
+func (g *Generator) generateRow(t *schema.Table, columns []*schema.Column, refs []reference, pools [][][]interface{}) ([]interface{}, error) {
+	values := make(map[string]interface{}, len(columns))
+	assigned := make(map[string]bool, len(columns))
+
+	for i, ref := range refs {
+		var parent []interface{}
+		if len(pools[i]) > 0 {
+			parent = pools[i][g.rand.Intn(len(pools[i]))]
+		}
+		for j, name := range ref.columns {
+			name = strings.ToLower(name)
+			if assigned[name] {
+				continue
+			}
+			assigned[name] = true
+			if parent != nil {
+				values[name] = parent[j]
+			} else if c := t.Column(name); c != nil {
+				values[name] = nullValue(c.BaseType())
+			}
+		}
+	}
+
+	row := make([]interface{}, len(columns))
+	for i, c := range columns {
+		name := strings.ToLower(c.Name)
+		if assigned[name] {
+			row[i] = values[name]
+			continue
+		}
+
+		var err error
+		if isKey(t, c.Name) {
+			row[i], err = g.keyValue(t, c)
+		} else {
+			row[i], err = g.value(c)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return row, nil
+}
+
+func (g *Generator) keyValue(t *schema.Table, c *schema.Column) (interface{}, error) {
+	switch c.BaseType() {
+	case "INT64":
+		name := strings.ToLower(t.Name + "." + c.Name)
+		next, ok := g.sequence[name]
+		if !ok {
+			next = 1
+		}
+		g.sequence[name] = next + 1
+		return next, nil
+	case "STRING":
+		return truncate(g.id(), c.MaxLength()), nil
+	}
+
+	return g.scalar(c.Name, c.BaseType(), c.MaxLength(), c.NotNull)
+}
+
+func (g *Generator) value(c *schema.Column) (interface{}, error) {
+	typ := c.BaseType()
+	if !c.NotNull && g.rand.Float64() < nullRatio {
+		return nullValue(typ), nil
+	}
+
+	if elem, ok := strings.CutPrefix(typ, "ARRAY<"); ok {
+		elem = strings.TrimSuffix(elem, ">")
+		return g.array(c.Name, elem, c.MaxLength())
+	}
+
+	return g.scalar(c.Name, typ, c.MaxLength(), c.NotNull)
+}
+
+func (g *Generator) scalar(name, typ string, maxLength int, notNull bool) (interface{}, error) {
+	switch typ {
+	case "STRING":
+		return truncate(g.text(name), maxLength), nil
+	case "INT64":
+		return g.rand.Int63n(10000), nil
+	case "FLOAT64":
+		return float64(g.rand.Int63n(100000)) / 100, nil
+	case "FLOAT32":
+		return float32(g.rand.Int63n(100000)) / 100, nil
+	case "NUMERIC":
+		return big.NewRat(g.rand.Int63n(1000000), 100), nil
+	case "BOOL":
+		return g.rand.Intn(2) == 1, nil
+	case "DATE":
+		return civil.DateOf(baseTime.AddDate(0, 0, g.rand.Intn(5*365))), nil
+	case "TIMESTAMP":
+		return baseTime.Add(time.Duration(g.rand.Int63n(5*365*24*3600)) * time.Second), nil
+	case "BYTES":
+		n := 16
+		if maxLength > 0 && maxLength < n {
+			n = maxLength
+		}
+		b := make([]byte, n)
+		g.rand.Read(b)
+		return b, nil
+	case "JSON":
+		return spanner.NullJSON{Value: map[string]interface{}{
+			"id":  g.rand.Intn(1000),
+			"tag": words[g.rand.Intn(len(words))],
+		}, Valid: true}, nil
+	}
+
+	if !notNull {
+		return nullValue(typ), nil
+	}
+	return nil, fmt.Errorf("column %s: unsupported type %s", name, typ)
+}
+
+func (g *Generator) array(name, elem string, maxLength int) (interface{}, error) {
+	n := g.rand.Intn(4)
+	values := make([]interface{}, n)
+	for i := range values {
+		v, err := g.scalar(name, elem, maxLength, true)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+
+	switch elem {
+	case "STRING":
+		return typedSlice[string](values), nil
+	case "INT64":
+		return typedSlice[int64](values), nil
+	case "FLOAT64":
+		return typedSlice[float64](values), nil
+	case "FLOAT32":
+		return typedSlice[float32](values), nil
+	case "NUMERIC":
+		return typedSlice[*big.Rat](values), nil
+	case "BOOL":
+		return typedSlice[bool](values), nil
+	case "DATE":
+		return typedSlice[civil.Date](values), nil
+	case "TIMESTAMP":
+		return typedSlice[time.Time](values), nil
+	case "BYTES":
+		return typedSlice[[]byte](values), nil
+	case "JSON":
+		return typedSlice[spanner.NullJSON](values), nil
+	}
+
+	return nil, fmt.Errorf("column %s: unsupported array element type %s", name, elem)
+}
+
+func typedSlice[T any](values []interface{}) []T {
+	result := make([]T, len(values))
+	for i, v := range values {
+		result[i] = v.(T)
+	}
+	return result
+}
+
+// nullValue returns a typed NULL for typ, so that NULL values are bound with
+// the column's type in mutations.
+func nullValue(typ string) interface{} {
+	switch typ {
+	case "STRING":
+		return spanner.NullString{}
+	case "INT64":
+		return spanner.NullInt64{}
+	case "FLOAT64":
+		return spanner.NullFloat64{}
+	case "FLOAT32":
+		return spanner.NullFloat32{}
+	case "NUMERIC":
+		return spanner.NullNumeric{}
+	case "BOOL":
+		return spanner.NullBool{}
+	case "DATE":
+		return spanner.NullDate{}
+	case "TIMESTAMP":
+		return spanner.NullTime{}
+	case "BYTES":
+		return []byte(nil)
+	case "JSON":
+		return spanner.NullJSON{}
+	}
+	return nil
+}
+
+// text generates a string value, using the column name to pick a realistic
+// kind of value.
+func (g *Generator) text(column string) string {
+	name := strings.ToLower(column)
+	first := firstNames[g.rand.Intn(len(firstNames))]
+	last := lastNames[g.rand.Intn(len(lastNames))]
+
+	switch {
+	case strings.Contains(name, "email"):
+		return fmt.Sprintf("%s.%s%d@example.com", strings.ToLower(first), strings.ToLower(last), g.rand.Intn(10000))
+	case strings.Contains(name, "first_name") || strings.Contains(name, "firstname"):
+		return first
+	case strings.Contains(name, "last_name") || strings.Contains(name, "lastname"):
+		return last
+	case strings.Contains(name, "name"):
+		return first + " " + last
+	case strings.Contains(name, "url"):
+		return fmt.Sprintf("https://example.com/%s/%d", words[g.rand.Intn(len(words))], g.rand.Intn(10000))
+	case strings.Contains(name, "phone"):
+		return fmt.Sprintf("+1-555-%04d", g.rand.Intn(10000))
+	case strings.Contains(name, "title"):
+		title := g.words(3 + g.rand.Intn(4))
+		r, size := utf8.DecodeRuneInString(title)
+		return string(unicode.ToUpper(r)) + title[size:]
+	case strings.Contains(name, "content") || strings.Contains(name, "body") || strings.Contains(name, "description"):
+		return g.words(8+g.rand.Intn(24)) + "."
+	}
+
+	return g.words(1 + g.rand.Intn(3))
+}
+
+func (g *Generator) words(n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = words[g.rand.Intn(len(words))]
+	}
+	return strings.Join(parts, " ")
+}
+
+// id generates a random UUID-formatted string from the generator's source.
+func (g *Generator) id() string {
+	b := make([]byte, 16)
+	g.rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// pool returns the known values of the given columns of a table.
+func (g *Generator) pool(table string, columns []string) [][]interface{} {
+	var result [][]interface{}
+	for _, set := range g.known[strings.ToLower(table)] {
+		index := make([]int, len(columns))
+		complete := true
+		for i, name := range columns {
+			index[i] = indexOf(set.columns, name)
+			if index[i] == -1 {
+				complete = false
+			}
+		}
+		if !complete {
+			continue
+		}
+
+		for _, row := range set.rows {
+			values := make([]interface{}, len(columns))
+			for i, idx := range index {
+				values[i] = row[idx]
+			}
+			result = append(result, values)
+		}
+	}
+	return result
+}
+
+func (g *Generator) keySet(table string) map[string]bool {
+	table = strings.ToLower(table)
+	if g.keys[table] == nil {
+		g.keys[table] = make(map[string]bool)
+	}
+	return g.keys[table]
+}
+
+// keyOf returns a string identifying the primary key of a row, or false if
+// the row does not contain all key columns.
+func keyOf(t *schema.Table, columns []string, row []interface{}) (string, bool) {
+	if len(t.PrimaryKey) == 0 {
+		return "", false
+	}
+	parts := make([]string, len(t.PrimaryKey))
+	for i, name := range t.PrimaryKey {
+		idx := indexOf(columns, name)
+		if idx == -1 {
+			return "", false
+		}
+		parts[i] = fmt.Sprintf("%v", row[idx])
+	}
+	return strings.Join(parts, "\x00"), true
+}
+
+func isKey(t *schema.Table, column string) bool {
+	return indexOf(t.PrimaryKey, column) != -1
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if strings.EqualFold(v, value) {
+			return i
+		}
+	}
+	return -1
+}
+
+func truncate(s string, maxLength int) string {
+	if maxLength <= 0 {
+		return s
+	}
+	runes := []rune(s)
+	if len(runes) <= maxLength {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:maxLength]))
+}
+
+// ParseCounts parses a list of table row counts such as users=1000,posts=5000.
+func ParseCounts(spec string) (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		table, count, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid row count %q: expected table=count", part)
+		}
+		var n int
+		if _, err := fmt.Sscanf(count, "%d", &n); err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid row count %q: expected table=count", part)
+		}
+		counts[strings.TrimSpace(table)] = n
+	}
+	if len(counts) == 0 {
+		return nil, fmt.Errorf("no row counts given")
+	}
+	return counts, nil
+}
+
+var firstNames = []string{
+	"James", "Mary", "John", "Patricia", "Robert", "Jennifer", "Michael", "Linda",
+	"William", "Elizabeth", "David", "Barbara", "Richard", "Susan", "Joseph", "Jessica",
+	"Thomas", "Sarah", "Charles", "Karen", "Yuki", "Haruto", "Sakura", "Ren",
+}
+
+var lastNames = []string{
+	"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis",
+	"Rodriguez", "Martinez", "Hernandez", "Lopez", "Wilson", "Anderson", "Taylor", "Thomas",
+	"Moore", "Jackson", "Martin", "Lee", "Sato", "Suzuki", "Takahashi", "Tanaka",
+}
+
+var words = []string{
+	"spanner", "emulator", "cloud", "database", "query", "table", "index", "schema",
+	"seed", "fixture", "test", "data", "row", "column", "key", "value",
+	"fast", "simple", "local", "reliable", "sample", "random", "quick", "great",
+	"hello", "world", "update", "release", "feature", "project", "team", "review",
+}
@@ -0,0 +1,306 @@
+package generate
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/nu0ma/spemu/pkg/parser"
+	"github.com/nu0ma/spemu/pkg/schema"
+)
+
+func testSchema() *schema.Schema {
+	return &schema.Schema{Tables: []*schema.Table{
+		{
+			Name: "posts",
+			Columns: []*schema.Column{
+				{Name: "id", Type: "INT64", NotNull: true},
+				{Name: "user_id", Type: "INT64", NotNull: true},
+				{Name: "title", Type: "STRING(20)", NotNull: true},
+				{Name: "tags", Type: "ARRAY<STRING(8)>"},
+				{Name: "title_length", Type: "INT64", Generated: true},
+			},
+			PrimaryKey: []string{"id"},
+			ForeignKeys: []*schema.ForeignKey{
+				{Name: "fk_user", Columns: []string{"user_id"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}},
+			},
+		},
+		{
+			Name: "users",
+			Columns: []*schema.Column{
+				{Name: "id", Type: "INT64", NotNull: true},
+				{Name: "name", Type: "STRING(10)", NotNull: true},
+				{Name: "email", Type: "STRING(255)"},
+				{Name: "active", Type: "BOOL"},
+				{Name: "created_at", Type: "TIMESTAMP", NotNull: true},
+			},
+			PrimaryKey: []string{"id"},
+		},
+		{
+			Name: "comments",
+			Columns: []*schema.Column{
+				{Name: "user_id", Type: "INT64", NotNull: true},
+				{Name: "comment_id", Type: "STRING(36)", NotNull: true},
+				{Name: "body", Type: "STRING(MAX)"},
+			},
+			PrimaryKey: []string{"user_id", "comment_id"},
+			Parent:     "users",
+		},
+	}}
+}
+
+func TestGenerate(t *testing.T) {
+	s := testSchema()
+	batches, err := New(s, 1).Generate(map[string]int{"users": 10, "posts": 50, "comments": 20})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var order []string
+	byTable := make(map[string]*Batch)
+	for _, b := range batches {
+		order = append(order, b.Table)
+		byTable[b.Table] = b
+	}
+	if !reflect.DeepEqual(order, []string{"users", "comments", "posts"}) && !reflect.DeepEqual(order, []string{"users", "posts", "comments"}) {
+		t.Fatalf("batch order = %v, expected users first", order)
+	}
+
+	users := byTable["users"]
+	if len(users.Rows) != 10 {
+		t.Fatalf("len(users.Rows) = %d, expected 10", len(users.Rows))
+	}
+	userIDs := make(map[int64]bool)
+	for _, row := range users.Rows {
+		id := row[0].(int64)
+		if userIDs[id] {
+			t.Errorf("duplicate user id %d", id)
+		}
+		userIDs[id] = true
+		if name := row[1].(string); len(name) > 10 || name == "" {
+			t.Errorf("name %q does not fit STRING(10)", name)
+		}
+	}
+
+	posts := byTable["posts"]
+	if !reflect.DeepEqual(posts.Columns, []string{"id", "user_id", "title", "tags"}) {
+		t.Errorf("posts.Columns = %v, generated columns must be skipped", posts.Columns)
+	}
+	for _, row := range posts.Rows {
+		if !userIDs[row[1].(int64)] {
+			t.Errorf("post references unknown user %v", row[1])
+		}
+		if title := row[2].(string); len(title) > 20 {
+			t.Errorf("title %q does not fit STRING(20)", title)
+		}
+		if tags, ok := row[3].([]string); ok {
+			for _, tag := range tags {
+				if len(tag) > 8 {
+					t.Errorf("tag %q does not fit STRING(8)", tag)
+				}
+			}
+		}
+	}
+
+	for _, row := range byTable["comments"].Rows {
+		if !userIDs[row[0].(int64)] {
+			t.Errorf("comment references unknown parent user %v", row[0])
+		}
+	}
+}
+
+func TestGenerator_TitleText(t *testing.T) {
+	g := New(testSchema(), 1)
+	for i := 0; i < 20; i++ {
+		title := g.text("title")
+		parts := strings.Fields(title)
+		if len(parts) < 3 || len(parts) > 6 {
+			t.Fatalf("title %q has %d words, expected 3 to 6", title, len(parts))
+		}
+		if first := parts[0][:1]; first != strings.ToUpper(first) {
+			t.Errorf("title %q is not capitalized", title)
+		}
+		for _, part := range parts {
+			if indexOf(words, part) == -1 {
+				t.Errorf("title %q contains %q, which is not a word", title, part)
+			}
+		}
+	}
+}
+
+func TestGenerate_Reproducible(t *testing.T) {
+	counts := map[string]int{"users": 5, "posts": 5}
+
+	first, err := New(testSchema(), 42).Generate(counts)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	second, err := New(testSchema(), 42).Generate(counts)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Error("Generate() with the same seed produced different rows")
+	}
+
+	other, err := New(testSchema(), 43).Generate(counts)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if reflect.DeepEqual(first, other) {
+		t.Error("Generate() with different seeds produced the same rows")
+	}
+}
+
+func TestGenerate_ExistingRows(t *testing.T) {
+	g := New(testSchema(), 1)
+	g.AddRows("users", []string{"id"}, [][]interface{}{{int64(100)}, {int64(200)}})
+
+	batches, err := g.Generate(map[string]int{"posts": 20})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for _, row := range batches[0].Rows {
+		if id := row[1].(int64); id != 100 && id != 200 {
+			t.Errorf("post references user %d, expected an existing user", id)
+		}
+	}
+
+	g = New(testSchema(), 1)
+	g.SetSequence("users", "id", 101)
+	batches, err = g.Generate(map[string]int{"users": 2})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if id := batches[0].Rows[0][0].(int64); id != 101 {
+		t.Errorf("first user id = %d, expected 101", id)
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	if _, err := New(testSchema(), 1).Generate(map[string]int{"orders": 1}); err == nil {
+		t.Error("expected an error for an unknown table")
+	}
+
+	_, err := New(testSchema(), 1).Generate(map[string]int{"posts": 1})
+	if err == nil || !strings.Contains(err.Error(), "no rows to reference") {
+		t.Errorf("Generate() error = %v, expected missing parent rows error", err)
+	}
+}
+
+func TestParseCounts(t *testing.T) {
+	counts, err := ParseCounts("users=1000, posts=5000")
+	if err != nil {
+		t.Fatalf("ParseCounts() error = %v", err)
+	}
+	if !reflect.DeepEqual(counts, map[string]int{"users": 1000, "posts": 5000}) {
+		t.Errorf("ParseCounts() = %v", counts)
+	}
+
+	for _, spec := range []string{"", "users", "users=abc", "users=-1"} {
+		if _, err := ParseCounts(spec); err == nil {
+			t.Errorf("ParseCounts(%q) expected error", spec)
+		}
+	}
+}
+
+func TestLiteral(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, "NULL"},
+		{"it's", `'it\'s'`},
+		{int64(42), "42"},
+		{float64(1), "1.0"},
+		{float64(1.5), "1.5"},
+		{true, "TRUE"},
+		{[]byte{0x01, 0xff}, `b'\x01\xff'`},
+		{[]byte(nil), "NULL"},
+		{big.NewRat(1234, 100), "NUMERIC '12.340000000'"},
+		{civil.Date{Year: 2024, Month: 1, Day: 2}, "DATE '2024-01-02'"},
+		{time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "TIMESTAMP '2024-01-02T03:04:05Z'"},
+		{spanner.NullJSON{Value: map[string]interface{}{"a": 1}, Valid: true}, `JSON '{"a":1}'`},
+		{spanner.NullString{}, "NULL"},
+		{[]string{"a", "b"}, "['a', 'b']"},
+		{[]int64{}, "[]"},
+	}
+
+	for _, tt := range tests {
+		result, err := Literal(tt.value)
+		if err != nil {
+			t.Errorf("Literal(%v) error = %v", tt.value, err)
+			continue
+		}
+		if result != tt.expected {
+			t.Errorf("Literal(%v) = %s, expected %s", tt.value, result, tt.expected)
+		}
+	}
+}
+
+func TestWriteSQL(t *testing.T) {
+	batches, err := New(testSchema(), 7).Generate(map[string]int{"users": 150, "posts": 3})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSQL(&buf, batches); err != nil {
+		t.Fatalf("WriteSQL() error = %v", err)
+	}
+
+	statements, err := parser.ParseDMLContent(buf.String())
+	if err != nil {
+		t.Fatalf("ParseDMLContent() error = %v", err)
+	}
+	// 150 users are split into two statements of at most 100 rows
+	if len(statements) != 3 {
+		t.Fatalf("got %d statements, expected 3", len(statements))
+	}
+	if !strings.HasPrefix(statements[0], "INSERT INTO users (id, name, email, active, created_at) VALUES") {
+		t.Errorf("unexpected first statement: %.80s", statements[0])
+	}
+	if !strings.HasPrefix(statements[2], "INSERT INTO posts") {
+		t.Errorf("unexpected last statement: %.80s", statements[2])
+	}
+}
+
+func TestWriteSQL_ReservedNames(t *testing.T) {
+	s := &schema.Schema{Tables: []*schema.Table{{
+		Name: "Order",
+		Columns: []*schema.Column{
+			{Name: "Id", Type: "INT64", NotNull: true},
+			{Name: "Group", Type: "STRING(10)"},
+		},
+		PrimaryKey: []string{"Id"},
+	}}}
+	batches, err := New(s, 1).Generate(map[string]int{"Order": 2})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSQL(&buf, batches); err != nil {
+		t.Fatalf("WriteSQL() error = %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "INSERT INTO `Order` (Id, `Group`) VALUES") {
+		t.Errorf("WriteSQL() = %.80s, expected quoted reserved names", buf.String())
+	}
+
+	statements, err := parser.ParseDMLContent(buf.String())
+	if err != nil {
+		t.Fatalf("ParseDMLContent() error = %v", err)
+	}
+	ins, err := parser.ParseInsertValues(statements[0])
+	if err != nil {
+		t.Fatalf("ParseInsertValues() error = %v", err)
+	}
+	if ins.Table != "Order" || !reflect.DeepEqual(ins.Columns, []string{"Id", "Group"}) || len(ins.Rows) != 2 {
+		t.Errorf("ParseInsertValues() = %+v", ins)
+	}
+}
@@ -0,0 +1,176 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/nu0ma/spemu/pkg/executor"
+	"github.com/nu0ma/spemu/pkg/parser"
+)
+
+// rowsPerStatement is the number of rows written per INSERT statement.
+const rowsPerStatement = 100
+
+// WriteSQL writes batches as multi-row INSERT statements that can be read by
+// the DML parser. Reserved table and column names are quoted.
+func WriteSQL(w io.Writer, batches []*Batch) error {
+	for _, batch := range batches {
+		for start := 0; start < len(batch.Rows); start += rowsPerStatement {
+			end := min(start+rowsPerStatement, len(batch.Rows))
+
+			var b strings.Builder
+			columns := make([]string, len(batch.Columns))
+			for i, column := range batch.Columns {
+				columns[i] = parser.QuoteIdentifier(column)
+			}
+			fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES\n", parser.QuoteIdentifier(batch.Table), strings.Join(columns, ", "))
+			for i, row := range batch.Rows[start:end] {
+				values := make([]string, len(row))
+				for j, v := range row {
+					literal, err := Literal(v)
+					if err != nil {
+						return fmt.Errorf("%s.%s: %w", batch.Table, batch.Columns[j], err)
+					}
+					values[j] = literal
+				}
+				b.WriteString("  (" + strings.Join(values, ", ") + ")")
+				if i < end-start-1 {
+					b.WriteString(",\n")
+				}
+			}
+			b.WriteString(";\n")
+
+			if _, err := io.WriteString(w, b.String()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Insert inserts batches with mutations, committing in chunks that stay
+// within Spanner's mutation limit. Batches are committed in order, so parent
+// rows exist before their children.
+func Insert(ctx context.Context, client *spanner.Client, batches []*Batch, verbose bool) error {
+	for _, batch := range batches {
+		ins := executor.NewInserter(client, batch.Table, batch.Columns)
+		if verbose {
+			ins.OnCommit = func(inserted int64) {
+				fmt.Printf("Inserted %d/%d rows into %s\n", inserted, len(batch.Rows), batch.Table)
+			}
+		}
+		for _, row := range batch.Rows {
+			if err := ins.Add(ctx, row); err != nil {
+				return err
+			}
+		}
+		if err := ins.Flush(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Literal returns v as a GoogleSQL literal.
+func Literal(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return parser.QuoteString(value), nil
+	case int64:
+		return strconv.FormatInt(value, 10), nil
+	case float64:
+		return floatLiteral(value, 64), nil
+	case float32:
+		return "CAST(" + floatLiteral(float64(value), 32) + " AS FLOAT32)", nil
+	case bool:
+		return strings.ToUpper(strconv.FormatBool(value)), nil
+	case []byte:
+		if value == nil {
+			return "NULL", nil
+		}
+		return parser.QuoteBytes(value), nil
+	case *big.Rat:
+		return "NUMERIC " + parser.QuoteString(spanner.NumericString(value)), nil
+	case civil.Date:
+		return "DATE " + parser.QuoteString(value.String()), nil
+	case time.Time:
+		return "TIMESTAMP " + parser.QuoteString(value.UTC().Format(time.RFC3339Nano)), nil
+	case spanner.NullJSON:
+		if !value.Valid {
+			return "NULL", nil
+		}
+		encoded, err := json.Marshal(value.Value)
+		if err != nil {
+			return "", err
+		}
+		return "JSON " + parser.QuoteString(string(encoded)), nil
+	case spanner.NullString, spanner.NullInt64, spanner.NullFloat64, spanner.NullFloat32,
+		spanner.NullNumeric, spanner.NullBool, spanner.NullDate, spanner.NullTime:
+		// The generator only produces these as NULL values
+		return "NULL", nil
+	case []string:
+		return arrayLiteral(value)
+	case []int64:
+		return arrayLiteral(value)
+	case []float64:
+		return arrayLiteral(value)
+	case []float32:
+		return arrayLiteral(value)
+	case []*big.Rat:
+		return arrayLiteral(value)
+	case []bool:
+		return arrayLiteral(value)
+	case []civil.Date:
+		return arrayLiteral(value)
+	case []time.Time:
+		return arrayLiteral(value)
+	case [][]byte:
+		return arrayLiteral(value)
+	case []spanner.NullJSON:
+		return arrayLiteral(value)
+	}
+
+	return "", fmt.Errorf("unsupported value type %T", v)
+}
+
+func arrayLiteral[T any](values []T) (string, error) {
+	elems := make([]string, len(values))
+	for i, v := range values {
+		literal, err := Literal(v)
+		if err != nil {
+			return "", err
+		}
+		elems[i] = literal
+	}
+	return "[" + strings.Join(elems, ", ") + "]", nil
+}
+
+func floatLiteral(f float64, bitSize int) string {
+	switch {
+	case math.IsNaN(f):
+		return "CAST('nan' AS FLOAT64)"
+	case math.IsInf(f, 1):
+		return "CAST('inf' AS FLOAT64)"
+	case math.IsInf(f, -1):
+		return "CAST('-inf' AS FLOAT64)"
+	}
+
+	s := strconv.FormatFloat(f, 'g', -1, bitSize)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
@@ -0,0 +1,215 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Format is an output format for query results.
+type Format string
+
+const (
+	FormatTable  Format = "table"
+	FormatCSV    Format = "csv"
+	FormatJSON   Format = "json"
+	FormatNDJSON Format = "ndjson"
+)
+
+// ParseFormat validates a format name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatTable, FormatCSV, FormatJSON, FormatNDJSON:
+		return f, nil
+	}
+
+	return "", fmt.Errorf("unknown format %q (expected table, csv, json or ndjson)", name)
+}
+
+// Write writes res to w in the given format.
+func Write(w io.Writer, res *Result, format Format) error {
+	switch format {
+	case FormatTable:
+		return WriteTable(w, res)
+	case FormatCSV:
+		return WriteCSV(w, res)
+	case FormatJSON:
+		return WriteJSON(w, res)
+	case FormatNDJSON:
+		return WriteNDJSON(w, res)
+	}
+
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// WriteCSV writes res as CSV with a header row. NULL is written as an empty
+// field; arrays and structs are written as JSON.
+func WriteCSV(w io.Writer, res *Result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(res.Columns); err != nil {
+		return err
+	}
+
+	for _, row := range res.Rows {
+		record := make([]string, len(row))
+		for i, v := range row {
+			switch {
+			case isNull(v.Value):
+				record[i] = ""
+			case v.Type.GetCode() == spannerpb.TypeCode_ARRAY || v.Type.GetCode() == spannerpb.TypeCode_STRUCT:
+				encoded, err := json.Marshal(jsonValue(v.Type, v.Value))
+				if err != nil {
+					return err
+				}
+				record[i] = string(encoded)
+			default:
+				record[i] = FormatValue(v)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes res as a JSON array of objects keyed by column name.
+func WriteJSON(w io.Writer, res *Result) error {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i, row := range res.Rows {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  ")
+		if err := writeObject(&buf, res.Columns, row); err != nil {
+			return err
+		}
+	}
+	if len(res.Rows) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteNDJSON writes res as one JSON object per line.
+func WriteNDJSON(w io.Writer, res *Result) error {
+	var buf bytes.Buffer
+	for _, row := range res.Rows {
+		if err := writeObject(&buf, res.Columns, row); err != nil {
+			return err
+		}
+		buf.WriteString("\n")
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeObject writes a row as a JSON object, keeping the column order.
+// Unnamed columns such as those of SELECT 1 are named _col1, _col2, ...
+func writeObject(buf *bytes.Buffer, columns []string, row []spanner.GenericColumnValue) error {
+	buf.WriteString("{")
+	for i, v := range row {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		name := fmt.Sprintf("_col%d", i+1)
+		if i < len(columns) && columns[i] != "" {
+			name = columns[i]
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(jsonValue(v.Type, v.Value))
+		if err != nil {
+			return err
+		}
+
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+
+	return nil
+}
+
+// jsonValue converts a column value to a value that encodes to typed JSON:
+// INT64 and FLOAT64 as numbers, BOOL as a boolean, JSON columns as embedded
+// JSON and NUMERIC as a string to preserve its precision.
+func jsonValue(t *spannerpb.Type, v *structpb.Value) interface{} {
+	if isNull(v) {
+		return nil
+	}
+
+	switch t.GetCode() {
+	case spannerpb.TypeCode_ARRAY:
+		elems := v.GetListValue().GetValues()
+		result := make([]interface{}, len(elems))
+		for i, elem := range elems {
+			result[i] = jsonValue(t.ArrayElementType, elem)
+		}
+		return result
+	case spannerpb.TypeCode_STRUCT:
+		fields := t.GetStructType().GetFields()
+		elems := v.GetListValue().GetValues()
+		result := make(map[string]interface{}, len(elems))
+		for i, elem := range elems {
+			name := fmt.Sprintf("_field%d", i+1)
+			var fieldType *spannerpb.Type
+			if i < len(fields) {
+				fieldType = fields[i].Type
+				if fields[i].Name != "" {
+					name = fields[i].Name
+				}
+			}
+			result[name] = jsonValue(fieldType, elem)
+		}
+		return result
+	case spannerpb.TypeCode_BOOL:
+		return v.GetBoolValue()
+	case spannerpb.TypeCode_INT64:
+		return json.Number(v.GetStringValue())
+	case spannerpb.TypeCode_FLOAT64, spannerpb.TypeCode_FLOAT32:
+		// NaN and infinities have no JSON number representation and are
+		// kept as strings
+		if n, ok := v.Kind.(*structpb.Value_NumberValue); ok {
+			// encoding/json formats float32 values with 32 bits
+			if t.GetCode() == spannerpb.TypeCode_FLOAT32 {
+				return float32(n.NumberValue)
+			}
+			return n.NumberValue
+		}
+		return v.GetStringValue()
+	case spannerpb.TypeCode_JSON:
+		if json.Valid([]byte(v.GetStringValue())) {
+			return json.RawMessage(v.GetStringValue())
+		}
+		return v.GetStringValue()
+	default:
+		return v.GetStringValue()
+	}
+}
+
+func isNull(v *structpb.Value) bool {
+	if v == nil {
+		return true
+	}
+	_, ok := v.Kind.(*structpb.Value_NullValue)
+	return ok
+}
@@ -0,0 +1,160 @@
+// Package output renders Spanner query results.
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Result holds the columns and rows returned by a query.
+type Result struct {
+	Columns []string
+	Rows    [][]spanner.GenericColumnValue
+}
+
+// Collect reads all rows from iter and stops it.
+func Collect(iter *spanner.RowIterator) (*Result, error) {
+	res := &Result{}
+	err := iter.Do(func(row *spanner.Row) error {
+		values := make([]spanner.GenericColumnValue, row.Size())
+		for i := range values {
+			if err := row.Column(i, &values[i]); err != nil {
+				return err
+			}
+		}
+		res.Rows = append(res.Rows, values)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Column names are taken from the metadata so that they are also known
+	// for queries that return no rows
+	if iter.Metadata != nil && iter.Metadata.RowType != nil {
+		for _, field := range iter.Metadata.RowType.Fields {
+			res.Columns = append(res.Columns, field.Name)
+		}
+	}
+
+	return res, nil
+}
+
+// WriteTable writes res as an aligned text table followed by a row count.
+func WriteTable(w io.Writer, res *Result) error {
+	widths := make([]int, len(res.Columns))
+	for i, col := range res.Columns {
+		widths[i] = utf8.RuneCountInString(col)
+	}
+
+	cells := make([][]string, len(res.Rows))
+	for r, row := range res.Rows {
+		cells[r] = make([]string, len(row))
+		for i, v := range row {
+			cells[r][i] = FormatValue(v)
+			if n := utf8.RuneCountInString(cells[r][i]); i < len(widths) && n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var b strings.Builder
+	separator := func() {
+		b.WriteString("+")
+		for _, width := range widths {
+			b.WriteString(strings.Repeat("-", width+2))
+			b.WriteString("+")
+		}
+		b.WriteString("\n")
+	}
+	line := func(values []string) {
+		b.WriteString("|")
+		for i, width := range widths {
+			value := ""
+			if i < len(values) {
+				value = values[i]
+			}
+			b.WriteString(" ")
+			b.WriteString(value)
+			b.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(value)))
+			b.WriteString(" |")
+		}
+		b.WriteString("\n")
+	}
+
+	if len(res.Columns) > 0 {
+		separator()
+		line(res.Columns)
+		separator()
+		for _, row := range cells {
+			line(row)
+		}
+		separator()
+	}
+
+	if len(res.Rows) == 1 {
+		b.WriteString("(1 row)\n")
+	} else {
+		fmt.Fprintf(&b, "(%d rows)\n", len(res.Rows))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// FormatValue renders a column value as text. NULL values are rendered as
+// NULL, arrays as [a, b] and structs as (a, b).
+func FormatValue(v spanner.GenericColumnValue) string {
+	return formatValue(v.Type, v.Value)
+}
+
+func formatValue(t *spannerpb.Type, v *structpb.Value) string {
+	if isNull(v) {
+		return "NULL"
+	}
+
+	switch t.GetCode() {
+	case spannerpb.TypeCode_ARRAY:
+		var parts []string
+		for _, elem := range v.GetListValue().GetValues() {
+			parts = append(parts, formatValue(t.ArrayElementType, elem))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case spannerpb.TypeCode_STRUCT:
+		var parts []string
+		fields := t.GetStructType().GetFields()
+		for i, elem := range v.GetListValue().GetValues() {
+			var fieldType *spannerpb.Type
+			if i < len(fields) {
+				fieldType = fields[i].Type
+			}
+			parts = append(parts, formatValue(fieldType, elem))
+		}
+		return "(" + strings.Join(parts, ", ") + ")"
+	case spannerpb.TypeCode_BOOL:
+		return strconv.FormatBool(v.GetBoolValue())
+	case spannerpb.TypeCode_FLOAT64, spannerpb.TypeCode_FLOAT32:
+		// NaN and infinities are encoded as strings
+		if n, ok := v.Kind.(*structpb.Value_NumberValue); ok {
+			// FLOAT32 values are formatted as float32, so that 0.1 is not
+			// printed as 0.10000000149011612
+			bitSize := 64
+			if t.GetCode() == spannerpb.TypeCode_FLOAT32 {
+				bitSize = 32
+			}
+			return strconv.FormatFloat(n.NumberValue, 'g', -1, bitSize)
+		}
+		return v.GetStringValue()
+	default:
+		// INT64, NUMERIC, STRING, BYTES (base64), DATE, TIMESTAMP and JSON
+		// values are all encoded as strings
+		return v.GetStringValue()
+	}
+}
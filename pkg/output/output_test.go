@@ -0,0 +1,189 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func typeOf(code spannerpb.TypeCode) *spannerpb.Type {
+	return &spannerpb.Type{Code: code}
+}
+
+func arrayOf(code spannerpb.TypeCode) *spannerpb.Type {
+	return &spannerpb.Type{Code: spannerpb.TypeCode_ARRAY, ArrayElementType: typeOf(code)}
+}
+
+func list(values ...*structpb.Value) *structpb.Value {
+	return structpb.NewListValue(&structpb.ListValue{Values: values})
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    spanner.GenericColumnValue
+		expected string
+	}{
+		{"null", spanner.GenericColumnValue{Type: typeOf(spannerpb.TypeCode_STRING), Value: structpb.NewNullValue()}, "NULL"},
+		{"string", spanner.GenericColumnValue{Type: typeOf(spannerpb.TypeCode_STRING), Value: structpb.NewStringValue("John")}, "John"},
+		{"int64", spanner.GenericColumnValue{Type: typeOf(spannerpb.TypeCode_INT64), Value: structpb.NewStringValue("42")}, "42"},
+		{"bool", spanner.GenericColumnValue{Type: typeOf(spannerpb.TypeCode_BOOL), Value: structpb.NewBoolValue(true)}, "true"},
+		{"float64", spanner.GenericColumnValue{Type: typeOf(spannerpb.TypeCode_FLOAT64), Value: structpb.NewNumberValue(1.5)}, "1.5"},
+		{"float64 NaN", spanner.GenericColumnValue{Type: typeOf(spannerpb.TypeCode_FLOAT64), Value: structpb.NewStringValue("NaN")}, "NaN"},
+		{"float32", spanner.GenericColumnValue{Type: typeOf(spannerpb.TypeCode_FLOAT32), Value: structpb.NewNumberValue(float64(float32(0.1)))}, "0.1"},
+		{"float32 array", spanner.GenericColumnValue{Type: arrayOf(spannerpb.TypeCode_FLOAT32), Value: list(structpb.NewNumberValue(float64(float32(2.2))))}, "[2.2]"},
+		{"numeric", spanner.GenericColumnValue{Type: typeOf(spannerpb.TypeCode_NUMERIC), Value: structpb.NewStringValue("3.140000000")}, "3.140000000"},
+		{"json", spanner.GenericColumnValue{Type: typeOf(spannerpb.TypeCode_JSON), Value: structpb.NewStringValue(`{"a":1}`)}, `{"a":1}`},
+		{
+			"array with null",
+			spanner.GenericColumnValue{Type: arrayOf(spannerpb.TypeCode_INT64), Value: list(structpb.NewStringValue("1"), structpb.NewNullValue())},
+			"[1, NULL]",
+		},
+		{"empty array", spanner.GenericColumnValue{Type: arrayOf(spannerpb.TypeCode_STRING), Value: list()}, "[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := FormatValue(tt.value); result != tt.expected {
+				t.Errorf("FormatValue() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	res := &Result{
+		Columns: []string{"id", "name"},
+		Rows: [][]spanner.GenericColumnValue{
+			{
+				{Type: typeOf(spannerpb.TypeCode_INT64), Value: structpb.NewStringValue("1")},
+				{Type: typeOf(spannerpb.TypeCode_STRING), Value: structpb.NewStringValue("John Doe")},
+			},
+			{
+				{Type: typeOf(spannerpb.TypeCode_INT64), Value: structpb.NewStringValue("20")},
+				{Type: typeOf(spannerpb.TypeCode_STRING), Value: structpb.NewNullValue()},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTable(&buf, res); err != nil {
+		t.Fatalf("WriteTable() unexpected error: %v", err)
+	}
+
+	expected := `+----+----------+
+| id | name     |
++----+----------+
+| 1  | John Doe |
+| 20 | NULL     |
++----+----------+
+(2 rows)
+`
+	if buf.String() != expected {
+		t.Errorf("WriteTable() =\n%s\nexpected\n%s", buf.String(), expected)
+	}
+}
+
+func sampleResult() *Result {
+	return &Result{
+		Columns: []string{"id", "price", "doc", "tags", ""},
+		Rows: [][]spanner.GenericColumnValue{
+			{
+				{Type: typeOf(spannerpb.TypeCode_INT64), Value: structpb.NewStringValue("9007199254740993")},
+				{Type: typeOf(spannerpb.TypeCode_NUMERIC), Value: structpb.NewStringValue("1.50")},
+				{Type: typeOf(spannerpb.TypeCode_JSON), Value: structpb.NewStringValue(`{"a":[1,2]}`)},
+				{Type: arrayOf(spannerpb.TypeCode_STRING), Value: list(structpb.NewStringValue("x, y"), structpb.NewNullValue())},
+				{Type: typeOf(spannerpb.TypeCode_BOOL), Value: structpb.NewBoolValue(false)},
+			},
+			{
+				{Type: typeOf(spannerpb.TypeCode_INT64), Value: structpb.NewStringValue("2")},
+				{Type: typeOf(spannerpb.TypeCode_NUMERIC), Value: structpb.NewNullValue()},
+				{Type: typeOf(spannerpb.TypeCode_JSON), Value: structpb.NewNullValue()},
+				{Type: arrayOf(spannerpb.TypeCode_STRING), Value: structpb.NewNullValue()},
+				{Type: typeOf(spannerpb.TypeCode_BOOL), Value: structpb.NewBoolValue(true)},
+			},
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		format   Format
+		expected string
+	}{
+		{
+			format: FormatCSV,
+			expected: `id,price,doc,tags,
+9007199254740993,1.50,"{""a"":[1,2]}","[""x, y"",null]",false
+2,,,,true
+`,
+		},
+		{
+			format: FormatJSON,
+			expected: `[
+  {"id":9007199254740993,"price":"1.50","doc":{"a":[1,2]},"tags":["x, y",null],"_col5":false},
+  {"id":2,"price":null,"doc":null,"tags":null,"_col5":true}
+]
+`,
+		},
+		{
+			format: FormatNDJSON,
+			expected: `{"id":9007199254740993,"price":"1.50","doc":{"a":[1,2]},"tags":["x, y",null],"_col5":false}
+{"id":2,"price":null,"doc":null,"tags":null,"_col5":true}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, sampleResult(), tt.format); err != nil {
+				t.Fatalf("Write() unexpected error: %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Write() =\n%s\nexpected\n%s", buf.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteJSON_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, &Result{Columns: []string{"id"}}); err != nil {
+		t.Fatalf("WriteJSON() unexpected error: %v", err)
+	}
+	if buf.String() != "[]\n" {
+		t.Errorf("WriteJSON() = %q, expected %q", buf.String(), "[]\n")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"table", "csv", "json", "ndjson", "JSON"} {
+		if _, err := ParseFormat(name); err != nil {
+			t.Errorf("ParseFormat(%q) unexpected error: %v", name, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("ParseFormat(\"xml\") expected error but got none")
+	}
+}
+
+func TestWriteJSON_Float32(t *testing.T) {
+	res := &Result{
+		Columns: []string{"score"},
+		Rows: [][]spanner.GenericColumnValue{
+			{{Type: typeOf(spannerpb.TypeCode_FLOAT32), Value: structpb.NewNumberValue(float64(float32(0.1)))}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteNDJSON(&buf, res); err != nil {
+		t.Fatalf("WriteNDJSON() unexpected error: %v", err)
+	}
+	if buf.String() != "{\"score\":0.1}\n" {
+		t.Errorf("WriteNDJSON() = %q, expected %q", buf.String(), "{\"score\":0.1}\n")
+	}
+}
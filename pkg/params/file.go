@@ -0,0 +1,96 @@
+package params
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadFile reads parameter values from a YAML or JSON file. Each entry is
+// either a plain value, whose type is inferred (strings are STRING, integers
+// INT64, decimals FLOAT64, booleans BOOL and objects JSON), or an object
+// with an explicit type:
+//
+//	id: 1
+//	avatar:
+//	  type: BYTES
+//	  value: aGVsbG8=
+//	tags:
+//	  type: ARRAY<STRING>
+//	  value: [a, b]
+func LoadFile(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	result := make(map[string]interface{}, len(raw))
+	for name, v := range raw {
+		value, err := fileValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid parameter %s: %w", path, name, err)
+		}
+		result[name] = value
+	}
+
+	return result, nil
+}
+
+func fileValue(v interface{}) (interface{}, error) {
+	if typ, value, ok := typedValue(v); ok {
+		if !IsType(typ) {
+			return nil, fmt.Errorf("unsupported type %s", typ)
+		}
+		return Convert(value, typ)
+	}
+
+	switch value := v.(type) {
+	case nil:
+		return nil, fmt.Errorf("NULL values need an explicit type")
+	case string:
+		return value, nil
+	case int:
+		return int64(value), nil
+	case int64:
+		return value, nil
+	case float64:
+		return value, nil
+	case bool:
+		return value, nil
+	case time.Time:
+		return value, nil
+	case map[string]interface{}:
+		return Convert(value, "JSON")
+	case []interface{}:
+		return nil, fmt.Errorf("array values need an explicit type, e.g. ARRAY<STRING>")
+	}
+
+	return nil, fmt.Errorf("unsupported value %v", v)
+}
+
+// typedValue reports whether v is an object with a type and an optional
+// value, and nothing else.
+func typedValue(v interface{}) (string, interface{}, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return "", nil, false
+	}
+	typ, ok := m["type"].(string)
+	if !ok {
+		return "", nil, false
+	}
+	for key := range m {
+		if key != "type" && key != "value" {
+			return "", nil, false
+		}
+	}
+
+	return typ, m["value"], true
+}
@@ -0,0 +1,100 @@
+package params
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+)
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlFile := filepath.Join(dir, "seed.params.yaml")
+	content := `id: 1
+name: O'Brien
+score: 1.5
+active: true
+avatar:
+  type: BYTES
+  value: aGVsbG8=
+metadata:
+  theme: dark
+tags:
+  type: ARRAY<STRING>
+  value: [a, b]
+deleted_at:
+  type: TIMESTAMP
+`
+	if err := os.WriteFile(yamlFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := LoadFile(yamlFile)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"id":         int64(1),
+		"name":       "O'Brien",
+		"score":      1.5,
+		"active":     true,
+		"avatar":     []byte("hello"),
+		"metadata":   spanner.NullJSON{Value: map[string]interface{}{"theme": "dark"}, Valid: true},
+		"tags":       []spanner.NullString{{StringVal: "a", Valid: true}, {StringVal: "b", Valid: true}},
+		"deleted_at": spanner.NullTime{},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("LoadFile() = %#v, expected %#v", result, expected)
+	}
+
+	jsonFile := filepath.Join(dir, "seed.params.json")
+	if err := os.WriteFile(jsonFile, []byte(`{"id": 2, "data": {"type": "JSON", "value": "{\"a\": 1}"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = LoadFile(jsonFile)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if result["id"] != int64(2) {
+		t.Errorf("id = %#v, expected int64(2)", result["id"])
+	}
+	if data, ok := result["data"].(spanner.NullJSON); !ok || !data.Valid {
+		t.Errorf("data = %#v, expected a JSON value", result["data"])
+	}
+}
+
+func TestLoadFile_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{"untyped null", "id: null", "explicit type"},
+		{"untyped array", "ids: [1, 2]", "explicit type"},
+		{"unknown type", "id:\n  type: INT\n  value: 1", "unsupported type INT"},
+		{"invalid value", "id:\n  type: INT64\n  value: abc", "invalid INT64 value"},
+		{"invalid file", "- a\n- b", "failed to parse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "params.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := LoadFile(path)
+			if err == nil {
+				t.Fatal("LoadFile() expected error")
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("LoadFile() error = %v, expected it to contain %q", err, tt.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,386 @@
+// Package params converts textual query parameter values into typed values
+// that can be bound to a spanner.Statement.
+package params
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+)
+
+// Null is the value text that denotes a typed NULL.
+const Null = "NULL"
+
+var scalarTypes = map[string]bool{
+	"STRING":    true,
+	"INT64":     true,
+	"FLOAT64":   true,
+	"FLOAT32":   true,
+	"BOOL":      true,
+	"BYTES":     true,
+	"DATE":      true,
+	"TIMESTAMP": true,
+	"NUMERIC":   true,
+	"JSON":      true,
+}
+
+// Parse parses a parameter definition of the form name=value:TYPE. The type
+// defaults to STRING when it is omitted or not a known Spanner type, so
+// values containing colons such as timestamps must specify the type
+// explicitly.
+func Parse(def string) (string, interface{}, error) {
+	name, text, ok := strings.Cut(def, "=")
+	name = strings.TrimPrefix(strings.TrimSpace(name), "@")
+	if !ok || name == "" {
+		return "", nil, fmt.Errorf("invalid parameter %q: expected name=value[:TYPE]", def)
+	}
+
+	typ := "STRING"
+	if idx := strings.LastIndex(text, ":"); idx != -1 && IsType(text[idx+1:]) {
+		text, typ = text[:idx], text[idx+1:]
+	}
+
+	value, err := Value(text, typ)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid parameter %s: %w", name, err)
+	}
+
+	return name, value, nil
+}
+
+// IsType reports whether typ is a supported Spanner type name, e.g. INT64 or
+// ARRAY<STRING>.
+func IsType(typ string) bool {
+	typ = strings.ToUpper(strings.TrimSpace(typ))
+	if elem, ok := arrayElementType(typ); ok {
+		return scalarTypes[elem]
+	}
+
+	return scalarTypes[typ]
+}
+
+// Value converts text to a Go value of the given Spanner type. The text NULL
+// produces a typed NULL. Array values are written as JSON arrays, e.g.
+// [1, 2, 3] for ARRAY<INT64>.
+func Value(text, typ string) (interface{}, error) {
+	typ = strings.ToUpper(strings.TrimSpace(typ))
+
+	if elem, ok := arrayElementType(typ); ok {
+		if text == Null {
+			return nullArray(elem)
+		}
+		var elems []interface{}
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		if err := decoder.Decode(&elems); err != nil {
+			return nil, fmt.Errorf("invalid %s value %q: expected a JSON array", typ, text)
+		}
+		return Convert(elems, typ)
+	}
+
+	if text == Null {
+		return null(typ)
+	}
+
+	return scalar(text, typ)
+}
+
+// Convert converts a value decoded from JSON or YAML to a Go value of the
+// given Spanner type. Strings are parsed as with Value; numbers and booleans
+// are accepted where they fit the type, and nil produces a typed NULL.
+func Convert(v interface{}, typ string) (interface{}, error) {
+	typ = strings.ToUpper(strings.TrimSpace(typ))
+
+	if elem, ok := arrayElementType(typ); ok {
+		if v == nil {
+			return nullArray(elem)
+		}
+		elems, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid %s value %v: expected a list", typ, v)
+		}
+		result := make([]interface{}, len(elems))
+		for i, elem := range elems {
+			converted, err := Convert(elem, elemType(typ))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = converted
+		}
+		return typedArray(result, elemType(typ))
+	}
+
+	if v == nil {
+		return null(typ)
+	}
+	if !scalarTypes[typ] {
+		return nil, fmt.Errorf("unsupported type %s", typ)
+	}
+
+	switch value := v.(type) {
+	case string:
+		return scalar(value, typ)
+	case bool:
+		if typ == "BOOL" {
+			return value, nil
+		}
+	case json.Number:
+		return scalar(value.String(), typ)
+	case int:
+		return scalar(strconv.Itoa(value), typ)
+	case int64:
+		return scalar(strconv.FormatInt(value, 10), typ)
+	case float64:
+		return scalar(strconv.FormatFloat(value, 'f', -1, 64), typ)
+	case time.Time:
+		// YAML decodes unquoted timestamps and dates to time.Time
+		switch typ {
+		case "TIMESTAMP":
+			return value, nil
+		case "DATE":
+			return civil.DateOf(value), nil
+		}
+	}
+	if typ == "JSON" {
+		return spanner.NullJSON{Value: v, Valid: true}, nil
+	}
+
+	return nil, fmt.Errorf("invalid %s value %v", typ, v)
+}
+
+func scalar(text, typ string) (interface{}, error) {
+	invalid := func(err error) error {
+		return fmt.Errorf("invalid %s value %q: %w", typ, text, err)
+	}
+
+	switch typ {
+	case "STRING":
+		return text, nil
+	case "INT64":
+		v, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return v, nil
+	case "FLOAT64":
+		v, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return v, nil
+	case "FLOAT32":
+		v, err := strconv.ParseFloat(text, 32)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return float32(v), nil
+	case "BOOL":
+		v, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return v, nil
+	case "BYTES":
+		v, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return v, nil
+	case "DATE":
+		v, err := civil.ParseDate(text)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return v, nil
+	case "TIMESTAMP":
+		v, err := time.Parse(time.RFC3339Nano, text)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return v, nil
+	case "NUMERIC":
+		v, ok := new(big.Rat).SetString(text)
+		if !ok {
+			return nil, fmt.Errorf("invalid NUMERIC value %q", text)
+		}
+		return spanner.NullNumeric{Numeric: *v, Valid: true}, nil
+	case "JSON":
+		var v interface{}
+		if err := json.Unmarshal([]byte(text), &v); err != nil {
+			return nil, invalid(err)
+		}
+		return spanner.NullJSON{Value: v, Valid: true}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", typ)
+}
+
+func null(typ string) (interface{}, error) {
+	switch typ {
+	case "STRING":
+		return spanner.NullString{}, nil
+	case "INT64":
+		return spanner.NullInt64{}, nil
+	case "FLOAT64":
+		return spanner.NullFloat64{}, nil
+	case "FLOAT32":
+		return spanner.NullFloat32{}, nil
+	case "BOOL":
+		return spanner.NullBool{}, nil
+	case "BYTES":
+		return []byte(nil), nil
+	case "DATE":
+		return spanner.NullDate{}, nil
+	case "TIMESTAMP":
+		return spanner.NullTime{}, nil
+	case "NUMERIC":
+		return spanner.NullNumeric{}, nil
+	case "JSON":
+		return spanner.NullJSON{}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", typ)
+}
+
+func nullArray(elem string) (interface{}, error) {
+	return typedArray(nil, elem)
+}
+
+// typedArray converts elements produced by Convert into a slice of the
+// element's Go type, so that the array is bound with the right Spanner type.
+func typedArray(elems []interface{}, elem string) (interface{}, error) {
+	switch elem {
+	case "STRING":
+		return collect[spanner.NullString](elems, func(v interface{}) spanner.NullString {
+			if s, ok := v.(string); ok {
+				return spanner.NullString{StringVal: s, Valid: true}
+			}
+			return v.(spanner.NullString)
+		}), nil
+	case "INT64":
+		return collect[spanner.NullInt64](elems, func(v interface{}) spanner.NullInt64 {
+			if n, ok := v.(int64); ok {
+				return spanner.NullInt64{Int64: n, Valid: true}
+			}
+			return v.(spanner.NullInt64)
+		}), nil
+	case "FLOAT64":
+		return collect[spanner.NullFloat64](elems, func(v interface{}) spanner.NullFloat64 {
+			if f, ok := v.(float64); ok {
+				return spanner.NullFloat64{Float64: f, Valid: true}
+			}
+			return v.(spanner.NullFloat64)
+		}), nil
+	case "FLOAT32":
+		return collect[spanner.NullFloat32](elems, func(v interface{}) spanner.NullFloat32 {
+			if f, ok := v.(float32); ok {
+				return spanner.NullFloat32{Float32: f, Valid: true}
+			}
+			return v.(spanner.NullFloat32)
+		}), nil
+	case "BOOL":
+		return collect[spanner.NullBool](elems, func(v interface{}) spanner.NullBool {
+			if b, ok := v.(bool); ok {
+				return spanner.NullBool{Bool: b, Valid: true}
+			}
+			return v.(spanner.NullBool)
+		}), nil
+	case "BYTES":
+		return collect[[]byte](elems, func(v interface{}) []byte {
+			return v.([]byte)
+		}), nil
+	case "DATE":
+		return collect[spanner.NullDate](elems, func(v interface{}) spanner.NullDate {
+			if d, ok := v.(civil.Date); ok {
+				return spanner.NullDate{Date: d, Valid: true}
+			}
+			return v.(spanner.NullDate)
+		}), nil
+	case "TIMESTAMP":
+		return collect[spanner.NullTime](elems, func(v interface{}) spanner.NullTime {
+			if t, ok := v.(time.Time); ok {
+				return spanner.NullTime{Time: t, Valid: true}
+			}
+			return v.(spanner.NullTime)
+		}), nil
+	case "NUMERIC":
+		return collect[spanner.NullNumeric](elems, func(v interface{}) spanner.NullNumeric {
+			return v.(spanner.NullNumeric)
+		}), nil
+	case "JSON":
+		return collect[spanner.NullJSON](elems, func(v interface{}) spanner.NullJSON {
+			return v.(spanner.NullJSON)
+		}), nil
+	}
+
+	return nil, fmt.Errorf("unsupported array element type %s", elem)
+}
+
+func collect[T any](elems []interface{}, convert func(interface{}) T) []T {
+	if elems == nil {
+		return nil
+	}
+	result := make([]T, len(elems))
+	for i, elem := range elems {
+		result[i] = convert(elem)
+	}
+	return result
+}
+
+func arrayElementType(typ string) (string, bool) {
+	if strings.HasPrefix(typ, "ARRAY<") && strings.HasSuffix(typ, ">") {
+		return strings.TrimSpace(typ[len("ARRAY<") : len(typ)-1]), true
+	}
+	return "", false
+}
+
+func elemType(typ string) string {
+	elem, _ := arrayElementType(typ)
+	return elem
+}
+
+// PostgreSQL converts a value produced by Value or Convert into the type it
+// is bound with in a PostgreSQL-dialect database, where NUMERIC and JSON
+// values use the PG.NUMERIC and PG.JSONB types. Other values are returned
+// unchanged.
+func PostgreSQL(v interface{}) interface{} {
+	switch v := v.(type) {
+	case spanner.NullNumeric:
+		return pgNumeric(v)
+	case spanner.NullJSON:
+		return spanner.PGJsonB{Value: v.Value, Valid: v.Valid}
+	case []spanner.NullNumeric:
+		if v == nil {
+			return []spanner.PGNumeric(nil)
+		}
+		result := make([]spanner.PGNumeric, len(v))
+		for i, n := range v {
+			result[i] = pgNumeric(n)
+		}
+		return result
+	case []spanner.NullJSON:
+		if v == nil {
+			return []spanner.PGJsonB(nil)
+		}
+		result := make([]spanner.PGJsonB, len(v))
+		for i, j := range v {
+			result[i] = spanner.PGJsonB{Value: j.Value, Valid: j.Valid}
+		}
+		return result
+	}
+	return v
+}
+
+func pgNumeric(n spanner.NullNumeric) spanner.PGNumeric {
+	if !n.Valid {
+		return spanner.PGNumeric{}
+	}
+	return spanner.PGNumeric{Numeric: spanner.NumericString(&n.Numeric), Valid: true}
+}
@@ -0,0 +1,162 @@
+package params
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		def       string
+		wantName  string
+		wantValue interface{}
+		wantErr   bool
+	}{
+		{"default string", "name=John", "name", "John", false},
+		{"explicit string", "name=John:STRING", "name", "John", false},
+		{"at prefix", "@id=1:INT64", "id", int64(1), false},
+		{"lowercase type", "id=1:int64", "id", int64(1), false},
+		{"float64", "score=1.5:FLOAT64", "score", 1.5, false},
+		{"bool", "active=true:BOOL", "active", true, false},
+		{"bytes", "data=aGVsbG8=:BYTES", "data", []byte("hello"), false},
+		{"date", "day=2024-01-02:DATE", "day", civil.Date{Year: 2024, Month: 1, Day: 2}, false},
+		{
+			"timestamp", "ts=2024-01-01T00:00:00Z:TIMESTAMP", "ts",
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), false,
+		},
+		{"colon without type", "ts=2024-01-01T00:00:00Z", "ts", "2024-01-01T00:00:00Z", false},
+		{"numeric", "price=1.25:NUMERIC", "price", spanner.NullNumeric{Numeric: *big.NewRat(5, 4), Valid: true}, false},
+		{"json", `doc={"a":1}:JSON`, "doc", spanner.NullJSON{Value: map[string]interface{}{"a": float64(1)}, Valid: true}, false},
+		{"null int64", "id=NULL:INT64", "id", spanner.NullInt64{}, false},
+		{"empty value", "name=", "name", "", false},
+		{
+			"int64 array", "ids=[1, 2, null]:ARRAY<INT64>", "ids",
+			[]spanner.NullInt64{{Int64: 1, Valid: true}, {Int64: 2, Valid: true}, {}}, false,
+		},
+		{
+			"string array", `tags=["a","b"]:ARRAY<STRING>`, "tags",
+			[]spanner.NullString{{StringVal: "a", Valid: true}, {StringVal: "b", Valid: true}}, false,
+		},
+		{"null array", "ids=NULL:ARRAY<INT64>", "ids", []spanner.NullInt64(nil), false},
+		{"missing equals", "name", "", nil, true},
+		{"missing name", "=1", "", nil, true},
+		{"invalid int64", "id=abc:INT64", "", nil, true},
+		{"invalid array", "ids=1,2:ARRAY<INT64>", "", nil, true},
+		{"invalid array element", `ids=["a"]:ARRAY<INT64>`, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, value, err := Parse(tt.def)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse(%q) expected error but got none", tt.def)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected error: %v", tt.def, err)
+			}
+			if name != tt.wantName {
+				t.Errorf("Parse(%q) name = %q, expected %q", tt.def, name, tt.wantName)
+			}
+			if !reflect.DeepEqual(value, tt.wantValue) {
+				t.Errorf("Parse(%q) value = %#v, expected %#v", tt.def, value, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestIsType(t *testing.T) {
+	tests := []struct {
+		typ      string
+		expected bool
+	}{
+		{"INT64", true},
+		{"string", true},
+		{"ARRAY<TIMESTAMP>", true},
+		{"ARRAY<ARRAY<INT64>>", false},
+		{"00Z", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			if result := IsType(tt.typ); result != tt.expected {
+				t.Errorf("IsType(%q) = %v, expected %v", tt.typ, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		typ      string
+		expected interface{}
+		wantErr  bool
+	}{
+		{"int from number", float64(3), "INT64", int64(3), false},
+		{"int from int", 3, "INT64", int64(3), false},
+		{"bool", true, "BOOL", true, false},
+		{"bool for int64", true, "INT64", nil, true},
+		{"string timestamp", "2024-01-01T00:00:00Z", "TIMESTAMP", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"time as date", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), "DATE", civil.Date{Year: 2024, Month: 1, Day: 2}, false},
+		{"nil", nil, "STRING", spanner.NullString{}, false},
+		{"json object", map[string]interface{}{"a": "b"}, "JSON", spanner.NullJSON{Value: map[string]interface{}{"a": "b"}, Valid: true}, false},
+		{"unknown type", "x", "GEOGRAPHY", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Convert(tt.value, tt.typ)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Convert() expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Convert() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Convert() = %#v, expected %#v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPostgreSQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected interface{}
+	}{
+		{"numeric", spanner.NullNumeric{Numeric: *big.NewRat(3, 2), Valid: true}, spanner.PGNumeric{Numeric: "1.500000000", Valid: true}},
+		{"null numeric", spanner.NullNumeric{}, spanner.PGNumeric{}},
+		{"json", spanner.NullJSON{Value: map[string]interface{}{"a": "b"}, Valid: true}, spanner.PGJsonB{Value: map[string]interface{}{"a": "b"}, Valid: true}},
+		{"numeric array", []spanner.NullNumeric{{Numeric: *big.NewRat(1, 1), Valid: true}, {}}, []spanner.PGNumeric{{Numeric: "1.000000000", Valid: true}, {}}},
+		{"null json array", []spanner.NullJSON(nil), []spanner.PGJsonB(nil)},
+		{"int", int64(1), int64(1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := PostgreSQL(tt.value)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("PostgreSQL() = %#v, expected %#v", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ddlKeywords are the keywords DDL statements start with.
+var ddlKeywords = []string{"CREATE", "ALTER", "DROP", "RENAME", "GRANT", "REVOKE", "ANALYZE"}
+
+// ParseDDL splits schema content read from filePath into DDL statements.
+// Comments are removed and semicolons inside string literals, e.g. in
+// DEFAULT values or OPTIONS (description="..."), do not end a statement.
+// Statements keep the file and line they start on, and statements that are
+// not DDL are reported as errors.
+func ParseDDL(content, filePath string) ([]Statement, error) {
+	return GoogleSQL.ParseDDL(content, filePath)
+}
+
+// ParseDDL parses DDL statements of the dialect like the package-level
+// ParseDDL.
+func (d Dialect) ParseDDL(content, filePath string) ([]Statement, error) {
+	var statements []Statement
+	for _, it := range scan(content, d) {
+		if it.directive != nil {
+			continue
+		}
+
+		stmt := Statement{SQL: it.sql, File: filePath, Line: it.line}
+		if !isDDL(d.lex(stmt.SQL)) {
+			return nil, fmt.Errorf("%s: invalid DDL statement: %s: expected %s",
+				stmt.Position(), truncate(stmt.SQL, 50), strings.Join(ddlKeywords, ", "))
+		}
+		statements = append(statements, stmt)
+	}
+
+	return statements, nil
+}
+
+func isDDL(tokens []token) bool {
+	if len(tokens) == 0 || tokens[0].kind != tokenWord {
+		return false
+	}
+	for _, keyword := range ddlKeywords {
+		if strings.EqualFold(tokens[0].text, keyword) {
+			return true
+		}
+	}
+	return false
+}
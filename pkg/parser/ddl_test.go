@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDDL(t *testing.T) {
+	content := `-- users
+CREATE TABLE users (
+  id INT64 NOT NULL, -- the id
+  name STRING(MAX) DEFAULT ('a;b'),
+  age INT64,
+  CONSTRAINT age_positive CHECK (age > 0),
+) PRIMARY KEY (id);
+
+/* posts; with an index */
+CREATE TABLE posts (
+  id INT64 NOT NULL,
+) PRIMARY KEY (id), OPTIONS (description="Posts;
+written by users");
+create index posts_by_id on posts (id)`
+
+	statements, err := ParseDDL(content, "schema.sql")
+	if err != nil {
+		t.Fatalf("ParseDDL() error = %v", err)
+	}
+
+	var lines []int
+	for _, stmt := range statements {
+		lines = append(lines, stmt.Line)
+		if stmt.File != "schema.sql" {
+			t.Errorf("statement File = %q, expected schema.sql", stmt.File)
+		}
+	}
+	if !reflect.DeepEqual(lines, []int{2, 10, 14}) {
+		t.Errorf("ParseDDL() lines = %v, expected [2 10 14]", lines)
+	}
+
+	if len(statements) != 3 {
+		t.Fatalf("ParseDDL() returned %d statements, expected 3", len(statements))
+	}
+	if !strings.Contains(statements[0].SQL, "DEFAULT ('a;b')") || strings.Contains(statements[0].SQL, "the id") {
+		t.Errorf("first statement = %q", statements[0].SQL)
+	}
+	if !strings.HasSuffix(statements[1].SQL, "OPTIONS (description=\"Posts;\nwritten by users\")") {
+		t.Errorf("second statement = %q", statements[1].SQL)
+	}
+}
+
+func TestParseDDL_Invalid(t *testing.T) {
+	tests := []struct {
+		content string
+		message string
+	}{
+		{"CREATE TABLE t (id INT64) PRIMARY KEY (id);\n\nINSERT INTO t (id) VALUES (1);", "schema.sql:3: invalid DDL statement: INSERT INTO t"},
+		{"'CREATE TABLE t';", "schema.sql:1: invalid DDL statement"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseDDL(tt.content, "schema.sql")
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("ParseDDL(%q) error = %v, expected it to contain %q", tt.content, err, tt.message)
+		}
+	}
+
+	if _, err := PostgreSQL.ParseDDL(`CREATE TABLE t (id bigint PRIMARY KEY, s text DEFAULT $$a;b$$);`, "pg.sql"); err != nil {
+		t.Errorf("ParseDDL() error = %v", err)
+	}
+}
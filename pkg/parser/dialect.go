@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect is the SQL dialect of a database.
+type Dialect int
+
+const (
+	GoogleSQL Dialect = iota
+	PostgreSQL
+)
+
+// ParseDialect parses a dialect name: googlesql or postgresql. An empty name
+// is GoogleSQL.
+func ParseDialect(name string) (Dialect, error) {
+	switch strings.ToLower(name) {
+	case "", "googlesql", "google_standard_sql":
+		return GoogleSQL, nil
+	case "postgresql", "postgres", "pg":
+		return PostgreSQL, nil
+	}
+	return GoogleSQL, fmt.Errorf("unknown dialect %q (expected googlesql or postgresql)", name)
+}
+
+func (d Dialect) String() string {
+	if d == PostgreSQL {
+		return "postgresql"
+	}
+	return "googlesql"
+}
+
+// segments splits SQL text of the dialect into code, literal and comment
+// segments.
+func (d Dialect) segments(content string) []segment {
+	if d == PostgreSQL {
+		return postgreSQLSegments(content)
+	}
+	return segments(content)
+}
+
+// isQuotedName reports whether a literal segment is a quoted identifier:
+// `name` in GoogleSQL and "name" in PostgreSQL.
+func (d Dialect) isQuotedName(literal string) bool {
+	if d == PostgreSQL {
+		return strings.HasPrefix(literal, `"`)
+	}
+	return strings.HasPrefix(literal, "`")
+}
+
+// postgreSQLSegments splits PostgreSQL text into segments. It recognizes
+// '...' strings with doubled quotes and E'...' strings with backslash
+// escapes, "..." identifiers, $tag$...$tag$ dollar-quoted strings, -- line
+// comments and nested /* */ block comments.
+func postgreSQLSegments(content string) []segment {
+	var result []segment
+	start := 0
+	emit := func(end int, kind segmentKind) {
+		if end > start {
+			result = append(result, segment{kind: kind, text: content[start:end]})
+		}
+		start = end
+	}
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '-' && strings.HasPrefix(content[i:], "--"):
+			emit(i, segmentCode)
+			end := strings.IndexByte(content[i:], '\n')
+			if end == -1 {
+				end = len(content) - i
+			}
+			i += end
+			emit(i, segmentComment)
+		case c == '/' && strings.HasPrefix(content[i:], "/*"):
+			emit(i, segmentCode)
+			i = blockCommentEnd(content, i)
+			emit(i, segmentComment)
+		case c == '\'' || c == '"':
+			// E'...' strings support backslash escapes
+			escapes := c == '\'' && i > 0 && (content[i-1] == 'e' || content[i-1] == 'E') &&
+				(i == 1 || !isParamChar(content[i-2]))
+			emit(i, segmentCode)
+			i = quotedEnd(content, i, escapes)
+			emit(i, segmentLiteral)
+		case c == '$' && (i == 0 || !isParamChar(content[i-1])):
+			tag, ok := dollarTag(content[i:])
+			if !ok {
+				i++
+				continue
+			}
+			emit(i, segmentCode)
+			end := strings.Index(content[i+len(tag):], tag)
+			if end == -1 {
+				i = len(content)
+			} else {
+				i += len(tag) + end + len(tag)
+			}
+			emit(i, segmentLiteral)
+		default:
+			i++
+		}
+	}
+	emit(len(content), segmentCode)
+
+	return result
+}
+
+// blockCommentEnd returns the index just past the possibly nested block
+// comment starting at content[i].
+func blockCommentEnd(content string, i int) int {
+	depth := 0
+	for i < len(content) {
+		switch {
+		case strings.HasPrefix(content[i:], "/*"):
+			depth++
+			i += 2
+		case strings.HasPrefix(content[i:], "*/"):
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return len(content)
+}
+
+// quotedEnd returns the index just past the quoted string or identifier
+// starting at content[i]. Doubled quotes are part of the literal.
+func quotedEnd(content string, i int, escapes bool) int {
+	quote := content[i]
+	for j := i + 1; j < len(content); j++ {
+		switch {
+		case escapes && content[j] == '\\':
+			j++
+		case content[j] == quote:
+			if j+1 < len(content) && content[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(content)
+}
+
+// dollarTag returns the opening tag of a dollar-quoted string, e.g. $$ or
+// $body$, at the start of s.
+func dollarTag(s string) (string, bool) {
+	for j := 1; j < len(s); j++ {
+		switch c := s[j]; {
+		case c == '$':
+			return s[:j+1], true
+		case j == 1 && isDigit(c), !isParamChar(c):
+			// $1 is a parameter
+			return "", false
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDialect(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected Dialect
+		wantErr  bool
+	}{
+		{"", GoogleSQL, false},
+		{"googlesql", GoogleSQL, false},
+		{"GOOGLE_STANDARD_SQL", GoogleSQL, false},
+		{"postgresql", PostgreSQL, false},
+		{"pg", PostgreSQL, false},
+		{"mysql", GoogleSQL, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseDialect(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDialect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result != tt.expected {
+				t.Errorf("ParseDialect() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPostgreSQL_SplitStatements(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		statements []string
+		rest       string
+	}{
+		{
+			name:       "doubled quote",
+			content:    "INSERT INTO t (s) VALUES ('it''s;'); DELETE FROM t WHERE true;",
+			statements: []string{"INSERT INTO t (s) VALUES ('it''s;')", "DELETE FROM t WHERE true"},
+		},
+		{
+			name:       "backslash is not an escape",
+			content:    `INSERT INTO t (s) VALUES ('a\'); DELETE FROM t WHERE true;`,
+			statements: []string{`INSERT INTO t (s) VALUES ('a\')`, "DELETE FROM t WHERE true"},
+		},
+		{
+			name:       "escape string",
+			content:    `INSERT INTO t (s) VALUES (E'a\';b');`,
+			statements: []string{`INSERT INTO t (s) VALUES (E'a\';b')`},
+		},
+		{
+			name:       "quoted identifier",
+			content:    `DELETE FROM "my;table" WHERE true;`,
+			statements: []string{`DELETE FROM "my;table" WHERE true`},
+		},
+		{
+			name:       "dollar quoting",
+			content:    "INSERT INTO t (s) VALUES ($$a;b$$), ($x$c $$;$ d$x$);",
+			statements: []string{"INSERT INTO t (s) VALUES ($$a;b$$), ($x$c $$;$ d$x$)"},
+		},
+		{
+			name:       "nested block comment",
+			content:    "DELETE FROM t /* a /* ; */ ; */ WHERE true;",
+			statements: []string{"DELETE FROM t  WHERE true"},
+		},
+		{
+			name:       "hash is not a comment",
+			content:    "SELECT 1 # 2;",
+			statements: []string{"SELECT 1 # 2"},
+		},
+		{
+			name:       "positional parameter",
+			content:    "UPDATE t SET s = $1 WHERE id = $2;",
+			statements: []string{"UPDATE t SET s = $1 WHERE id = $2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, rest := PostgreSQL.SplitStatements(tt.content)
+			if !reflect.DeepEqual(statements, tt.statements) {
+				t.Errorf("SplitStatements() statements = %q, expected %q", statements, tt.statements)
+			}
+			if rest != tt.rest {
+				t.Errorf("SplitStatements() rest = %q, expected %q", rest, tt.rest)
+			}
+		})
+	}
+}
+
+func TestPostgreSQL_ParamNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		stmt     string
+		expected []string
+	}{
+		{"positional", "UPDATE t SET s = $2 WHERE id = $1 AND x = $2", []string{"p2", "p1"}},
+		{"in literals", "INSERT INTO t (s) VALUES ('$1', $$ $2 $$, \"$3\")", nil},
+		{"named is not a parameter", "SELECT @a", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := PostgreSQL.ParamNames(tt.stmt)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParamNames() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPostgreSQL_LoadContent(t *testing.T) {
+	content := `-- @tag users
+INSERT INTO "Users" (id, name) VALUES (1, 'O''Brien');
+UPDATE "Users" SET name = $$a;b$$ WHERE id = $1;
+`
+	f, err := PostgreSQL.LoadContent(content, "seed.sql")
+	if err != nil {
+		t.Fatalf("LoadContent() unexpected error: %v", err)
+	}
+	if len(f.Statements) != 2 {
+		t.Fatalf("LoadContent() returned %d statements, expected 2", len(f.Statements))
+	}
+	if f.Statements[1].Line != 3 {
+		t.Errorf("second statement line = %d, expected 3", f.Statements[1].Line)
+	}
+	if _, ok := f.Statements[0].Annotation("tag"); !ok {
+		t.Errorf("first statement is missing its @tag annotation")
+	}
+}
+
+func TestPostgreSQL_CheckPartitioned(t *testing.T) {
+	if err := PostgreSQL.CheckPartitioned(`UPDATE "Users" SET "Active" = false WHERE id > $1`); err != nil {
+		t.Errorf("CheckPartitioned() unexpected error: %v", err)
+	}
+	if err := PostgreSQL.CheckPartitioned(`UPDATE "Users" SET "Score" = "Score" + 1 WHERE true`); err == nil {
+		t.Errorf("CheckPartitioned() expected error for a non-idempotent update")
+	}
+}
@@ -0,0 +1,578 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DML is a parsed INSERT, UPDATE or DELETE statement: *Insert, *Update or
+// *Delete.
+type DML interface {
+	// TableName returns the table the statement modifies.
+	TableName() string
+	dml()
+}
+
+// Insert is an INSERT statement. Rows are given either as VALUES rows or as
+// a query.
+type Insert struct {
+	// Hints are the statement hints, e.g. @{PDML_MAX_PARALLELISM=1}.
+	Hints map[string]string
+	// OrUpdate and OrIgnore are set for INSERT OR UPDATE and INSERT OR
+	// IGNORE statements.
+	OrUpdate bool
+	OrIgnore bool
+	Table    string
+	// Columns is nil when the statement has no column list.
+	Columns []string
+	Rows    [][]Expr
+	// Query is the query rows are read from, e.g. SELECT ... FROM ...
+	Query *Expr
+	// OnConflict is the PostgreSQL ON CONFLICT clause after ON CONFLICT.
+	OnConflict *Expr
+	Return     *Return
+}
+
+// Update is an UPDATE statement.
+type Update struct {
+	Hints       map[string]string
+	Table       string
+	Alias       string
+	Assignments []Assignment
+	// Where is nil when the statement has no WHERE clause.
+	Where  *Expr
+	Return *Return
+}
+
+// Delete is a DELETE statement.
+type Delete struct {
+	Hints map[string]string
+	Table string
+	Alias string
+	// Where is nil when the statement has no WHERE clause.
+	Where  *Expr
+	Return *Return
+}
+
+func (s *Insert) TableName() string { return s.Table }
+func (s *Update) TableName() string { return s.Table }
+func (s *Delete) TableName() string { return s.Table }
+
+func (*Insert) dml() {}
+func (*Update) dml() {}
+func (*Delete) dml() {}
+
+// Assignment is a column = value item of an UPDATE statement's SET clause.
+type Assignment struct {
+	// Column is the assigned column without a table qualifier.
+	Column string
+	Value  Expr
+}
+
+// Return is a THEN RETURN clause, or a RETURNING clause in PostgreSQL.
+type Return struct {
+	// WithAction is set for THEN RETURN WITH ACTION.
+	WithAction bool
+	Items      []Expr
+}
+
+// Expr is an expression. Expressions are not parsed beyond finding where
+// they end; SQL holds the expression as written.
+type Expr struct {
+	SQL    string
+	tokens []token
+}
+
+// Literal returns the value of an expression that is a single literal, e.g.
+// 'John', -1.5, NULL or DATE '2024-01-01'.
+func (e Expr) Literal() (Value, bool) {
+	p := &tokenParser{tokens: e.tokens}
+	v, err := p.value()
+	if err != nil || !p.done() {
+		return Value{}, false
+	}
+	return v, true
+}
+
+// ParseDML parses an INSERT, UPDATE or DELETE statement.
+func ParseDML(stmt string) (DML, error) {
+	return GoogleSQL.ParseDML(stmt)
+}
+
+// ParseDML parses an INSERT, UPDATE or DELETE statement of the dialect.
+func (d Dialect) ParseDML(stmt string) (DML, error) {
+	p := &dmlParser{tokenParser: tokenParser{tokens: d.lex(stmt)}, stmt: stmt, dialect: d}
+
+	hints, err := p.hints()
+	if err != nil {
+		return nil, err
+	}
+
+	var result DML
+	switch {
+	case p.keyword("INSERT"):
+		result, err = p.insert(hints)
+	case p.keyword("UPDATE"):
+		result, err = p.update(hints)
+	case p.keyword("DELETE"):
+		result, err = p.delete(hints)
+	default:
+		return nil, fmt.Errorf("expected INSERT, UPDATE or DELETE, got %s", p.peek().text)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %s at the end of the statement", p.peek().text)
+	}
+	return result, nil
+}
+
+// dmlParser parses DML statements.
+type dmlParser struct {
+	tokenParser
+	stmt    string
+	dialect Dialect
+}
+
+func (p *dmlParser) insert(hints map[string]string) (*Insert, error) {
+	ins := &Insert{Hints: hints}
+	if p.dialect == GoogleSQL && p.keyword("OR") {
+		switch {
+		case p.keyword("UPDATE"):
+			ins.OrUpdate = true
+		case p.keyword("IGNORE"):
+			ins.OrIgnore = true
+		default:
+			return nil, fmt.Errorf("expected UPDATE or IGNORE after INSERT OR, got %s", p.peek().text)
+		}
+	}
+	if !p.keyword("INTO") && p.dialect == PostgreSQL {
+		return nil, fmt.Errorf("expected INTO after INSERT, got %s", p.peek().text)
+	}
+
+	table, err := p.table()
+	if err != nil {
+		return nil, err
+	}
+	ins.Table = table
+
+	if p.punct("(") {
+		if p.isQuery() {
+			// INSERT INTO t (SELECT ...)
+			p.pos--
+		} else {
+			if ins.Columns, err = p.columns(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch {
+	case p.keyword("VALUES"):
+		for {
+			row, err := p.row()
+			if err != nil {
+				return nil, err
+			}
+			ins.Rows = append(ins.Rows, row)
+			if !p.punct(",") {
+				break
+			}
+		}
+	case p.isQuery() || p.peek().kind == tokenPunct && p.peek().text == "(":
+		query, err := p.clause("query")
+		if err != nil {
+			return nil, err
+		}
+		ins.Query = &query
+	default:
+		return nil, fmt.Errorf("expected VALUES or a query, got %s", p.peek().text)
+	}
+
+	if p.dialect == PostgreSQL && p.keywords("ON", "CONFLICT") {
+		p.pos += 2
+		clause, err := p.scan("ON CONFLICT clause", false, []string{"RETURNING"})
+		if err != nil {
+			return nil, err
+		}
+		ins.OnConflict = &clause
+	}
+
+	if ins.Return, err = p.returning(); err != nil {
+		return nil, err
+	}
+	return ins, nil
+}
+
+func (p *dmlParser) update(hints map[string]string) (*Update, error) {
+	upd := &Update{Hints: hints}
+
+	table, err := p.table()
+	if err != nil {
+		return nil, err
+	}
+	upd.Table = table
+	upd.Alias = p.alias()
+
+	if !p.keyword("SET") {
+		return nil, fmt.Errorf("expected SET, got %s", p.peek().text)
+	}
+	for {
+		column, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		if !p.punct("=") {
+			return nil, fmt.Errorf("expected = after %s, got %s", column, p.peek().text)
+		}
+		value, err := p.expr("value of " + column)
+		if err != nil {
+			return nil, err
+		}
+		upd.Assignments = append(upd.Assignments, Assignment{Column: column, Value: value})
+		if !p.punct(",") {
+			break
+		}
+	}
+
+	if upd.Where, err = p.where(); err != nil {
+		return nil, err
+	}
+	if upd.Return, err = p.returning(); err != nil {
+		return nil, err
+	}
+	return upd, nil
+}
+
+func (p *dmlParser) delete(hints map[string]string) (*Delete, error) {
+	del := &Delete{Hints: hints}
+	if !p.keyword("FROM") && p.dialect == PostgreSQL {
+		return nil, fmt.Errorf("expected FROM after DELETE, got %s", p.peek().text)
+	}
+
+	table, err := p.table()
+	if err != nil {
+		return nil, err
+	}
+	del.Table = table
+	del.Alias = p.alias()
+
+	if del.Where, err = p.where(); err != nil {
+		return nil, err
+	}
+	if del.Return, err = p.returning(); err != nil {
+		return nil, err
+	}
+	return del, nil
+}
+
+// hints parses optional hints of the form @{KEY=value, ...}.
+func (p *dmlParser) hints() (map[string]string, error) {
+	if p.dialect != GoogleSQL || !p.punct("@") {
+		return nil, nil
+	}
+	if !p.punct("{") {
+		return nil, fmt.Errorf("expected { after @, got %s", p.peek().text)
+	}
+
+	hints := make(map[string]string)
+	for !p.punct("}") {
+		start := p.pos
+		for !p.done() && p.peek().text != "=" {
+			p.pos++
+		}
+		if p.pos == start || !p.punct("=") {
+			return nil, fmt.Errorf("expected KEY=value in hint, got %s", p.peek().text)
+		}
+		key := p.text(start, p.pos-1)
+
+		start = p.pos
+		for !p.done() && p.peek().text != "," && p.peek().text != "}" {
+			p.pos++
+		}
+		if p.pos == start {
+			return nil, fmt.Errorf("hint %s has no value", key)
+		}
+		hints[strings.ToUpper(key)] = p.text(start, p.pos)
+
+		if !p.punct(",") && p.peek().text != "}" {
+			return nil, fmt.Errorf("unterminated hint")
+		}
+	}
+	return hints, nil
+}
+
+// reservedWords are keywords that cannot be used as unquoted names.
+var reservedWords = map[string]bool{
+	"ALL": true, "AND": true, "ANY": true, "ARRAY": true, "AS": true, "ASC": true,
+	"BETWEEN": true, "BY": true, "CASE": true, "CAST": true, "CROSS": true,
+	"DEFAULT": true, "DESC": true, "DISTINCT": true, "ELSE": true, "END": true,
+	"EXISTS": true, "FALSE": true, "FOR": true, "FROM": true, "FULL": true,
+	"GROUP": true, "HAVING": true, "IN": true, "INNER": true, "INTO": true,
+	"IS": true, "JOIN": true, "LEFT": true, "LIKE": true, "LIMIT": true,
+	"NOT": true, "NULL": true, "ON": true, "OR": true, "ORDER": true,
+	"OUTER": true, "RETURNING": true, "RIGHT": true, "SELECT": true, "SET": true,
+	"THEN": true, "TRUE": true, "UNION": true, "USING": true, "WHEN": true,
+	"WHERE": true, "WITH": true,
+}
+
+// IsReserved reports whether word is a reserved keyword, which cannot be
+// used as a name without quoting it.
+func IsReserved(word string) bool {
+	return reservedWords[strings.ToUpper(word)]
+}
+
+// name consumes a plain or quoted identifier that is not a reserved word.
+func (p *dmlParser) name() (string, bool) {
+	if t := p.peek(); t.kind == tokenWord && reservedWords[strings.ToUpper(t.text)] {
+		return "", false
+	}
+	return p.tokenParser.name()
+}
+
+// table parses a possibly qualified table name and an optional table hint.
+func (p *dmlParser) table() (string, error) {
+	table, ok := p.name()
+	if !ok {
+		return "", fmt.Errorf("expected a table name, got %s", p.peek().text)
+	}
+	for p.punct(".") {
+		part, ok := p.name()
+		if !ok {
+			return "", fmt.Errorf("expected a name after %s., got %s", table, p.peek().text)
+		}
+		table += "." + part
+	}
+
+	// Table hints, e.g. Singers@{FORCE_INDEX=SingersByName}, only affect
+	// how the statement is executed
+	if p.peek().text == "@" {
+		if _, err := p.hints(); err != nil {
+			return "", err
+		}
+	}
+	return table, nil
+}
+
+// alias parses an optional [AS] alias. The keywords that can follow it are
+// reserved and not taken for an alias.
+func (p *dmlParser) alias() string {
+	p.keyword("AS")
+	alias, _ := p.name()
+	return alias
+}
+
+// path parses a possibly qualified column name and returns the column.
+func (p *dmlParser) path() (string, error) {
+	column, ok := p.name()
+	if !ok {
+		return "", fmt.Errorf("expected a column name, got %s", p.peek().text)
+	}
+	for p.punct(".") {
+		if column, ok = p.name(); !ok {
+			return "", fmt.Errorf("expected a column name, got %s", p.peek().text)
+		}
+	}
+	return column, nil
+}
+
+// columns parses a column list after its opening parenthesis.
+func (p *dmlParser) columns() ([]string, error) {
+	var columns []string
+	for {
+		column, ok := p.name()
+		if !ok {
+			return nil, fmt.Errorf("expected a column name, got %s", p.peek().text)
+		}
+		columns = append(columns, column)
+		if p.punct(")") {
+			return columns, nil
+		}
+		if !p.punct(",") {
+			return nil, fmt.Errorf("expected , or ) in column list, got %s", p.peek().text)
+		}
+	}
+}
+
+// row parses a parenthesized VALUES row.
+func (p *dmlParser) row() ([]Expr, error) {
+	if !p.punct("(") {
+		return nil, fmt.Errorf("expected ( before VALUES row, got %s", p.peek().text)
+	}
+
+	var row []Expr
+	for {
+		value, err := p.expr("value")
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, value)
+		if p.punct(")") {
+			return row, nil
+		}
+		if !p.punct(",") {
+			return nil, fmt.Errorf("expected , or ) in VALUES row, got %s", p.peek().text)
+		}
+	}
+}
+
+func (p *dmlParser) where() (*Expr, error) {
+	if !p.keyword("WHERE") {
+		return nil, nil
+	}
+	where, err := p.clause("WHERE condition")
+	if err != nil {
+		return nil, err
+	}
+	return &where, nil
+}
+
+// returning parses an optional THEN RETURN clause, or RETURNING clause in
+// PostgreSQL.
+func (p *dmlParser) returning() (*Return, error) {
+	ret := &Return{}
+	switch {
+	case p.dialect == GoogleSQL && p.keywords("THEN", "RETURN"):
+		p.pos += 2
+		if p.keywords("WITH", "ACTION") {
+			p.pos += 2
+			ret.WithAction = true
+			if p.keyword("AS") {
+				if _, ok := p.name(); !ok {
+					return nil, fmt.Errorf("expected an alias after WITH ACTION AS, got %s", p.peek().text)
+				}
+			}
+		}
+	case p.dialect == PostgreSQL && p.keyword("RETURNING"):
+	default:
+		return nil, nil
+	}
+
+	for {
+		item, err := p.expr("returned item")
+		if err != nil {
+			return nil, err
+		}
+		ret.Items = append(ret.Items, item)
+		if !p.punct(",") {
+			return ret, nil
+		}
+	}
+}
+
+// isQuery reports whether the next token starts a query.
+func (p *dmlParser) isQuery() bool {
+	return p.keywords("SELECT") || p.keywords("WITH") ||
+		p.peek().kind == tokenPunct && p.peek().text == "(" && p.pos+1 < len(p.tokens) &&
+			strings.EqualFold(p.tokens[p.pos+1].text, "SELECT")
+}
+
+// keywords reports whether the next tokens are the given keywords, without
+// consuming them.
+func (p *dmlParser) keywords(words ...string) bool {
+	for i, word := range words {
+		if p.pos+i >= len(p.tokens) {
+			return false
+		}
+		t := p.tokens[p.pos+i]
+		if t.kind != tokenWord || !strings.EqualFold(t.text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// expr consumes an expression that is an item of a list, e.g. a value of a
+// VALUES row. It ends before a comma or closing parenthesis that is not
+// nested, or before a clause that can follow it.
+func (p *dmlParser) expr(what string) (Expr, error) {
+	return p.scan(what, true, append([]string{"WHERE"}, p.returnClauses()...))
+}
+
+// clause consumes the text of a clause, e.g. a query or WHERE condition,
+// up to the end of the statement or a clause that can follow it.
+func (p *dmlParser) clause(what string) (Expr, error) {
+	return p.scan(what, false, p.returnClauses())
+}
+
+// returnClauses are the clauses that can follow the rows of an INSERT
+// statement or the WHERE clause of other statements.
+func (p *dmlParser) returnClauses() []string {
+	if p.dialect == PostgreSQL {
+		return []string{"ON CONFLICT", "RETURNING"}
+	}
+	return []string{"THEN RETURN"}
+}
+
+// scan consumes tokens until one of the ends is reached at the outermost
+// level. Ends are keywords separated by spaces. A list item also ends
+// before a comma or unmatched closing parenthesis.
+func (p *dmlParser) scan(what string, item bool, ends []string) (Expr, error) {
+	start := p.pos
+	depth := 0
+loop:
+	for !p.done() {
+		t := p.peek()
+		switch {
+		case t.kind == tokenPunct && (t.text == "(" || t.text == "["):
+			depth++
+		case t.kind == tokenPunct && (t.text == ")" || t.text == "]"):
+			if depth == 0 {
+				break loop
+			}
+			depth--
+		case t.kind == tokenPunct && t.text == "," && depth == 0 && item:
+			break loop
+		case t.kind == tokenWord && depth == 0:
+			for _, end := range ends {
+				if p.keywords(strings.Fields(end)...) {
+					break loop
+				}
+			}
+			if strings.EqualFold(t.text, "CASE") {
+				// THEN and END within CASE do not end the expression
+				if err := p.skipCase(); err != nil {
+					return Expr{}, err
+				}
+				continue
+			}
+		}
+		p.pos++
+	}
+
+	if p.pos == start {
+		return Expr{}, fmt.Errorf("expected %s, got %s", what, p.peek().text)
+	}
+	if depth > 0 {
+		return Expr{}, fmt.Errorf("unbalanced parentheses in %s", what)
+	}
+	return Expr{SQL: p.text(start, p.pos), tokens: p.tokens[start:p.pos]}, nil
+}
+
+// skipCase consumes a CASE expression up to its END.
+func (p *dmlParser) skipCase() error {
+	depth := 0
+	for !p.done() {
+		t := p.peek()
+		p.pos++
+		if t.kind != tokenWord {
+			continue
+		}
+		switch strings.ToUpper(t.text) {
+		case "CASE":
+			depth++
+		case "END":
+			if depth--; depth == 0 {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("CASE without END")
+}
+
+// text returns the source text of the tokens from start up to end.
+func (p *dmlParser) text(start, end int) string {
+	return p.stmt[p.tokens[start].start:p.tokens[end-1].end]
+}
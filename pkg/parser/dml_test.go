@@ -0,0 +1,264 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDML_Insert(t *testing.T) {
+	stmt, err := ParseDML("@{PDML_MAX_PARALLELISM=1} INSERT OR UPDATE INTO `Users` (id, name) VALUES (1, CONCAT('a', 'b')), (@id, 'it''s') THEN RETURN id, name AS n")
+	if err != nil {
+		t.Fatalf("ParseDML() error = %v", err)
+	}
+	ins, ok := stmt.(*Insert)
+	if !ok {
+		t.Fatalf("ParseDML() = %T, expected *Insert", stmt)
+	}
+
+	if ins.Table != "Users" || !ins.OrUpdate || ins.OrIgnore {
+		t.Errorf("ParseDML() = %+v, expected INSERT OR UPDATE into Users", ins)
+	}
+	if !reflect.DeepEqual(ins.Hints, map[string]string{"PDML_MAX_PARALLELISM": "1"}) {
+		t.Errorf("Hints = %v", ins.Hints)
+	}
+	if !reflect.DeepEqual(ins.Columns, []string{"id", "name"}) {
+		t.Errorf("Columns = %v", ins.Columns)
+	}
+	if rows := exprRows(ins.Rows); !reflect.DeepEqual(rows, [][]string{{"1", "CONCAT('a', 'b')"}, {"@id", "'it''s'"}}) {
+		t.Errorf("Rows = %q", rows)
+	}
+	if ins.Return == nil || !reflect.DeepEqual(exprSQL(ins.Return.Items), []string{"id", "name AS n"}) {
+		t.Errorf("Return = %+v", ins.Return)
+	}
+}
+
+func TestParseDML(t *testing.T) {
+	tests := []struct {
+		name  string
+		stmt  string
+		check func(t *testing.T, stmt DML)
+	}{
+		{
+			name: "insert without column list",
+			stmt: "INSERT INTO users VALUES (1)",
+			check: func(t *testing.T, stmt DML) {
+				ins := stmt.(*Insert)
+				if ins.Columns != nil || len(ins.Rows) != 1 {
+					t.Errorf("ParseDML() = %+v", ins)
+				}
+			},
+		},
+		{
+			name: "insert select",
+			stmt: "INSERT users (id, name) SELECT id, name FROM old_users WHERE active THEN RETURN WITH ACTION AS a id",
+			check: func(t *testing.T, stmt DML) {
+				ins := stmt.(*Insert)
+				if ins.Table != "users" || ins.Query == nil || ins.Query.SQL != "SELECT id, name FROM old_users WHERE active" {
+					t.Errorf("ParseDML() = %+v", ins)
+				}
+				if ins.Return == nil || !ins.Return.WithAction {
+					t.Errorf("Return = %+v, expected WITH ACTION", ins.Return)
+				}
+			},
+		},
+		{
+			name: "insert parenthesized select",
+			stmt: "INSERT INTO users (id) (SELECT 1)",
+			check: func(t *testing.T, stmt DML) {
+				if q := stmt.(*Insert).Query; q == nil || q.SQL != "(SELECT 1)" {
+					t.Errorf("Query = %+v", q)
+				}
+			},
+		},
+		{
+			name: "update",
+			stmt: "UPDATE users@{FORCE_INDEX=UsersByName} AS u SET u.name = CASE WHEN u.id = 1 THEN 'a' ELSE 'b' END, score = (SELECT MAX(score) FROM s WHERE s.id = u.id) WHERE u.id IN (1, 2) THEN RETURN *",
+			check: func(t *testing.T, stmt DML) {
+				upd := stmt.(*Update)
+				if upd.Table != "users" || upd.Alias != "u" {
+					t.Errorf("ParseDML() = %+v", upd)
+				}
+				expected := []Assignment{
+					{Column: "name", Value: Expr{SQL: "CASE WHEN u.id = 1 THEN 'a' ELSE 'b' END"}},
+					{Column: "score", Value: Expr{SQL: "(SELECT MAX(score) FROM s WHERE s.id = u.id)"}},
+				}
+				for i, a := range upd.Assignments {
+					if i >= len(expected) || a.Column != expected[i].Column || a.Value.SQL != expected[i].Value.SQL {
+						t.Errorf("Assignments[%d] = %s = %q", i, a.Column, a.Value.SQL)
+					}
+				}
+				if upd.Where == nil || upd.Where.SQL != "u.id IN (1, 2)" {
+					t.Errorf("Where = %+v", upd.Where)
+				}
+				if upd.Return == nil || len(upd.Return.Items) != 1 {
+					t.Errorf("Return = %+v", upd.Return)
+				}
+			},
+		},
+		{
+			name: "update without where",
+			stmt: "UPDATE users SET name='test'",
+			check: func(t *testing.T, stmt DML) {
+				if upd := stmt.(*Update); upd.Where != nil || len(upd.Assignments) != 1 {
+					t.Errorf("ParseDML() = %+v", upd)
+				}
+			},
+		},
+		{
+			name: "delete without from",
+			stmt: "delete users u where u.id = 1 -- comment",
+			check: func(t *testing.T, stmt DML) {
+				del := stmt.(*Delete)
+				if del.Table != "users" || del.Alias != "u" || del.Where == nil || del.Where.SQL != "u.id = 1" {
+					t.Errorf("ParseDML() = %+v", del)
+				}
+			},
+		},
+		{
+			name: "qualified table",
+			stmt: "DELETE FROM sales.orders WHERE true",
+			check: func(t *testing.T, stmt DML) {
+				if table := stmt.TableName(); table != "sales.orders" {
+					t.Errorf("TableName() = %q", table)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := ParseDML(tt.stmt)
+			if err != nil {
+				t.Fatalf("ParseDML() error = %v", err)
+			}
+			tt.check(t, stmt)
+		})
+	}
+}
+
+func TestParseDML_Invalid(t *testing.T) {
+	tests := []struct {
+		stmt    string
+		message string
+	}{
+		{"INSERTX foo", "expected INSERT, UPDATE or DELETE, got INSERTX"},
+		{"SELECT * FROM users", "expected INSERT, UPDATE or DELETE, got SELECT"},
+		{"", "got end of statement"},
+		{"INSERT INTO users", "expected VALUES or a query"},
+		{"INSERT INTO users (id VALUES (1)", "expected , or ) in column list"},
+		{"INSERT INTO users (id) VALUES (1", "expected , or ) in VALUES row"},
+		{"INSERT INTO users (id) VALUES ()", "expected value"},
+		{"INSERT OR REPLACE INTO users (id) VALUES (1)", "expected UPDATE or IGNORE"},
+		{"UPDATE users WHERE id = 1", "expected SET"},
+		{"UPDATE users SET name WHERE id = 1", "expected = after name"},
+		{"UPDATE users SET name = CASE WHEN true THEN 'a'", "CASE without END"},
+		{"DELETE FROM users WHERE id = 1)", "unexpected ) at the end"},
+		{"DELETE FROM WHERE id = 1", "expected a table name"},
+		{"@{PDML_MAX_PARALLELISM} DELETE FROM users WHERE true", "expected KEY=value"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseDML(tt.stmt)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("ParseDML(%q) error = %v, expected it to contain %q", tt.stmt, err, tt.message)
+		}
+	}
+}
+
+func TestParseDML_Statements(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement string
+		expected  bool
+	}{
+		{"INSERT uppercase", "INSERT INTO users VALUES (1)", true},
+		{"INSERT lowercase", "insert into users values (1)", true},
+		{"INSERT mixed case", "Insert Into users Values (1)", true},
+		{"UPDATE uppercase", "UPDATE users SET name = 'test'", true},
+		{"UPDATE lowercase", "update users set name = 'test'", true},
+		{"DELETE uppercase", "DELETE FROM users WHERE id = 1", true},
+		{"DELETE lowercase", "delete from users where id = 1", true},
+		{"SELECT statement", "SELECT * FROM users", false},
+		{"CREATE statement", "CREATE TABLE users (id INT)", false},
+		{"DROP statement", "DROP TABLE users", false},
+		{"Empty statement", "", false},
+		{"Whitespace only", "   ", false},
+		{"Invalid prefix", "INVALID STATEMENT", false},
+		{"Keyword prefix", "INSERTX foo", false},
+		{"INSERT without rows", "INSERT INTO users (id)", false},
+		{"Statement hint", "@{PDML_MAX_PARALLELISM=2} DELETE FROM users WHERE true", true},
+		{"THEN RETURN", "UPDATE users SET name = 'test' WHERE id = 1 THEN RETURN id", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDML(tt.statement)
+			if (err == nil) != tt.expected {
+				t.Errorf("ParseDML(%q) error = %v, expected valid %v", tt.statement, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseDML_PostgreSQL(t *testing.T) {
+	stmt, err := PostgreSQL.ParseDML(`INSERT INTO "Users" (id, name) VALUES ($1, 'O''Brien') ON CONFLICT (id) DO UPDATE SET name = excluded.name RETURNING id`)
+	if err != nil {
+		t.Fatalf("ParseDML() error = %v", err)
+	}
+	ins := stmt.(*Insert)
+	if ins.Table != "Users" || ins.OnConflict == nil || ins.OnConflict.SQL != "(id) DO UPDATE SET name = excluded.name" {
+		t.Errorf("ParseDML() = %+v", ins)
+	}
+	if ins.Return == nil || !reflect.DeepEqual(exprSQL(ins.Return.Items), []string{"id"}) {
+		t.Errorf("Return = %+v", ins.Return)
+	}
+
+	if _, err := PostgreSQL.ParseDML("DELETE users WHERE true"); err == nil {
+		t.Error("ParseDML() expected error for DELETE without FROM")
+	}
+	if _, err := PostgreSQL.ParseDML(`UPDATE "Users" SET name = $$a$$ WHERE id = $1 RETURNING *`); err != nil {
+		t.Errorf("ParseDML() error = %v", err)
+	}
+}
+
+func TestExpr_Literal(t *testing.T) {
+	tests := []struct {
+		stmt     string
+		expected Value
+		ok       bool
+	}{
+		{"INSERT INTO t (a) VALUES ('x')", Value{Kind: ValueString, Text: "x"}, true},
+		{"INSERT INTO t (a) VALUES (-1.5)", Value{Kind: ValueNumber, Text: "-1.5"}, true},
+		{"INSERT INTO t (a) VALUES (DATE '2024-01-01')", Value{Kind: ValueTyped, Text: "2024-01-01", Type: "DATE"}, true},
+		{"INSERT INTO t (a) VALUES (1 + 1)", Value{}, false},
+		{"INSERT INTO t (a) VALUES (@a)", Value{}, false},
+	}
+
+	for _, tt := range tests {
+		stmt, err := ParseDML(tt.stmt)
+		if err != nil {
+			t.Fatalf("ParseDML(%q) error = %v", tt.stmt, err)
+		}
+		v, ok := stmt.(*Insert).Rows[0][0].Literal()
+		if ok != tt.ok || v != tt.expected {
+			t.Errorf("Literal() of %q = %+v, %v, expected %+v, %v", tt.stmt, v, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func exprSQL(exprs []Expr) []string {
+	result := make([]string, len(exprs))
+	for i, e := range exprs {
+		result[i] = e.SQL
+	}
+	return result
+}
+
+func exprRows(rows [][]Expr) [][]string {
+	result := make([][]string, len(rows))
+	for i, row := range rows {
+		result[i] = exprSQL(row)
+	}
+	return result
+}
@@ -0,0 +1,345 @@
+package parser
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Statement is a parsed statement and the position it was read from.
+type Statement struct {
+	SQL string
+	// File is the file the statement was read from, empty for content that
+	// was not read from a file.
+	File string
+	// Line is the 1-based line the statement starts on.
+	Line int
+	// Annotations are the directives written between the previous statement
+	// and the end of this one, e.g. -- @partitioned.
+	Annotations []Directive
+}
+
+// Position returns the statement's source position as file:line.
+func (s Statement) Position() string {
+	if s.File == "" {
+		return fmt.Sprintf("line %d", s.Line)
+	}
+	return fmt.Sprintf("%s:%d", s.File, s.Line)
+}
+
+// Annotation returns the argument of the statement's last annotation with the
+// given name and whether there is one.
+func (s Statement) Annotation(name string) (string, bool) {
+	for i := len(s.Annotations) - 1; i >= 0; i-- {
+		if s.Annotations[i].Name == name {
+			return s.Annotations[i].Arg, true
+		}
+	}
+	return "", false
+}
+
+// Directive is a comment of the form -- @name argument.
+type Directive struct {
+	Name string
+	Arg  string
+	Line int
+}
+
+const includeDirective = "include"
+
+// File is a parsed DML file.
+type File struct {
+	Path       string
+	Statements []Statement
+	// Directives are the directives of the file itself, excluding includes
+	// and the directives of included files.
+	Directives []Directive
+	// Includes are the paths of the files included directly or indirectly,
+	// in the order they were first read.
+	Includes []string
+}
+
+// Directive returns the arguments of the directives with the given name.
+func (f *File) Directive(name string) []string {
+	var args []string
+	for _, d := range f.Directives {
+		if d.Name == name {
+			args = append(args, d.Arg)
+		}
+	}
+	return args
+}
+
+// ParseFile parses the DML statements of a file. Other files can be included
+// with -- @include path/to/other.sql, where the path is relative to the
+// including file. Statements keep the file and line they were read from.
+func ParseFile(filePath string) ([]Statement, error) {
+	f, err := LoadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return f.Statements, nil
+}
+
+// ParseContent parses DML statements like ParseFile, with content read from
+// filePath. It is used for content that was transformed after reading, e.g.
+// an expanded template.
+func ParseContent(content, filePath string) ([]Statement, error) {
+	f, err := LoadContent(content, filePath)
+	if err != nil {
+		return nil, err
+	}
+	return f.Statements, nil
+}
+
+// LoadFile parses a file like ParseFile and also returns its directives.
+func LoadFile(filePath string) (*File, error) {
+	return GoogleSQL.LoadFile(filePath)
+}
+
+// LoadContent parses content like ParseContent and also returns its
+// directives.
+func LoadContent(content, filePath string) (*File, error) {
+	return GoogleSQL.LoadContent(content, filePath)
+}
+
+// LoadFile parses a file written in the dialect like the package-level
+// LoadFile.
+func (d Dialect) LoadFile(filePath string) (*File, error) {
+	l := osLoader(d)
+	statements, err := l.load(filepath.Clean(filePath), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &File{Path: filePath, Statements: statements, Directives: l.directives, Includes: l.includes}, nil
+}
+
+// LoadContent parses content written in the dialect like the package-level
+// LoadContent.
+func (d Dialect) LoadContent(content, filePath string) (*File, error) {
+	l := osLoader(d)
+	statements, err := l.parse(content, filepath.Clean(filePath), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &File{Path: filePath, Statements: statements, Directives: l.directives, Includes: l.includes}, nil
+}
+
+// ParseFS parses the DML files in fsys matching pattern (see fs.Glob), in
+// lexical file order. Included paths are relative to the including file
+// within fsys.
+func ParseFS(fsys fs.FS, pattern string) ([]Statement, error) {
+	matches, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no files match %s", pattern)
+	}
+
+	l := &loader{
+		read: func(name string) ([]byte, error) {
+			return fs.ReadFile(fsys, name)
+		},
+		resolve: func(from, name string) string {
+			return path.Join(path.Dir(from), name)
+		},
+	}
+
+	var statements []Statement
+	for _, name := range matches {
+		fileStatements, err := l.load(name, nil)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, fileStatements...)
+	}
+
+	return statements, nil
+}
+
+func osLoader(d Dialect) *loader {
+	return &loader{
+		dialect: d,
+		read:    os.ReadFile,
+		resolve: func(from, name string) string {
+			if filepath.IsAbs(name) {
+				return filepath.Clean(name)
+			}
+			return filepath.Join(filepath.Dir(from), name)
+		},
+	}
+}
+
+// loader reads files and expands their includes.
+type loader struct {
+	dialect Dialect
+	read    func(name string) ([]byte, error)
+	resolve func(from, name string) string
+
+	// directives collects the directives of the outermost file
+	directives []Directive
+	// includes collects the included files
+	includes []string
+}
+
+// load parses a file. stack holds the files currently being included, to
+// detect cycles.
+func (l *loader) load(name string, stack []string) ([]Statement, error) {
+	content, err := l.read(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", name, err)
+	}
+
+	return l.parse(string(content), name, stack)
+}
+
+func (l *loader) parse(content, name string, stack []string) ([]Statement, error) {
+	stack = append(stack, name)
+
+	var statements []Statement
+	var annotations []Directive
+	for _, it := range scan(content, l.dialect) {
+		if it.directive == nil {
+			stmt := Statement{SQL: it.sql, File: name, Line: it.line, Annotations: annotations}
+			if _, err := l.dialect.ParseDML(stmt.SQL); err != nil {
+				return nil, fmt.Errorf("%s: invalid DML statement: %s: %w", stmt.Position(), truncate(stmt.SQL, 50), err)
+			}
+			statements = append(statements, stmt)
+			annotations = nil
+			continue
+		}
+
+		d := it.directive
+		if d.Name != includeDirective {
+			if len(stack) == 1 {
+				l.directives = append(l.directives, *d)
+			}
+			annotations = append(annotations, *d)
+			continue
+		}
+		// Annotations do not carry over into included files
+		annotations = nil
+		if it.inStatement {
+			return nil, fmt.Errorf("%s:%d: @include must appear between statements", name, d.Line)
+		}
+		target := strings.Trim(d.Arg, `'"`)
+		if target == "" {
+			return nil, fmt.Errorf("%s:%d: @include requires a file path", name, d.Line)
+		}
+
+		included := l.resolve(name, target)
+		for i, file := range stack {
+			if file == included {
+				cycle := append(append([]string{}, stack[i:]...), included)
+				return nil, fmt.Errorf("%s:%d: include cycle: %s", name, d.Line, strings.Join(cycle, " -> "))
+			}
+		}
+
+		if !slices.Contains(l.includes, included) {
+			l.includes = append(l.includes, included)
+		}
+		includedStatements, err := l.load(included, stack)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, d.Line, err)
+		}
+		statements = append(statements, includedStatements...)
+	}
+
+	return statements, nil
+}
+
+// item is a statement or a directive found by scan.
+type item struct {
+	sql  string
+	line int
+
+	directive *Directive
+	// inStatement is set for directives that appear inside a statement.
+	inStatement bool
+}
+
+// scan splits content into statements and directives in source order.
+// Comments other than directives are removed from statements.
+func scan(content string, d Dialect) []item {
+	var items []item
+	var current strings.Builder
+	line, start := 1, 0
+
+	finish := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			items = append(items, item{sql: stmt, line: start})
+		}
+		current.Reset()
+		start = 0
+	}
+
+	for _, seg := range d.segments(content) {
+		switch seg.kind {
+		case segmentComment:
+			if directive, ok := parseDirective(seg.text, line); ok {
+				items = append(items, item{directive: directive, inStatement: start != 0})
+			}
+			current.WriteString(commentPlaceholder(seg.text))
+		case segmentLiteral:
+			if start == 0 {
+				start = line
+			}
+			current.WriteString(seg.text)
+		default:
+			for i := 0; i < len(seg.text); i++ {
+				c := seg.text[i]
+				switch {
+				case c == ';':
+					finish()
+					continue
+				case c == '\n':
+				case c != ' ' && c != '\t' && c != '\r' && start == 0:
+					start = line
+				}
+				current.WriteByte(c)
+				if c == '\n' {
+					line++
+				}
+			}
+			continue
+		}
+		line += strings.Count(seg.text, "\n")
+	}
+	finish()
+
+	return items
+}
+
+// parseDirective parses a line comment of the form -- @name argument.
+func parseDirective(comment string, line int) (*Directive, bool) {
+	body, ok := strings.CutPrefix(comment, "--")
+	if !ok {
+		return nil, false
+	}
+	body, ok = strings.CutPrefix(strings.TrimSpace(body), "@")
+	if !ok {
+		return nil, false
+	}
+
+	name, arg := body, ""
+	if idx := strings.IndexAny(body, " \t"); idx != -1 {
+		name, arg = body[:idx], body[idx+1:]
+	}
+	if name == "" {
+		return nil, false
+	}
+
+	return &Directive{Name: strings.ToLower(name), Arg: strings.TrimSpace(arg), Line: line}, true
+}
+
+func truncate(s string, limit int) string {
+	if len(s) < limit {
+		return s
+	}
+	return s[:limit]
+}
@@ -0,0 +1,223 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseFS_Include(t *testing.T) {
+	fsys := fstest.MapFS{
+		"scenarios/basic.sql": {Data: []byte(`-- @include ../shared/users.sql
+
+INSERT INTO posts (id, user_id) VALUES (1, 1);
+-- @include "../shared/comments.sql"
+`)},
+		"shared/users.sql": {Data: []byte(`-- shared users
+INSERT INTO users (id, name)
+VALUES (1, 'John');
+-- @include roles.sql`)},
+		"shared/roles.sql":    {Data: []byte(`INSERT INTO roles (id) VALUES (1);`)},
+		"shared/comments.sql": {Data: []byte("\n\nDELETE FROM comments WHERE true;")},
+	}
+
+	statements, err := ParseFS(fsys, "scenarios/*.sql")
+	if err != nil {
+		t.Fatalf("ParseFS() error = %v", err)
+	}
+
+	expected := []Statement{
+		{SQL: "INSERT INTO users (id, name)\nVALUES (1, 'John')", File: "shared/users.sql", Line: 2},
+		{SQL: "INSERT INTO roles (id) VALUES (1)", File: "shared/roles.sql", Line: 1},
+		{SQL: "INSERT INTO posts (id, user_id) VALUES (1, 1)", File: "scenarios/basic.sql", Line: 3},
+		{SQL: "DELETE FROM comments WHERE true", File: "shared/comments.sql", Line: 3},
+	}
+	if !reflect.DeepEqual(statements, expected) {
+		t.Errorf("ParseFS() = %+v, expected %+v", statements, expected)
+	}
+}
+
+func TestParseFS_IncludeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		message string
+	}{
+		{
+			name: "cycle",
+			files: fstest.MapFS{
+				"a.sql": {Data: []byte("-- @include b.sql")},
+				"b.sql": {Data: []byte("INSERT INTO t (id) VALUES (1);\n-- @include a.sql")},
+			},
+			message: "b.sql:2: include cycle: a.sql -> b.sql -> a.sql",
+		},
+		{
+			name: "self include",
+			files: fstest.MapFS{
+				"a.sql": {Data: []byte("-- @include ./a.sql")},
+			},
+			message: "include cycle: a.sql -> a.sql",
+		},
+		{
+			name: "missing file",
+			files: fstest.MapFS{
+				"a.sql": {Data: []byte("-- @include missing.sql")},
+			},
+			message: "a.sql:1: failed to read file missing.sql",
+		},
+		{
+			name: "invalid statement in included file",
+			files: fstest.MapFS{
+				"a.sql": {Data: []byte("-- @include b.sql")},
+				"b.sql": {Data: []byte("INSERT INTO t (id) VALUES (1);\n\nSELECT 1;")},
+			},
+			message: "b.sql:3: invalid DML statement: SELECT 1",
+		},
+		{
+			name: "include inside statement",
+			files: fstest.MapFS{
+				"a.sql": {Data: []byte("INSERT INTO t (id)\n-- @include b.sql\nVALUES (1);")},
+				"b.sql": {Data: []byte("")},
+			},
+			message: "a.sql:2: @include must appear between statements",
+		},
+		{
+			name: "missing path",
+			files: fstest.MapFS{
+				"a.sql": {Data: []byte("-- @include")},
+			},
+			message: "a.sql:1: @include requires a file path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFS(tt.files, "a.sql")
+			if err == nil {
+				t.Fatal("ParseFS() expected error")
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("ParseFS() error = %v, expected it to contain %q", err, tt.message)
+			}
+		})
+	}
+}
+
+func TestParseFile_Include(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "shared"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"seed.sql":         "-- @include shared/users.sql\nINSERT INTO posts (id) VALUES (1);",
+		"shared/users.sql": "INSERT INTO users (id) VALUES (1);",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	statements, err := ParseFile(filepath.Join(dir, "seed.sql"))
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	if len(statements) != 2 {
+		t.Fatalf("ParseFile() returned %d statements, expected 2", len(statements))
+	}
+	if statements[0].File != filepath.Join(dir, "shared", "users.sql") || statements[0].Line != 1 {
+		t.Errorf("first statement position = %s, expected shared/users.sql:1", statements[0].Position())
+	}
+
+	f, err := LoadFile(filepath.Join(dir, "seed.sql"))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if expected := []string{filepath.Join(dir, "shared", "users.sql")}; !reflect.DeepEqual(f.Includes, expected) {
+		t.Errorf("LoadFile() includes = %v, expected %v", f.Includes, expected)
+	}
+
+	// Templates are expanded before parsing, so includes resolve relative to
+	// the original file
+	statements, err = ParseContent("-- @include shared/users.sql", filepath.Join(dir, "seed.sql.tmpl"))
+	if err != nil {
+		t.Fatalf("ParseContent() error = %v", err)
+	}
+	if len(statements) != 1 {
+		t.Errorf("ParseContent() returned %d statements, expected 1", len(statements))
+	}
+}
+
+func TestParseDirective(t *testing.T) {
+	tests := []struct {
+		comment  string
+		expected *Directive
+	}{
+		{"-- @include shared/users.sql", &Directive{Name: "include", Arg: "shared/users.sql", Line: 1}},
+		{"--@Include\tusers.sql ", &Directive{Name: "include", Arg: "users.sql", Line: 1}},
+		{"-- @skip", &Directive{Name: "skip", Line: 1}},
+		{"-- plain comment", nil},
+		{"-- @", nil},
+		{"/* @include users.sql */", nil},
+	}
+
+	for _, tt := range tests {
+		d, ok := parseDirective(tt.comment, 1)
+		if ok != (tt.expected != nil) || !reflect.DeepEqual(d, tt.expected) {
+			t.Errorf("parseDirective(%q) = %+v, expected %+v", tt.comment, d, tt.expected)
+		}
+	}
+}
+
+func TestLoadContent_Directives(t *testing.T) {
+	content := `-- @depends-on users.sql
+-- @group fixtures
+INSERT INTO posts (id) VALUES (1);
+-- @depends-on tags.sql`
+
+	f, err := LoadContent(content, "posts.sql")
+	if err != nil {
+		t.Fatalf("LoadContent() error = %v", err)
+	}
+	if result := f.Directive("depends-on"); !reflect.DeepEqual(result, []string{"users.sql", "tags.sql"}) {
+		t.Errorf("Directive(depends-on) = %q", result)
+	}
+	if result := f.Directive("group"); !reflect.DeepEqual(result, []string{"fixtures"}) {
+		t.Errorf("Directive(group) = %q", result)
+	}
+	if result := f.Directive("missing"); result != nil {
+		t.Errorf("Directive(missing) = %q, expected none", result)
+	}
+}
+
+func TestLoadContent_Annotations(t *testing.T) {
+	content := `-- @group cleanup
+INSERT INTO users (id) VALUES (1);
+
+-- @partitioned
+DELETE FROM sessions WHERE true;
+DELETE FROM tokens WHERE true;`
+
+	f, err := LoadContent(content, "cleanup.sql")
+	if err != nil {
+		t.Fatalf("LoadContent() error = %v", err)
+	}
+	if len(f.Statements) != 3 {
+		t.Fatalf("LoadContent() returned %d statements, expected 3", len(f.Statements))
+	}
+
+	if _, ok := f.Statements[1].Annotation("partitioned"); !ok {
+		t.Error("second statement is not annotated with @partitioned")
+	}
+	for _, i := range []int{0, 2} {
+		if _, ok := f.Statements[i].Annotation("partitioned"); ok {
+			t.Errorf("statement %d is unexpectedly annotated with @partitioned", i+1)
+		}
+	}
+	if arg, ok := f.Statements[0].Annotation("group"); !ok || arg != "cleanup" {
+		t.Errorf("Annotation(group) = %q, %v, expected cleanup", arg, ok)
+	}
+}
@@ -0,0 +1,308 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InsertValues is an INSERT statement whose rows are given as literals, e.g.
+// INSERT INTO users (id, name) VALUES (1, 'John'), (2, 'Jane').
+type InsertValues struct {
+	Table   string
+	Columns []string
+	Rows    [][]Value
+	// OrUpdate is set for INSERT OR UPDATE statements.
+	OrUpdate bool
+}
+
+// ValueKind is the kind of a literal value.
+type ValueKind int
+
+const (
+	ValueNull ValueKind = iota
+	ValueBool
+	ValueNumber
+	ValueString
+	ValueBytes
+	// ValueTyped is a typed literal such as DATE '2024-01-01'. Text holds the
+	// unquoted string and Type the type name.
+	ValueTyped
+	// ValueCommitTimestamp is PENDING_COMMIT_TIMESTAMP().
+	ValueCommitTimestamp
+)
+
+// Value is a literal in a VALUES list. Text holds the literal as written for
+// numbers and booleans, and the unquoted contents for strings and bytes.
+type Value struct {
+	Kind ValueKind
+	Text string
+	Type string
+}
+
+// typedLiterals are the types accepted before a string literal.
+var typedLiterals = map[string]bool{"DATE": true, "TIMESTAMP": true, "NUMERIC": true, "JSON": true}
+
+// ParseInsertValues parses an INSERT statement with a column list and
+// literal VALUES rows. Statements using anything else, e.g. expressions,
+// parameters, subqueries, hints or THEN RETURN, are reported as errors that
+// describe what is not supported.
+func ParseInsertValues(stmt string) (*InsertValues, error) {
+	parsed, err := ParseDML(stmt)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := parsed.(*Insert)
+	if !ok {
+		return nil, fmt.Errorf("not an INSERT statement")
+	}
+
+	switch {
+	case len(s.Hints) > 0:
+		return nil, fmt.Errorf("unsupported statement hints")
+	case s.OrIgnore:
+		return nil, fmt.Errorf("unsupported INSERT OR IGNORE")
+	case s.Columns == nil:
+		return nil, fmt.Errorf("INSERT without a column list")
+	case s.Query != nil:
+		return nil, fmt.Errorf("INSERT without VALUES")
+	case s.Return != nil:
+		return nil, fmt.Errorf("unsupported THEN RETURN")
+	}
+
+	ins := &InsertValues{Table: s.Table, Columns: s.Columns, OrUpdate: s.OrUpdate}
+	for _, exprs := range s.Rows {
+		if len(exprs) != len(s.Columns) {
+			return nil, fmt.Errorf("row has %d values for %d columns", len(exprs), len(s.Columns))
+		}
+		row := make([]Value, len(exprs))
+		for i, expr := range exprs {
+			v, ok := expr.Literal()
+			if !ok {
+				return nil, fmt.Errorf("unsupported value expression %s", expr.SQL)
+			}
+			row[i] = v
+		}
+		ins.Rows = append(ins.Rows, row)
+	}
+	return ins, nil
+}
+
+// tokenParser consumes the tokens of a statement.
+type tokenParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *tokenParser) peek() token {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return token{kind: tokenEnd, text: "end of statement"}
+}
+
+func (p *tokenParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *tokenParser) keyword(word string) bool {
+	if t := p.peek(); t.kind == tokenWord && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *tokenParser) punct(s string) bool {
+	if t := p.peek(); t.kind == tokenPunct && t.text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// name consumes a plain or quoted identifier.
+func (p *tokenParser) name() (string, bool) {
+	switch t := p.peek(); t.kind {
+	case tokenWord:
+		p.pos++
+		return t.text, true
+	case tokenQuotedName:
+		p.pos++
+		return t.text[1 : len(t.text)-1], true
+	}
+	return "", false
+}
+
+func (p *tokenParser) value() (Value, error) {
+	t := p.peek()
+	p.pos++
+
+	switch t.kind {
+	case tokenNumber:
+		return Value{Kind: ValueNumber, Text: t.text}, nil
+	case tokenString:
+		s, err := Unquote(t.text)
+		if err != nil {
+			return Value{}, err
+		}
+		kind := ValueString
+		if isBytesLiteral(t.text) {
+			kind = ValueBytes
+		}
+		return Value{Kind: kind, Text: s}, nil
+	case tokenPunct:
+		if (t.text == "-" || t.text == "+") && p.peek().kind == tokenNumber {
+			n := p.peek()
+			p.pos++
+			return Value{Kind: ValueNumber, Text: strings.TrimPrefix(t.text, "+") + n.text}, nil
+		}
+	case tokenWord:
+		word := strings.ToUpper(t.text)
+		switch {
+		case word == "NULL":
+			return Value{Kind: ValueNull}, nil
+		case word == "TRUE" || word == "FALSE":
+			return Value{Kind: ValueBool, Text: word}, nil
+		case typedLiterals[word] && p.peek().kind == tokenString && !isBytesLiteral(p.peek().text):
+			s, err := Unquote(p.peek().text)
+			if err != nil {
+				return Value{}, err
+			}
+			p.pos++
+			return Value{Kind: ValueTyped, Text: s, Type: word}, nil
+		case word == "PENDING_COMMIT_TIMESTAMP" && p.punct("(") && p.punct(")"):
+			return Value{Kind: ValueCommitTimestamp}, nil
+		}
+	}
+
+	return Value{}, fmt.Errorf("unsupported value expression %s", t.text)
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenWord
+	tokenNumber
+	tokenString
+	tokenQuotedName
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// start and end are the offsets of the token in the statement
+	start, end int
+}
+
+// lex splits a statement of the dialect into tokens, dropping comments.
+// String and bytes literals keep their quotes and prefixes.
+func (d Dialect) lex(stmt string) []token {
+	var tokens []token
+	// prefix is set while the last token is a literal prefix such as r or b
+	// directly followed by a quote
+	prefixEnd := false
+	offset := 0
+
+	for _, seg := range d.segments(stmt) {
+		start := offset
+		offset += len(seg.text)
+		switch seg.kind {
+		case segmentComment:
+			prefixEnd = false
+			continue
+		case segmentLiteral:
+			if d.isQuotedName(seg.text) {
+				tokens = append(tokens, token{kind: tokenQuotedName, text: seg.text, start: start, end: offset})
+			} else if n := len(tokens); prefixEnd && d.isLiteralPrefix(tokens[n-1].text) {
+				tokens[n-1] = token{kind: tokenString, text: tokens[n-1].text + seg.text, start: tokens[n-1].start, end: offset}
+			} else {
+				tokens = append(tokens, token{kind: tokenString, text: seg.text, start: start, end: offset})
+			}
+			prefixEnd = false
+			continue
+		}
+
+		text := seg.text
+		for i := 0; i < len(text); {
+			c := text[i]
+			end := i + 1
+			kind := tokenPunct
+			switch {
+			case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+				i++
+				continue
+			case isDigit(c) || c == '.' && i+1 < len(text) && isDigit(text[i+1]):
+				end = numberEnd(text, i)
+				kind = tokenNumber
+			case isParamChar(c):
+				for end < len(text) && isParamChar(text[end]) {
+					end++
+				}
+				kind = tokenWord
+			}
+			tokens = append(tokens, token{kind: kind, text: text[i:end], start: start + i, end: start + end})
+			i = end
+		}
+		prefixEnd = len(tokens) > 0 && tokens[len(tokens)-1].kind == tokenWord &&
+			strings.HasSuffix(text, tokens[len(tokens)-1].text)
+	}
+
+	return tokens
+}
+
+// isLiteralPrefix reports whether s prefixes a string literal of the
+// dialect, e.g. r or b in GoogleSQL and E in PostgreSQL.
+func (d Dialect) isLiteralPrefix(s string) bool {
+	if d == PostgreSQL {
+		return strings.EqualFold(s, "e")
+	}
+	return isLiteralPrefix(s)
+}
+
+// numberEnd returns the end of the numeric literal starting at text[i].
+func numberEnd(text string, i int) int {
+	if strings.HasPrefix(text[i:], "0x") || strings.HasPrefix(text[i:], "0X") {
+		end := i + 2
+		for end < len(text) && isHexDigit(text[end]) {
+			end++
+		}
+		return end
+	}
+
+	end := i
+	for end < len(text) && (isDigit(text[end]) || text[end] == '.') {
+		end++
+	}
+	if end < len(text) && (text[end] == 'e' || text[end] == 'E') {
+		exp := end + 1
+		if exp < len(text) && (text[exp] == '+' || text[exp] == '-') {
+			exp++
+		}
+		if exp < len(text) && isDigit(text[exp]) {
+			end = exp
+			for end < len(text) && isDigit(text[end]) {
+				end++
+			}
+		}
+	}
+	return end
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func isLiteralPrefix(s string) bool {
+	switch strings.ToLower(s) {
+	case "r", "b", "rb", "br":
+		return true
+	}
+	return false
+}
+
+func isBytesLiteral(literal string) bool {
+	prefix := strings.ToLower(literal[:strings.IndexAny(literal, `'"`)])
+	return strings.Contains(prefix, "b")
+}
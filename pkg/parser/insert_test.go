@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseInsertValues(t *testing.T) {
+	stmt := `INSERT INTO users (id, name, ` + "`data`" + `, score, active, created_at, updated_at, deleted_at) VALUES
+  (1, 'John\'s', b'\x00\xff', -1.5e3, TRUE, TIMESTAMP '2024-01-01T00:00:00Z', PENDING_COMMIT_TIMESTAMP(), NULL),
+  (0x1F, r"C:\path", B"raw", +2, false, '2024-01-02', pending_commit_timestamp ( ), null)`
+
+	ins, err := ParseInsertValues(stmt)
+	if err != nil {
+		t.Fatalf("ParseInsertValues() error = %v", err)
+	}
+
+	expected := &InsertValues{
+		Table:   "users",
+		Columns: []string{"id", "name", "data", "score", "active", "created_at", "updated_at", "deleted_at"},
+		Rows: [][]Value{
+			{
+				{Kind: ValueNumber, Text: "1"},
+				{Kind: ValueString, Text: "John's"},
+				{Kind: ValueBytes, Text: "\x00\xff"},
+				{Kind: ValueNumber, Text: "-1.5e3"},
+				{Kind: ValueBool, Text: "TRUE"},
+				{Kind: ValueTyped, Text: "2024-01-01T00:00:00Z", Type: "TIMESTAMP"},
+				{Kind: ValueCommitTimestamp},
+				{Kind: ValueNull},
+			},
+			{
+				{Kind: ValueNumber, Text: "0x1F"},
+				{Kind: ValueString, Text: `C:\path`},
+				{Kind: ValueBytes, Text: "raw"},
+				{Kind: ValueNumber, Text: "2"},
+				{Kind: ValueBool, Text: "FALSE"},
+				{Kind: ValueString, Text: "2024-01-02"},
+				{Kind: ValueCommitTimestamp},
+				{Kind: ValueNull},
+			},
+		},
+	}
+	if !reflect.DeepEqual(ins, expected) {
+		t.Errorf("ParseInsertValues() = %+v, expected %+v", ins, expected)
+	}
+
+	ins, err = ParseInsertValues("INSERT OR UPDATE Singers (SingerId) VALUES (1)")
+	if err != nil {
+		t.Fatalf("ParseInsertValues() error = %v", err)
+	}
+	if !ins.OrUpdate || ins.Table != "Singers" {
+		t.Errorf("ParseInsertValues() = %+v, expected INSERT OR UPDATE into Singers", ins)
+	}
+}
+
+func TestParseInsertValues_Unsupported(t *testing.T) {
+	tests := []struct {
+		stmt    string
+		message string
+	}{
+		{"UPDATE users SET name = 'x' WHERE true", "not an INSERT statement"},
+		{"INSERT INTO users VALUES (1)", "without a column list"},
+		{"INSERT OR IGNORE INTO users (id) VALUES (1)", "unsupported INSERT OR IGNORE"},
+		{"INSERT INTO users (id) SELECT id FROM old_users", "without VALUES"},
+		{"INSERT INTO users (id, name) VALUES (1, CONCAT('a', 'b'))", "unsupported value expression CONCAT"},
+		{"INSERT INTO users (id) VALUES (@id)", "unsupported value expression @"},
+		{"INSERT INTO users (id, tags) VALUES (1, ['a'])", "unsupported value expression ["},
+		{"INSERT INTO users (id) VALUES (1 + 1)", "unsupported value expression 1 + 1"},
+		{"INSERT INTO users (id, name) VALUES (1)", "row has 1 values for 2 columns"},
+		{"INSERT INTO users (id) VALUES (1) THEN RETURN id", "unsupported THEN RETURN"},
+		{"@{PDML_MAX_PARALLELISM=1} INSERT INTO users (id) VALUES (1)", "unsupported statement hints"},
+		{"INSERT INTO users (id) VALUES (1", "expected , or ) in VALUES row"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseInsertValues(tt.stmt)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("ParseInsertValues(%q) error = %v, expected it to contain %q", tt.stmt, err, tt.message)
+		}
+	}
+}
+
+func TestParseInsertValues_Example(t *testing.T) {
+	content, err := os.ReadFile("../../examples/seed.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements, err := ParseDMLContent(string(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, stmt := range statements {
+		if _, err := ParseInsertValues(stmt); err != nil {
+			t.Errorf("ParseInsertValues(%q) error = %v", truncate(stmt, 50), err)
+		}
+	}
+}
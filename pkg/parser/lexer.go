@@ -0,0 +1,256 @@
+package parser
+
+import "strings"
+
+type segmentKind int
+
+const (
+	segmentCode segmentKind = iota
+	segmentLiteral
+	segmentComment
+)
+
+// segment is a run of SQL text that is either plain code, a quoted literal
+// (string, bytes or quoted identifier) or a comment.
+type segment struct {
+	kind segmentKind
+	text string
+}
+
+// segments splits SQL text into code, literal and comment segments so that
+// semicolons and comment markers inside literals are not mistaken for
+// statement terminators or comments. An unterminated literal or block
+// comment extends to the end of the content.
+func segments(content string) []segment {
+	var result []segment
+	start := 0
+	emit := func(end int, kind segmentKind) {
+		if end > start {
+			result = append(result, segment{kind: kind, text: content[start:end]})
+		}
+		start = end
+	}
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '-' && strings.HasPrefix(content[i:], "--"), c == '#':
+			emit(i, segmentCode)
+			end := strings.IndexByte(content[i:], '\n')
+			if end == -1 {
+				end = len(content) - i
+			}
+			i += end
+			emit(i, segmentComment)
+		case c == '/' && strings.HasPrefix(content[i:], "/*"):
+			emit(i, segmentCode)
+			end := strings.Index(content[i+2:], "*/")
+			if end == -1 {
+				i = len(content)
+			} else {
+				i += 2 + end + 2
+			}
+			emit(i, segmentComment)
+		case c == '\'' || c == '"' || c == '`':
+			emit(i, segmentCode)
+			i = literalEnd(content, i)
+			emit(i, segmentLiteral)
+		default:
+			i++
+		}
+	}
+	emit(len(content), segmentCode)
+
+	return result
+}
+
+// literalEnd returns the index just past the literal starting at content[i],
+// which must be a quote character. Triple-quoted strings and backslash
+// escapes are supported.
+func literalEnd(content string, i int) int {
+	quote := content[i]
+	delim := content[i : i+1]
+	if quote != '`' && strings.HasPrefix(content[i:], strings.Repeat(delim, 3)) {
+		delim = strings.Repeat(delim, 3)
+	}
+
+	for j := i + len(delim); j < len(content); j++ {
+		switch {
+		case content[j] == '\\':
+			j++
+		case content[j] == quote && strings.HasPrefix(content[j:], delim):
+			return j + len(delim)
+		}
+	}
+
+	return len(content)
+}
+
+// SplitStatements splits SQL text into statements terminated by semicolons
+// and removes comments. Semicolons and comment markers inside string
+// literals and quoted identifiers are preserved. Text following the last
+// semicolon is returned as rest; it is non-blank when the content ends with
+// an incomplete statement.
+func SplitStatements(content string) (statements []string, rest string) {
+	return GoogleSQL.SplitStatements(content)
+}
+
+// SplitStatements splits SQL text of the dialect like the package-level
+// SplitStatements.
+func (d Dialect) SplitStatements(content string) (statements []string, rest string) {
+	var current strings.Builder
+	for _, seg := range d.segments(content) {
+		switch seg.kind {
+		case segmentComment:
+			current.WriteString(commentPlaceholder(seg.text))
+		case segmentLiteral:
+			current.WriteString(seg.text)
+		default:
+			text := seg.text
+			for {
+				idx := strings.IndexByte(text, ';')
+				if idx == -1 {
+					current.WriteString(text)
+					break
+				}
+				current.WriteString(text[:idx])
+				if stmt := strings.TrimSpace(current.String()); stmt != "" {
+					statements = append(statements, stmt)
+				}
+				current.Reset()
+				text = text[idx+1:]
+			}
+		}
+	}
+
+	return statements, current.String()
+}
+
+// commentPlaceholder returns the text that replaces a comment: its newlines,
+// so that line numbers of the following text are preserved.
+func commentPlaceholder(comment string) string {
+	return strings.Repeat("\n", strings.Count(comment, "\n"))
+}
+
+// ParamNames returns the names of the @name query parameters referenced in
+// stmt in order of first appearance. Parameters inside literals and comments,
+// hints such as @{FORCE_INDEX=...} and system variables (@@name) are ignored.
+func ParamNames(stmt string) []string {
+	return GoogleSQL.ParamNames(stmt)
+}
+
+// ParamNames returns the names of the query parameters referenced in stmt,
+// like the package-level ParamNames. PostgreSQL statements use positional
+// parameters $1, $2, ..., which are returned as p1, p2, ..., the names they
+// are bound by.
+func (d Dialect) ParamNames(stmt string) []string {
+	if d == PostgreSQL {
+		return positionalParamNames(stmt)
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, seg := range segments(stmt) {
+		if seg.kind != segmentCode {
+			continue
+		}
+
+		text := seg.text
+		for i := 0; i < len(text); i++ {
+			if text[i] != '@' {
+				continue
+			}
+			if i+1 < len(text) && text[i+1] == '@' {
+				// Skip the whole system variable name
+				i++
+				for i+1 < len(text) && isParamChar(text[i+1]) {
+					i++
+				}
+				continue
+			}
+
+			end := i + 1
+			for end < len(text) && isParamChar(text[end]) {
+				end++
+			}
+			if end == i+1 || isDigit(text[i+1]) {
+				continue
+			}
+
+			name := text[i+1 : end]
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+			i = end - 1
+		}
+	}
+
+	return names
+}
+
+func positionalParamNames(stmt string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, seg := range postgreSQLSegments(stmt) {
+		if seg.kind != segmentCode {
+			continue
+		}
+
+		text := seg.text
+		for i := 0; i < len(text); i++ {
+			if text[i] != '$' || i > 0 && isParamChar(text[i-1]) {
+				continue
+			}
+			end := i + 1
+			for end < len(text) && isDigit(text[end]) {
+				end++
+			}
+			if end == i+1 {
+				continue
+			}
+
+			name := "p" + text[i+1:end]
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+			i = end - 1
+		}
+	}
+
+	return names
+}
+
+func isParamChar(c byte) bool {
+	return c == '_' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Token is a token of a statement: a word, number, string literal, quoted
+// name or punctuation character.
+type Token struct {
+	Text string
+	// Offset is the position of the token in the statement.
+	Offset int
+}
+
+// Tokens splits a statement into tokens. Comments are dropped and string
+// literals keep their quotes and prefixes, e.g. r'...' or b"...".
+func Tokens(stmt string) []Token {
+	return GoogleSQL.Tokens(stmt)
+}
+
+// Tokens splits a statement of the dialect into tokens like the
+// package-level Tokens.
+func (d Dialect) Tokens(stmt string) []Token {
+	lexed := d.lex(stmt)
+	tokens := make([]Token, len(lexed))
+	for i, t := range lexed {
+		tokens[i] = Token{Text: t.text, Offset: t.start}
+	}
+	return tokens
+}
@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements_Literals(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		statements []string
+		rest       string
+	}{
+		{
+			name:       "semicolon in string literal",
+			content:    "INSERT INTO t (s) VALUES ('a;b'); DELETE FROM t WHERE true;",
+			statements: []string{"INSERT INTO t (s) VALUES ('a;b')", "DELETE FROM t WHERE true"},
+		},
+		{
+			name:       "comment marker in string literal",
+			content:    "INSERT INTO t (s) VALUES ('a -- b', \"c # d\");",
+			statements: []string{"INSERT INTO t (s) VALUES ('a -- b', \"c # d\")"},
+		},
+		{
+			name:       "escaped quote",
+			content:    `INSERT INTO t (s) VALUES ('it\'s;');`,
+			statements: []string{`INSERT INTO t (s) VALUES ('it\'s;')`},
+		},
+		{
+			name:       "triple-quoted string",
+			content:    "INSERT INTO t (s) VALUES ('''a ' ; b''');",
+			statements: []string{"INSERT INTO t (s) VALUES ('''a ' ; b''')"},
+		},
+		{
+			name:       "quoted identifier",
+			content:    "DELETE FROM `my;table` WHERE true;",
+			statements: []string{"DELETE FROM `my;table` WHERE true"},
+		},
+		{
+			name:       "block and hash comments",
+			content:    "/* first; */ DELETE FROM t WHERE true; # second;\nDELETE FROM u WHERE true;",
+			statements: []string{"DELETE FROM t WHERE true", "DELETE FROM u WHERE true"},
+		},
+		{
+			name:       "incomplete statement",
+			content:    "DELETE FROM t WHERE true; SELECT 1",
+			statements: []string{"DELETE FROM t WHERE true"},
+			rest:       " SELECT 1",
+		},
+		{
+			name:    "unterminated string",
+			content: "INSERT INTO t (s) VALUES ('a;",
+			rest:    "INSERT INTO t (s) VALUES ('a;",
+		},
+		{
+			name:       "multi-line block comment keeps newlines",
+			content:    "/* a\nb */DELETE FROM t WHERE true;",
+			statements: []string{"DELETE FROM t WHERE true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, rest := SplitStatements(tt.content)
+			if !reflect.DeepEqual(statements, tt.statements) {
+				t.Errorf("SplitStatements() statements = %q, expected %q", statements, tt.statements)
+			}
+			if rest != tt.rest {
+				t.Errorf("SplitStatements() rest = %q, expected %q", rest, tt.rest)
+			}
+		})
+	}
+}
+
+func TestRemoveComments_Literals(t *testing.T) {
+	content := "INSERT INTO t (s) VALUES ('-- not a comment'); -- comment\n/* block\ncomment */"
+	expected := "INSERT INTO t (s) VALUES ('-- not a comment'); \n\n"
+
+	if result := removeComments(content); result != expected {
+		t.Errorf("removeComments() = %q, expected %q", result, expected)
+	}
+}
+
+func TestParamNames(t *testing.T) {
+	tests := []struct {
+		stmt     string
+		expected []string
+	}{
+		{"INSERT INTO t (id, data) VALUES (@id, @data)", []string{"id", "data"}},
+		{"UPDATE t SET a = @v, b = @v WHERE id = @id", []string{"v", "id"}},
+		{"INSERT INTO t (s) VALUES ('@not_a_param') -- @comment", nil},
+		{"SELECT * FROM t@{FORCE_INDEX=idx} WHERE id = @id", []string{"id"}},
+		{"SELECT @@sys_var, @p1", []string{"p1"}},
+		{"DELETE FROM t WHERE true", nil},
+	}
+
+	for _, tt := range tests {
+		if result := ParamNames(tt.stmt); !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("ParamNames(%q) = %q, expected %q", tt.stmt, result, tt.expected)
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	stmt := "CREATE TABLE `Order` ( -- orders\n  id INT64 DEFAULT (b'x'),\n) PRIMARY KEY (id)"
+	expected := []Token{
+		{"CREATE", 0}, {"TABLE", 7}, {"`Order`", 13}, {"(", 21},
+		{"id", 35}, {"INT64", 38}, {"DEFAULT", 44}, {"(", 52}, {"b'x'", 53}, {")", 57}, {",", 58},
+		{")", 60}, {"PRIMARY", 62}, {"KEY", 70}, {"(", 74}, {"id", 75}, {")", 77},
+	}
+	if result := Tokens(stmt); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Tokens() = %v, expected %v", result, expected)
+	}
+}
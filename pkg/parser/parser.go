@@ -2,17 +2,39 @@ package parser
 
 import (
 	"fmt"
-	"os"
+	"io/fs"
 	"strings"
 )
 
+// ParseDMLFile parses the DML statements of a file, expanding includes (see
+// ParseFile).
 func ParseDMLFile(filePath string) ([]string, error) {
-	content, err := os.ReadFile(filePath)
+	statements, err := ParseFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+		return nil, err
 	}
 
-	return ParseDMLContent(string(content))
+	return SQL(statements), nil
+}
+
+// ParseDMLFS parses the DML files in fsys matching pattern (see fs.Glob).
+// Statements from all matching files are returned in lexical file order.
+func ParseDMLFS(fsys fs.FS, pattern string) ([]string, error) {
+	statements, err := ParseFS(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return SQL(statements), nil
+}
+
+// SQL returns the SQL text of statements.
+func SQL(statements []Statement) []string {
+	result := make([]string, len(statements))
+	for i, stmt := range statements {
+		result[i] = stmt.SQL
+	}
+	return result
 }
 
 func ParseDMLContent(content string) ([]string, error) {
@@ -27,12 +49,12 @@ func ParseDMLContent(content string) ([]string, error) {
 			continue
 		}
 
-		if !isValidDMLStatement(stmt) {
+		if _, err := ParseDML(stmt); err != nil {
 			limit := 50
 			if len(stmt) < limit {
 				limit = len(stmt)
 			}
-			return nil, fmt.Errorf("invalid DML statement: %s", stmt[:limit])
+			return nil, fmt.Errorf("invalid DML statement: %s: %w", stmt[:limit], err)
 		}
 
 		validStatements = append(validStatements, stmt)
@@ -42,47 +64,23 @@ func ParseDMLContent(content string) ([]string, error) {
 }
 
 func removeComments(content string) string {
-	lines := strings.Split(content, "\n")
-	var result []string
-
-	for _, line := range lines {
-		if idx := strings.Index(line, "--"); idx != -1 {
-			line = line[:idx]
+	var result strings.Builder
+	for _, seg := range segments(content) {
+		if seg.kind == segmentComment {
+			result.WriteString(commentPlaceholder(seg.text))
+			continue
 		}
-		result = append(result, line)
+		result.WriteString(seg.text)
 	}
 
-	return strings.Join(result, "\n")
+	return result.String()
 }
 
 func splitStatements(content string) []string {
-	statements := strings.Split(content, ";")
-
-	var result []string
-	for _, stmt := range statements {
-		stmt = strings.TrimSpace(stmt)
-		if stmt != "" {
-			result = append(result, stmt)
-		}
-	}
-
-	return result
-}
-
-func isValidDMLStatement(stmt string) bool {
-	stmt = strings.TrimSpace(strings.ToUpper(stmt))
-
-	validPrefixes := []string{
-		"INSERT",
-		"UPDATE",
-		"DELETE",
-	}
-
-	for _, prefix := range validPrefixes {
-		if strings.HasPrefix(stmt, prefix) {
-			return true
-		}
+	statements, rest := SplitStatements(content)
+	if rest = strings.TrimSpace(rest); rest != "" {
+		statements = append(statements, rest)
 	}
 
-	return false
+	return statements
 }
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"testing/fstest"
 )
 
 func TestParseDMLContent(t *testing.T) {
@@ -124,6 +125,74 @@ func TestParseDMLFileNotExist(t *testing.T) {
 	}
 }
 
+func TestParseDMLFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"fixtures/02_posts.sql": {Data: []byte(`INSERT INTO posts (id, user_id) VALUES (1, 1);`)},
+		"fixtures/01_users.sql": {Data: []byte(`-- users
+INSERT INTO users (id, name) VALUES (1, 'John');`)},
+		"fixtures/readme.txt": {Data: []byte(`not sql`)},
+		"broken/bad.sql":      {Data: []byte(`SELECT * FROM users;`)},
+	}
+
+	tests := []struct {
+		name     string
+		pattern  string
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "single file",
+			pattern:  "fixtures/01_users.sql",
+			expected: []string{"INSERT INTO users (id, name) VALUES (1, 'John')"},
+		},
+		{
+			name:    "glob in lexical order",
+			pattern: "fixtures/*.sql",
+			expected: []string{
+				"INSERT INTO users (id, name) VALUES (1, 'John')",
+				"INSERT INTO posts (id, user_id) VALUES (1, 1)",
+			},
+		},
+		{
+			name:    "no matches",
+			pattern: "missing/*.sql",
+			wantErr: true,
+		},
+		{
+			name:    "invalid pattern",
+			pattern: "fixtures/[",
+			wantErr: true,
+		},
+		{
+			name:    "invalid statement",
+			pattern: "broken/*.sql",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseDMLFS(fsys, tt.pattern)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDMLFS() expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("ParseDMLFS() unexpected error: %v", err)
+				return
+			}
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseDMLFS() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestRemoveComments(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -200,37 +269,6 @@ func TestSplitStatements(t *testing.T) {
 	}
 }
 
-func TestIsValidDMLStatement(t *testing.T) {
-	tests := []struct {
-		name      string
-		statement string
-		expected  bool
-	}{
-		{"INSERT uppercase", "INSERT INTO users VALUES (1)", true},
-		{"INSERT lowercase", "insert into users values (1)", true},
-		{"INSERT mixed case", "Insert Into users Values (1)", true},
-		{"UPDATE uppercase", "UPDATE users SET name = 'test'", true},
-		{"UPDATE lowercase", "update users set name = 'test'", true},
-		{"DELETE uppercase", "DELETE FROM users WHERE id = 1", true},
-		{"DELETE lowercase", "delete from users where id = 1", true},
-		{"SELECT statement", "SELECT * FROM users", false},
-		{"CREATE statement", "CREATE TABLE users (id INT)", false},
-		{"DROP statement", "DROP TABLE users", false},
-		{"Empty statement", "", false},
-		{"Whitespace only", "   ", false},
-		{"Invalid prefix", "INVALID STATEMENT", false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := isValidDMLStatement(tt.statement)
-			if result != tt.expected {
-				t.Errorf("isValidDMLStatement(%q) = %v, expected %v", tt.statement, result, tt.expected)
-			}
-		})
-	}
-}
-
 func TestMin(t *testing.T) {
 	tests := []struct {
 		name     string
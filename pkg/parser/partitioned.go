@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CheckPartitioned reports why stmt cannot be executed as partitioned DML,
+// or nil if it can. Partitioned DML runs UPDATE and DELETE statements
+// outside a transaction, one partition at a time, and may apply a statement
+// to a row more than once. Statements must therefore be idempotent: an
+// assignment such as SET count = count + 1 is rejected. Subqueries and
+// THEN RETURN are rejected as well, since partitioned DML does not support
+// them.
+func CheckPartitioned(stmt string) error {
+	return GoogleSQL.CheckPartitioned(stmt)
+}
+
+// CheckPartitioned checks a statement of the dialect like the package-level
+// CheckPartitioned.
+func (d Dialect) CheckPartitioned(stmt string) error {
+	parsed, err := d.ParseDML(stmt)
+	if err != nil {
+		return err
+	}
+
+	var exprs []Expr
+	var ret *Return
+	switch s := parsed.(type) {
+	case *Update:
+		for _, a := range s.Assignments {
+			exprs = append(exprs, a.Value)
+		}
+		if s.Where != nil {
+			exprs = append(exprs, *s.Where)
+		}
+		ret = s.Return
+	case *Delete:
+		if s.Where != nil {
+			exprs = append(exprs, *s.Where)
+		}
+		ret = s.Return
+	default:
+		return errors.New("partitioned DML supports only UPDATE and DELETE statements, not INSERT")
+	}
+
+	if ret != nil {
+		if d == PostgreSQL {
+			return errors.New("partitioned DML does not support RETURNING")
+		}
+		return errors.New("partitioned DML does not support THEN RETURN")
+	}
+	for _, expr := range exprs {
+		if expr.hasKeyword("SELECT") {
+			return errors.New("partitioned DML does not support subqueries")
+		}
+	}
+
+	if upd, ok := parsed.(*Update); ok {
+		return checkIdempotentAssignments(upd.Assignments)
+	}
+	return nil
+}
+
+// checkIdempotentAssignments rejects SET assignments whose value refers to
+// the assigned column, since applying them twice gives a different result.
+func checkIdempotentAssignments(assignments []Assignment) error {
+	for _, a := range assignments {
+		if a.Value.refersTo(a.Column) {
+			return fmt.Errorf("statement is not idempotent: %s is assigned a value computed from itself, which partitioned DML may apply more than once", strings.ToLower(a.Column))
+		}
+	}
+	return nil
+}
+
+// hasKeyword reports whether the expression contains the keyword, e.g.
+// SELECT in a subquery.
+func (e Expr) hasKeyword(word string) bool {
+	for _, t := range e.tokens {
+		if t.kind == tokenWord && strings.EqualFold(t.text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// refersTo reports whether the expression refers to the column, by itself
+// or as the last part of a qualified name such as u.name. Parameters and
+// functions of the same name do not refer to the column.
+func (e Expr) refersTo(column string) bool {
+	for i, t := range e.tokens {
+		var name string
+		switch t.kind {
+		case tokenWord:
+			name = t.text
+		case tokenQuotedName:
+			name = t.text[1 : len(t.text)-1]
+		default:
+			continue
+		}
+		if i > 0 && e.tokens[i-1].kind == tokenPunct && e.tokens[i-1].text == "@" {
+			continue
+		}
+		if i+1 < len(e.tokens) && e.tokens[i+1].kind == tokenPunct && e.tokens[i+1].text == "(" {
+			continue
+		}
+		if strings.EqualFold(name, column) {
+			return true
+		}
+	}
+	return false
+}
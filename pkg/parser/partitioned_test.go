@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckPartitioned(t *testing.T) {
+	tests := []struct {
+		name    string
+		stmt    string
+		message string
+	}{
+		{name: "delete all rows", stmt: "DELETE FROM users WHERE true"},
+		{name: "update to a constant", stmt: "UPDATE users SET status = 'archived', updated_at = PENDING_COMMIT_TIMESTAMP() WHERE created_at < '2020-01-01'"},
+		{name: "update from another column", stmt: "UPDATE users SET display_name = UPPER(name) WHERE true"},
+		{name: "update to a parameter of the same name", stmt: "UPDATE users SET name = @name WHERE id > 0"},
+		{name: "column in literal", stmt: "UPDATE users SET name = 'name' WHERE true"},
+		{name: "hinted delete", stmt: "@{PDML_MAX_PARALLELISM=2} DELETE FROM users WHERE true"},
+		{name: "hinted update", stmt: "@{PDML_MAX_PARALLELISM=2} UPDATE users SET status = 'archived' WHERE true"},
+		{name: "function of the same name", stmt: "UPDATE users SET upper = UPPER(name) WHERE true"},
+		{
+			name:    "hinted increment",
+			stmt:    "@{PDML_MAX_PARALLELISM=2} UPDATE users SET visits = visits + 1 WHERE true",
+			message: "visits is assigned a value computed from itself",
+		},
+		{
+			name:    "hinted insert",
+			stmt:    "@{PDML_MAX_PARALLELISM=2} INSERT INTO users (id) VALUES (1)",
+			message: "supports only UPDATE and DELETE statements, not INSERT",
+		},
+		{
+			name:    "not DML",
+			stmt:    "SELECT * FROM users",
+			message: "expected INSERT, UPDATE or DELETE",
+		},
+		{
+			name:    "insert",
+			stmt:    "INSERT INTO users (id) VALUES (1)",
+			message: "supports only UPDATE and DELETE statements, not INSERT",
+		},
+		{
+			name:    "increment",
+			stmt:    "UPDATE users SET visits = visits + 1 WHERE true",
+			message: "visits is assigned a value computed from itself",
+		},
+		{
+			name:    "qualified increment",
+			stmt:    "UPDATE users u SET u.visits = 1, `Balance` = IF(u.balance > 0, balance - 1, 0) WHERE true",
+			message: "balance is assigned a value computed from itself",
+		},
+		{
+			name:    "subquery",
+			stmt:    "DELETE FROM posts WHERE user_id IN (SELECT id FROM users WHERE deleted)",
+			message: "does not support subqueries",
+		},
+		{
+			name:    "subquery in assignment",
+			stmt:    "UPDATE users SET score = (SELECT MAX(score) FROM scores) WHERE true",
+			message: "does not support subqueries",
+		},
+		{
+			name:    "then return",
+			stmt:    "DELETE FROM users WHERE true THEN RETURN id",
+			message: "does not support THEN RETURN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckPartitioned(tt.stmt)
+			if tt.message == "" {
+				if err != nil {
+					t.Errorf("CheckPartitioned() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("CheckPartitioned() error = %v, expected it to contain %q", err, tt.message)
+			}
+		})
+	}
+}
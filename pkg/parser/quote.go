@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// QuoteString returns s as a GoogleSQL string literal. Quotes, backslashes
+// and control characters are escaped, so the result can be embedded in a
+// statement without terminating the literal early.
+func QuoteString(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04x`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// QuoteBytes returns b as a GoogleSQL bytes literal.
+func QuoteBytes(b []byte) string {
+	var sb strings.Builder
+	sb.Grow(len(b)*4 + 3)
+	sb.WriteString("b'")
+	for _, c := range b {
+		fmt.Fprintf(&sb, `\x%02x`, c)
+	}
+	sb.WriteByte('\'')
+	return sb.String()
+}
+
+// QuoteIdentifier returns name as a GoogleSQL identifier, quoted with
+// backticks if it is a reserved word or not a plain identifier. Qualified
+// names such as sch.Users are quoted part by part.
+func QuoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if !isPlainName(part) || IsReserved(part) {
+			parts[i] = "`" + strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(part) + "`"
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// isPlainName reports whether s can be used as a name without quoting it,
+// apart from reserved words.
+func isPlainName(s string) bool {
+	if s == "" || isDigit(s[0]) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isParamChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Unquote returns the contents of a GoogleSQL string or bytes literal,
+// including raw (r'...') and triple-quoted literals, with escape sequences
+// resolved.
+func Unquote(literal string) (string, error) {
+	quoteAt := strings.IndexAny(literal, `'"`)
+	if quoteAt == -1 || quoteAt > 0 && !isLiteralPrefix(literal[:quoteAt]) {
+		return "", fmt.Errorf("invalid literal %s", literal)
+	}
+	prefix := strings.ToLower(literal[:quoteAt])
+	body := literal[quoteAt:]
+
+	delim := body[:1]
+	if len(body) >= 6 && strings.HasPrefix(body, strings.Repeat(delim, 3)) {
+		delim = strings.Repeat(delim, 3)
+	}
+	if len(body) < 2*len(delim) || !strings.HasSuffix(body, delim) {
+		return "", fmt.Errorf("unterminated literal %s", literal)
+	}
+	body = body[len(delim) : len(body)-len(delim)]
+
+	if strings.Contains(prefix, "r") {
+		return body, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' {
+			b.WriteByte(body[i])
+			continue
+		}
+		i++
+		if i == len(body) {
+			return "", fmt.Errorf("invalid escape at end of literal %s", literal)
+		}
+
+		switch c := body[i]; c {
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case '\\', '?', '"', '\'', '`':
+			b.WriteByte(c)
+		case 'x', 'X', 'u', 'U':
+			digits := map[byte]int{'x': 2, 'X': 2, 'u': 4, 'U': 8}[c]
+			if i+digits >= len(body) {
+				return "", fmt.Errorf("invalid escape \\%c in literal %s", c, literal)
+			}
+			n, err := strconv.ParseUint(body[i+1:i+1+digits], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape \\%s in literal %s", body[i:i+1+digits], literal)
+			}
+			if c == 'x' || c == 'X' {
+				b.WriteByte(byte(n))
+			} else {
+				if !utf8.ValidRune(rune(n)) {
+					return "", fmt.Errorf("invalid code point \\%s in literal %s", body[i:i+1+digits], literal)
+				}
+				b.WriteRune(rune(n))
+			}
+			i += digits
+		case '0', '1', '2', '3':
+			if i+3 > len(body) {
+				return "", fmt.Errorf("invalid octal escape in literal %s", literal)
+			}
+			n, err := strconv.ParseUint(body[i:i+3], 8, 8)
+			if err != nil {
+				return "", fmt.Errorf("invalid octal escape \\%s in literal %s", body[i:i+3], literal)
+			}
+			b.WriteByte(byte(n))
+			i += 2
+		default:
+			return "", fmt.Errorf("invalid escape \\%c in literal %s", c, literal)
+		}
+	}
+
+	return b.String(), nil
+}
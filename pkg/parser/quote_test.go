@@ -0,0 +1,106 @@
+package parser
+
+import "testing"
+
+func TestQuoteString(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"plain", "John", `'John'`},
+		{"empty", "", `''`},
+		{"single quote", "it's", `'it\'s'`},
+		{"backslash", `a\b`, `'a\\b'`},
+		{"backslash before quote", `\'; DELETE FROM users; --`, `'\\\'; DELETE FROM users; --'`},
+		{"newline and tab", "a\nb\tc", `'a\nb\tc'`},
+		{"control character", "a\x00b", `'a\u0000b'`},
+		{"unicode", "日本", `'日本'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := QuoteString(tt.value)
+			if result != tt.expected {
+				t.Errorf("QuoteString(%q) = %s, expected %s", tt.value, result, tt.expected)
+			}
+
+			// The quoted value must be a single literal
+			statements, rest := SplitStatements("INSERT INTO t (s) VALUES (" + result + ");")
+			if len(statements) != 1 || rest != "" {
+				t.Errorf("QuoteString(%q) does not form a single literal: %q", tt.value, statements)
+			}
+		})
+	}
+}
+
+func TestQuoteBytes(t *testing.T) {
+	if result := QuoteBytes([]byte{0x00, 'a', 0xff}); result != `b'\x00\x61\xff'` {
+		t.Errorf("QuoteBytes() = %s, expected %s", result, `b'\x00\x61\xff'`)
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Users", "Users"},
+		{"user_id2", "user_id2"},
+		{"Order", "`Order`"},
+		{"group", "`group`"},
+		{"2fa", "`2fa`"},
+		{"first-name", "`first-name`"},
+		{"a`b", "`a\\`b`"},
+		{"sch.Order", "sch.`Order`"},
+	}
+
+	for _, tt := range tests {
+		if result := QuoteIdentifier(tt.name); result != tt.expected {
+			t.Errorf("QuoteIdentifier(%q) = %s, expected %s", tt.name, result, tt.expected)
+		}
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	tests := []struct {
+		literal  string
+		expected string
+		wantErr  bool
+	}{
+		{literal: `'plain'`, expected: "plain"},
+		{literal: `"it's"`, expected: "it's"},
+		{literal: `'a\'b\\c\nd\te'`, expected: "a'b\\c\nd\te"},
+		{literal: `'\x41\u00e9\U0001F600\101'`, expected: "Aé😀A"},
+		{literal: `r'C:\path\n'`, expected: `C:\path\n`},
+		{literal: `b'\x00\xff'`, expected: "\x00\xff"},
+		{literal: `"""multi 'quoted'` + "\n" + `line"""`, expected: "multi 'quoted'\nline"},
+		{literal: `'''a'''`, expected: "a"},
+		{literal: `''`, expected: ""},
+		{literal: `'\q'`, wantErr: true},
+		{literal: `'\x4'`, wantErr: true},
+		{literal: `'unterminated`, wantErr: true},
+		{literal: `x'abc'`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		result, err := Unquote(tt.literal)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unquote(%s) error = %v, wantErr %v", tt.literal, err, tt.wantErr)
+			continue
+		}
+		if result != tt.expected {
+			t.Errorf("Unquote(%s) = %q, expected %q", tt.literal, result, tt.expected)
+		}
+	}
+
+	// Quoted strings read back unchanged
+	for _, s := range []string{"it's", `back\slash`, "tab\tnew\nline", "\x01ctl"} {
+		if result, err := Unquote(QuoteString(s)); err != nil || result != s {
+			t.Errorf("Unquote(QuoteString(%q)) = %q, %v", s, result, err)
+		}
+	}
+	if result, err := Unquote(QuoteBytes([]byte{0, 1, 0xff})); err != nil || result != "\x00\x01\xff" {
+		t.Errorf("Unquote(QuoteBytes()) = %q, %v", result, err)
+	}
+}
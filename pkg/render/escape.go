@@ -0,0 +1,379 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/nu0ma/spemu/pkg/parser"
+)
+
+// state is a lexical position in SQL text.
+type state int
+
+const (
+	stateCode state = iota
+	stateSingleQuote
+	stateDoubleQuote
+	stateTripleSingleQuote
+	stateTripleDoubleQuote
+	stateIdentifier
+	stateLineComment
+	stateBlockComment
+)
+
+// sqlContext is the SQL context a template action appears in.
+type sqlContext struct {
+	state state
+	// raw is set inside raw string literals such as r'...', where escape
+	// sequences are not interpreted.
+	raw bool
+}
+
+func (c sqlContext) String() string {
+	switch c.state {
+	case stateSingleQuote, stateDoubleQuote, stateTripleSingleQuote, stateTripleDoubleQuote:
+		if c.raw {
+			return "raw string literal"
+		}
+		return "string literal"
+	case stateIdentifier:
+		return "quoted identifier"
+	case stateLineComment, stateBlockComment:
+		return "comment"
+	}
+	return "SQL"
+}
+
+const (
+	escapeLiteral      = "_sql_literal"
+	escapeString       = "_sql_string"
+	escapeIdentifier   = "_sql_identifier"
+	escapeLineComment  = "_sql_line_comment"
+	escapeBlockComment = "_sql_block_comment"
+)
+
+var escapers = template.FuncMap{
+	escapeLiteral:      literal,
+	escapeString:       stringContent,
+	escapeIdentifier:   identifierContent,
+	escapeLineComment:  lineCommentContent,
+	escapeBlockComment: blockCommentContent,
+}
+
+// escape appends the escaper for its SQL context to every action in tmpl and
+// its associated templates.
+func escape(tmpl *template.Template) error {
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+
+		e := &escaper{tree: t.Tree}
+		end, err := e.list(t.Tree.Root, sqlContext{})
+		if err != nil {
+			return err
+		}
+		// Templates included with {{template}} are called from SQL context and
+		// must return to it
+		if t.Name() != tmpl.Name() && end != (sqlContext{}) {
+			return fmt.Errorf("template %s ends inside a %s", t.Name(), end)
+		}
+	}
+
+	return nil
+}
+
+type escaper struct {
+	tree *parse.Tree
+}
+
+func (e *escaper) errorf(node parse.Node, format string, args ...interface{}) error {
+	location, _ := e.tree.ErrorContext(node)
+	return fmt.Errorf("%s: %s", location, fmt.Sprintf(format, args...))
+}
+
+func (e *escaper) list(list *parse.ListNode, c sqlContext) (sqlContext, error) {
+	if list == nil {
+		return c, nil
+	}
+
+	for _, node := range list.Nodes {
+		var err error
+		if c, err = e.node(node, c); err != nil {
+			return c, err
+		}
+	}
+
+	return c, nil
+}
+
+func (e *escaper) node(node parse.Node, c sqlContext) (sqlContext, error) {
+	switch n := node.(type) {
+	case *parse.TextNode:
+		next, ok := advance(c, n.Text)
+		if !ok {
+			return c, e.errorf(n, "template action follows a backslash escape")
+		}
+		return next, nil
+	case *parse.ActionNode:
+		// Variable declarations produce no output
+		if len(n.Pipe.Decl) > 0 {
+			return c, nil
+		}
+		name, err := escaperFor(c)
+		if err != nil {
+			return c, e.errorf(n, "%v", err)
+		}
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      n.Pos,
+			Args:     []parse.Node{parse.NewIdentifier(name).SetTree(e.tree).SetPos(n.Pos)},
+		})
+		return c, nil
+	case *parse.IfNode:
+		return e.branch(&n.BranchNode, c, false)
+	case *parse.WithNode:
+		return e.branch(&n.BranchNode, c, false)
+	case *parse.RangeNode:
+		return e.branch(&n.BranchNode, c, true)
+	case *parse.TemplateNode:
+		if c != (sqlContext{}) {
+			return c, e.errorf(n, "template %s is called inside a %s", n.Name, c)
+		}
+		return c, nil
+	}
+
+	return c, nil
+}
+
+// branch escapes the bodies of if, with and range. Every way through the
+// branch must end in the same context, and range bodies must end in the
+// context they start in since they may run repeatedly.
+func (e *escaper) branch(n *parse.BranchNode, c sqlContext, loop bool) (sqlContext, error) {
+	body, err := e.list(n.List, c)
+	if err != nil {
+		return c, err
+	}
+	if loop && body != c {
+		return c, e.errorf(n, "range body starts in %s and ends inside a %s", c, body)
+	}
+
+	other, err := e.list(n.ElseList, c)
+	if err != nil {
+		return c, err
+	}
+	if body != other {
+		return c, e.errorf(n, "branches end in different contexts: %s and %s", body, other)
+	}
+
+	return body, nil
+}
+
+func escaperFor(c sqlContext) (string, error) {
+	switch c.state {
+	case stateSingleQuote, stateDoubleQuote, stateTripleSingleQuote, stateTripleDoubleQuote:
+		if c.raw {
+			return "", fmt.Errorf("values cannot be substituted into a raw string literal")
+		}
+		return escapeString, nil
+	case stateIdentifier:
+		return escapeIdentifier, nil
+	case stateLineComment:
+		return escapeLineComment, nil
+	case stateBlockComment:
+		return escapeBlockComment, nil
+	}
+
+	return escapeLiteral, nil
+}
+
+// advance returns the context at the end of text when it starts in c. It
+// reports false if text ends with an unfinished backslash escape.
+func advance(c sqlContext, text []byte) (sqlContext, bool) {
+	for i := 0; i < len(text); i++ {
+		rest := text[i:]
+		switch c.state {
+		case stateCode:
+			switch {
+			case hasPrefix(rest, "--") || rest[0] == '#':
+				c.state = stateLineComment
+			case hasPrefix(rest, "/*"):
+				c.state = stateBlockComment
+				i++
+			case hasPrefix(rest, "'''"):
+				c = sqlContext{state: stateTripleSingleQuote, raw: isRawPrefix(text[:i])}
+				i += 2
+			case hasPrefix(rest, `"""`):
+				c = sqlContext{state: stateTripleDoubleQuote, raw: isRawPrefix(text[:i])}
+				i += 2
+			case rest[0] == '\'':
+				c = sqlContext{state: stateSingleQuote, raw: isRawPrefix(text[:i])}
+			case rest[0] == '"':
+				c = sqlContext{state: stateDoubleQuote, raw: isRawPrefix(text[:i])}
+			case rest[0] == '`':
+				c.state = stateIdentifier
+			}
+		case stateLineComment:
+			if rest[0] == '\n' {
+				c.state = stateCode
+			}
+		case stateBlockComment:
+			if hasPrefix(rest, "*/") {
+				c.state = stateCode
+				i++
+			}
+		default:
+			if rest[0] == '\\' {
+				if i+1 == len(text) {
+					return c, false
+				}
+				i++
+				continue
+			}
+			if end := closingQuote(c.state); hasPrefix(rest, end) {
+				i += len(end) - 1
+				c = sqlContext{}
+			}
+		}
+	}
+
+	return c, true
+}
+
+func closingQuote(s state) string {
+	switch s {
+	case stateSingleQuote:
+		return "'"
+	case stateDoubleQuote:
+		return `"`
+	case stateTripleSingleQuote:
+		return "'''"
+	case stateTripleDoubleQuote:
+		return `"""`
+	}
+	return "`"
+}
+
+// isRawPrefix reports whether the text before a quote ends with a raw string
+// prefix such as r, R, rb or br.
+func isRawPrefix(before []byte) bool {
+	end := len(before)
+	start := end
+	for start > 0 && start > end-2 && strings.ContainsRune("rRbB", rune(before[start-1])) {
+		start--
+	}
+	if start > 0 && isIdentifierChar(before[start-1]) {
+		return false
+	}
+	return strings.ContainsAny(string(before[start:end]), "rR")
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func hasPrefix(b []byte, prefix string) bool {
+	return len(b) >= len(prefix) && string(b[:len(prefix)]) == prefix
+}
+
+// literal writes v as a SQL literal. Strings are always quoted, even if they
+// look like numbers, e.g. --var zip=007; only Go numbers, e.g. from seq or
+// int, are written as numeric literals. Negative numbers are parenthesized,
+// so that a minus sign before the action, as in 1-{{.n}}, cannot turn into a
+// -- comment.
+func literal(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return parser.QuoteString(value), nil
+	case bool:
+		return strings.ToUpper(strconv.FormatBool(value)), nil
+	case []byte:
+		return parser.QuoteBytes(value), nil
+	case time.Time:
+		return "TIMESTAMP " + parser.QuoteString(value.UTC().Format(time.RFC3339Nano)), nil
+	case float32:
+		return floatLiteral(float64(value)), nil
+	case float64:
+		return floatLiteral(value), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return signed(strconv.FormatInt(rv.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Slice, reflect.Array:
+		elems := make([]string, rv.Len())
+		for i := range elems {
+			elem, err := literal(rv.Index(i).Interface())
+			if err != nil {
+				return "", err
+			}
+			elems[i] = elem
+		}
+		return "[" + strings.Join(elems, ", ") + "]", nil
+	}
+
+	return parser.QuoteString(text(v)), nil
+}
+
+func floatLiteral(f float64) string {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "CAST(" + parser.QuoteString(strconv.FormatFloat(f, 'g', -1, 64)) + " AS FLOAT64)"
+	}
+	return signed(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// signed parenthesizes a negative number.
+func signed(number string) string {
+	if strings.HasPrefix(number, "-") {
+		return "(" + number + ")"
+	}
+	return number
+}
+
+// text returns the textual form of v used inside literals and comments.
+func text(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	case time.Time:
+		return value.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
+
+// stringContent escapes v for use inside a single or double quoted string
+// literal.
+func stringContent(v interface{}) string {
+	quoted := parser.QuoteString(text(v))
+	return strings.ReplaceAll(quoted[1:len(quoted)-1], `"`, `\"`)
+}
+
+// identifierContent escapes v for use inside a backtick-quoted identifier.
+func identifierContent(v interface{}) string {
+	return strings.NewReplacer(`\`, `\\`, "`", "\\`", "\n", `\n`, "\r", `\r`).Replace(text(v))
+}
+
+// lineCommentContent keeps v on the comment's line.
+func lineCommentContent(v interface{}) string {
+	return strings.NewReplacer("\n", " ", "\r", " ").Replace(text(v))
+}
+
+// blockCommentContent keeps v from closing the comment.
+func blockCommentContent(v interface{}) string {
+	return strings.ReplaceAll(text(v), "*/", "* /")
+}
@@ -0,0 +1,137 @@
+// Package render expands templated seed files before they are parsed.
+//
+// Templates use Go text/template syntax. The output of every action is
+// escaped for the SQL context it appears in: inside a string literal the
+// value is escaped as literal content, elsewhere it is written as a SQL
+// literal, so substituted values cannot change the structure of a statement.
+package render
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Render expands the template content with vars available as {{.name}}.
+// name is used in error messages.
+func Render(name, content string, vars map[string]string) (string, error) {
+	if vars == nil {
+		vars = map[string]string{}
+	}
+
+	tmpl, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(funcs(time.Now())).
+		Funcs(escapers).
+		Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	if err := escape(tmpl); err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+
+	return b.String(), nil
+}
+
+// IsTemplate reports whether a file name marks a template, e.g. seed.sql.tmpl.
+func IsTemplate(name string) bool {
+	return strings.HasSuffix(name, ".tmpl")
+}
+
+// ParseVar parses a variable definition of the form key=value.
+func ParseVar(def string) (string, string, error) {
+	key, value, ok := strings.Cut(def, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("invalid variable %q: expected key=value", def)
+	}
+
+	return key, value, nil
+}
+
+// funcs returns the helper functions available to templates. now is fixed
+// for a single rendering so that all rows share the same timestamp.
+func funcs(now time.Time) template.FuncMap {
+	return template.FuncMap{
+		"env": os.Getenv,
+		"now": func() time.Time {
+			return now
+		},
+		"uuid": newUUID,
+		"seq":  seq,
+		"add": func(a, b int) int {
+			return a + b
+		},
+		"int":   parseInt,
+		"float": parseFloat,
+	}
+}
+
+// parseInt converts a string such as a --var to an integer, which is
+// written as a numeric literal rather than a string.
+func parseInt(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("int: %q is not an integer", s)
+	}
+	return n, nil
+}
+
+// parseFloat converts a string such as a --var to a floating point number,
+// which is written as a numeric literal rather than a string.
+func parseFloat(s string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("float: %q is not a number", s)
+	}
+	return f, nil
+}
+
+// maxSeq bounds the number of values seq yields, so that a mistyped bound
+// does not exhaust memory.
+const maxSeq = 1000000
+
+// seq returns the integers 1..n for seq n, or start..end for seq start end.
+func seq(bounds ...int) ([]int, error) {
+	var start, end int
+	switch len(bounds) {
+	case 1:
+		start, end = 1, bounds[0]
+	case 2:
+		start, end = bounds[0], bounds[1]
+	default:
+		return nil, fmt.Errorf("seq expects 1 or 2 arguments, got %d", len(bounds))
+	}
+	if end >= start && int64(end)-int64(start) >= maxSeq {
+		return nil, fmt.Errorf("seq %d %d yields more than %d values", start, end, maxSeq)
+	}
+
+	var result []int
+	for i := start; i <= end; i++ {
+		result = append(result, i)
+	}
+	return result, nil
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
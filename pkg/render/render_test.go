@@ -0,0 +1,191 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nu0ma/spemu/pkg/parser"
+)
+
+func TestRender(t *testing.T) {
+	t.Setenv("SPEMU_TEST_REGION", "tokyo")
+
+	tests := []struct {
+		name     string
+		content  string
+		vars     map[string]string
+		expected string
+	}{
+		{
+			name:     "string literal context",
+			content:  "INSERT INTO tenants (id, name) VALUES ({{int .id}}, '{{.name}}');",
+			vars:     map[string]string{"id": "42", "name": "Acme"},
+			expected: "INSERT INTO tenants (id, name) VALUES (42, 'Acme');",
+		},
+		{
+			name:     "quotes are escaped inside literals",
+			content:  "INSERT INTO users (name) VALUES ('{{.name}}');",
+			vars:     map[string]string{"name": "O'Brien'); DELETE FROM users; --"},
+			expected: `INSERT INTO users (name) VALUES ('O\'Brien\'); DELETE FROM users; --');`,
+		},
+		{
+			name:     "strings outside literals are quoted",
+			content:  "INSERT INTO users (name) VALUES ({{.name}});",
+			vars:     map[string]string{"name": "x'); DELETE FROM users; --"},
+			expected: `INSERT INTO users (name) VALUES ('x\'); DELETE FROM users; --');`,
+		},
+		{
+			name:     "double quoted literal",
+			content:  `INSERT INTO users (name) VALUES ("{{.name}}");`,
+			vars:     map[string]string{"name": `say "hi"`},
+			expected: `INSERT INTO users (name) VALUES ("say \"hi\"");`,
+		},
+		{
+			name:     "quoted identifier",
+			content:  "INSERT INTO `{{.table}}` (id) VALUES (1);",
+			vars:     map[string]string{"table": "users`; DROP"},
+			expected: "INSERT INTO `users\\`; DROP` (id) VALUES (1);",
+		},
+		{
+			name:     "negative numbers cannot start a comment",
+			content:  "UPDATE t SET a = -{{int .n}}, b = 1-{{float .n}} WHERE id = {{int .id}};",
+			vars:     map[string]string{"n": "-1", "id": "2"},
+			expected: "UPDATE t SET a = -(-1), b = 1-(-1) WHERE id = 2;",
+		},
+		{
+			name:     "strings that look like numbers stay strings",
+			content:  "INSERT INTO t (zip, phone, score) VALUES ({{.zip}}, {{.phone}}, {{float .score}});",
+			vars:     map[string]string{"zip": "007", "phone": "0312345678", "score": "1.5"},
+			expected: "INSERT INTO t (zip, phone, score) VALUES ('007', '0312345678', 1.5);",
+		},
+		{
+			name:     "line comment",
+			content:  "-- tenant {{.name}}\nINSERT INTO t (id) VALUES (1);",
+			vars:     map[string]string{"name": "a\nDELETE FROM t"},
+			expected: "-- tenant a DELETE FROM t\nINSERT INTO t (id) VALUES (1);",
+		},
+		{
+			name:     "escaped quote inside literal",
+			content:  `INSERT INTO t (s) VALUES ('it\'s {{.v}}');`,
+			vars:     map[string]string{"v": "ok"},
+			expected: `INSERT INTO t (s) VALUES ('it\'s ok');`,
+		},
+		{
+			name:     "range over seq",
+			content:  "{{range $i := seq 3}}INSERT INTO users (id, name) VALUES ({{$i}}, 'user{{$i}}');\n{{end}}",
+			expected: "INSERT INTO users (id, name) VALUES (1, 'user1');\nINSERT INTO users (id, name) VALUES (2, 'user2');\nINSERT INTO users (id, name) VALUES (3, 'user3');\n",
+		},
+		{
+			name:     "seq with bounds and add",
+			content:  "{{range $i := seq 5 6}}({{add $i 100}}){{end}}",
+			expected: "(105)(106)",
+		},
+		{
+			name:     "env",
+			content:  "INSERT INTO t (region) VALUES ('{{env \"SPEMU_TEST_REGION\"}}');",
+			expected: "INSERT INTO t (region) VALUES ('tokyo');",
+		},
+		{
+			name:     "if else",
+			content:  "{{if .flag}}'{{.flag}}'{{else}}NULL{{end}}",
+			vars:     map[string]string{"flag": "yes"},
+			expected: "'yes'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Render("seed.sql.tmpl", tt.content, tt.vars)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Render() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRender_InjectionStaysInLiteral(t *testing.T) {
+	content := "INSERT INTO users (id, name) VALUES (1, '{{.name}}');\n" +
+		"INSERT INTO users (id, name) VALUES (2, {{.name}});"
+	result, err := Render("seed", content, map[string]string{"name": `\'); DELETE FROM users; --`})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	statements, err := parser.ParseDMLContent(result)
+	if err != nil {
+		t.Fatalf("ParseDMLContent() error = %v", err)
+	}
+	if len(statements) != 2 {
+		t.Fatalf("got %d statements, expected 2: %q", len(statements), statements)
+	}
+	for _, stmt := range statements {
+		if !strings.HasPrefix(stmt, "INSERT INTO users") {
+			t.Errorf("unexpected statement %q", stmt)
+		}
+	}
+}
+
+func TestRender_NowAndUUID(t *testing.T) {
+	result, err := Render("seed", "{{now}}|'{{uuid}}'|{{uuid}}", nil)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	parts := strings.Split(result, "|")
+	if !strings.HasPrefix(parts[0], "TIMESTAMP '") {
+		t.Errorf("now in SQL context = %q, expected a TIMESTAMP literal", parts[0])
+	}
+	if len(parts[1]) != 38 {
+		t.Errorf("uuid in literal = %q, expected a quoted UUID", parts[1])
+	}
+	if len(parts[2]) != 38 || parts[1] == parts[2] {
+		t.Errorf("uuid in SQL context = %q, expected a distinct quoted UUID", parts[2])
+	}
+}
+
+func TestRender_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		vars    map[string]string
+		message string
+	}{
+		{"missing variable", "VALUES ({{.tenant}})", nil, "tenant"},
+		{"syntax error", "VALUES ({{.tenant)", nil, "failed to parse template"},
+		{"raw string", "VALUES (r'{{.v}}')", map[string]string{"v": "x"}, "raw string literal"},
+		{"unbalanced range", "{{range seq 2}}'{{end}}'", nil, "range body"},
+		{"unbalanced if", "{{if true}}'{{end}}x'", nil, "branches end in different contexts"},
+		{"seq arguments", "{{seq 1 2 3}}", nil, "seq expects 1 or 2 arguments"},
+		{"seq too long", "{{range seq 2000000}}{{end}}", nil, "yields more than 1000000 values"},
+		{"int", "VALUES ({{int .id}})", map[string]string{"id": "x"}, `int: "x" is not an integer`},
+		{"float", "VALUES ({{float .score}})", map[string]string{"score": "1.5x"}, `float: "1.5x" is not a number`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Render("seed", tt.content, tt.vars)
+			if err == nil {
+				t.Fatal("Render() expected error")
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Render() error = %v, expected it to contain %q", err, tt.message)
+			}
+		})
+	}
+}
+
+func TestParseVar(t *testing.T) {
+	key, value, err := ParseVar("tenant=acme=1")
+	if err != nil || key != "tenant" || value != "acme=1" {
+		t.Errorf("ParseVar() = %q, %q, %v", key, value, err)
+	}
+
+	for _, def := range []string{"tenant", "=acme"} {
+		if _, _, err := ParseVar(def); err == nil {
+			t.Errorf("ParseVar(%q) expected error", def)
+		}
+	}
+}
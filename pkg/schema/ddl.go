@@ -0,0 +1,553 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nu0ma/spemu/pkg/parser"
+)
+
+// ParseDDL builds a schema from DDL statements, e.g. the statements of a
+// schema file or those returned by GetDatabaseDdl. Tables, indexes and change
+// streams are parsed; ALTER TABLE statements that add columns, constraints
+// or a row deletion policy are applied to their tables. Statements creating
+// other objects are kept in Other.
+func ParseDDL(statements []string) (*Schema, error) {
+	s := &Schema{}
+	for _, stmt := range statements {
+		p := &ddlParser{sql: stmt, tokens: parser.Tokens(stmt)}
+
+		var err error
+		switch {
+		case p.accept("CREATE", "TABLE"):
+			err = p.createTable(s)
+		case p.accept("CREATE", "CHANGE", "STREAM"):
+			err = p.createChangeStream(s)
+		case p.is("CREATE", "INDEX") || p.is("CREATE", "UNIQUE") || p.is("CREATE", "NULL_FILTERED"):
+			p.pos++
+			err = p.createIndex(s)
+		case p.accept("ALTER", "TABLE"):
+			err = p.alterTable(s)
+		default:
+			s.Other = append(s.Other, stmt)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", truncate(stmt, 50), err)
+		}
+	}
+
+	return s, nil
+}
+
+func (p *ddlParser) createTable(s *Schema) error {
+	p.accept("IF", "NOT", "EXISTS")
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	if s.Table(name) != nil {
+		return fmt.Errorf("duplicate table %s", name)
+	}
+	t := &Table{Name: name}
+
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for !p.accept(")") {
+		if err := p.tableElement(t); err != nil {
+			return err
+		}
+		if !p.accept(",") {
+			if err := p.expect(")"); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	if err := p.expect("PRIMARY", "KEY"); err != nil {
+		return err
+	}
+	if t.PrimaryKey, t.Descending, err = p.keyParts(); err != nil {
+		return err
+	}
+
+	for p.accept(",") {
+		switch {
+		case p.accept("INTERLEAVE", "IN"):
+			p.accept("PARENT")
+			if t.Parent, err = p.name(); err != nil {
+				return err
+			}
+			if t.OnDelete, err = p.onDelete(); err != nil {
+				return err
+			}
+		case p.accept("ROW", "DELETION", "POLICY"):
+			if t.RowDeletionPolicy, err = p.parenthesized(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported table option %s", p.peek())
+		}
+	}
+	if err := p.end(); err != nil {
+		return err
+	}
+
+	s.Tables = append(s.Tables, t)
+	return nil
+}
+
+// tableElement parses a column definition or a table constraint.
+func (p *ddlParser) tableElement(t *Table) error {
+	constraint := ""
+	if p.accept("CONSTRAINT") {
+		var err error
+		if constraint, err = p.name(); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case p.is("FOREIGN", "KEY"):
+		fk, err := p.foreignKey(constraint)
+		if err != nil {
+			return err
+		}
+		t.ForeignKeys = append(t.ForeignKeys, fk)
+	case p.is("CHECK"):
+		check, err := p.check(constraint)
+		if err != nil {
+			return err
+		}
+		t.Checks = append(t.Checks, check)
+	case constraint != "":
+		return fmt.Errorf("unsupported constraint %s", p.peek())
+	default:
+		c, err := p.column()
+		if err != nil {
+			return err
+		}
+		if t.Column(c.Name) != nil {
+			return fmt.Errorf("duplicate column %s", c.Name)
+		}
+		t.Columns = append(t.Columns, c)
+	}
+	return nil
+}
+
+// column parses a column definition: name type [NOT NULL] [DEFAULT (expr) |
+// AS (expr) [STORED]] [HIDDEN] [OPTIONS (...)].
+func (p *ddlParser) column() (*Column, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	c := &Column{Name: name}
+
+	c.Type = p.until(",", ")", "NOT", "DEFAULT", "AS", "HIDDEN", "OPTIONS")
+	if c.Type == "" {
+		return nil, fmt.Errorf("column %s has no type", name)
+	}
+
+	for {
+		switch {
+		case p.accept("NOT", "NULL"):
+			c.NotNull = true
+		case p.accept("DEFAULT"):
+			if c.Default, err = p.parenthesized(); err != nil {
+				return nil, err
+			}
+		case p.accept("AS"):
+			if c.Expression, err = p.parenthesized(); err != nil {
+				return nil, err
+			}
+			c.Generated = true
+			c.Stored = p.accept("STORED")
+		case p.accept("HIDDEN"):
+			c.Hidden = true
+		case p.accept("OPTIONS"):
+			if c.Options, err = p.parenthesized(); err != nil {
+				return nil, err
+			}
+		default:
+			return c, nil
+		}
+	}
+}
+
+// foreignKey parses FOREIGN KEY (columns) REFERENCES table (columns)
+// [ON DELETE action].
+func (p *ddlParser) foreignKey(name string) (*ForeignKey, error) {
+	if err := p.expect("FOREIGN", "KEY"); err != nil {
+		return nil, err
+	}
+	fk := &ForeignKey{Name: name}
+
+	var err error
+	if fk.Columns, err = p.names(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("REFERENCES"); err != nil {
+		return nil, err
+	}
+	if fk.ReferencedTable, err = p.name(); err != nil {
+		return nil, err
+	}
+	if fk.ReferencedColumns, err = p.names(); err != nil {
+		return nil, err
+	}
+	if fk.OnDelete, err = p.onDelete(); err != nil {
+		return nil, err
+	}
+	return fk, nil
+}
+
+func (p *ddlParser) check(name string) (*Check, error) {
+	if err := p.expect("CHECK"); err != nil {
+		return nil, err
+	}
+	expr, err := p.parenthesized()
+	if err != nil {
+		return nil, err
+	}
+	return &Check{Name: name, Expression: expr}, nil
+}
+
+// onDelete parses an optional ON DELETE CASCADE or ON DELETE NO ACTION.
+func (p *ddlParser) onDelete() (string, error) {
+	if !p.accept("ON", "DELETE") {
+		return "", nil
+	}
+	switch {
+	case p.accept("CASCADE"):
+		return "CASCADE", nil
+	case p.accept("NO", "ACTION"):
+		return "NO ACTION", nil
+	}
+	return "", fmt.Errorf("unsupported ON DELETE %s", p.peek())
+}
+
+// createIndex parses the rest of CREATE [UNIQUE] [NULL_FILTERED] INDEX.
+func (p *ddlParser) createIndex(s *Schema) error {
+	idx := &Index{}
+	idx.Unique = p.accept("UNIQUE")
+	idx.NullFiltered = p.accept("NULL_FILTERED")
+	if err := p.expect("INDEX"); err != nil {
+		return err
+	}
+	p.accept("IF", "NOT", "EXISTS")
+
+	var err error
+	if idx.Name, err = p.name(); err != nil {
+		return err
+	}
+	if s.Index(idx.Name) != nil {
+		return fmt.Errorf("duplicate index %s", idx.Name)
+	}
+	if err := p.expect("ON"); err != nil {
+		return err
+	}
+	if idx.Table, err = p.name(); err != nil {
+		return err
+	}
+
+	keys, descending, err := p.keyParts()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if containsFold(descending, key) {
+			key += " DESC"
+		}
+		idx.Columns = append(idx.Columns, key)
+	}
+
+	if p.accept("STORING") {
+		if idx.Storing, err = p.names(); err != nil {
+			return err
+		}
+	}
+	if p.accept(",", "INTERLEAVE", "IN") {
+		if idx.Parent, err = p.name(); err != nil {
+			return err
+		}
+	}
+	if err := p.end(); err != nil {
+		return err
+	}
+
+	s.Indexes = append(s.Indexes, idx)
+	return nil
+}
+
+// createChangeStream parses the rest of CREATE CHANGE STREAM.
+func (p *ddlParser) createChangeStream(s *Schema) error {
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	if s.ChangeStream(name) != nil {
+		return fmt.Errorf("duplicate change stream %s", name)
+	}
+	cs := &ChangeStream{Name: name}
+
+	if p.accept("FOR") {
+		if cs.For = p.until("OPTIONS"); cs.For == "" {
+			return fmt.Errorf("change stream %s watches nothing after FOR", name)
+		}
+	}
+	if p.accept("OPTIONS") {
+		if cs.Options, err = p.parenthesized(); err != nil {
+			return err
+		}
+	}
+	if err := p.end(); err != nil {
+		return err
+	}
+
+	s.ChangeStreams = append(s.ChangeStreams, cs)
+	return nil
+}
+
+// alterTable applies an ALTER TABLE statement that adds to a table.
+func (p *ddlParser) alterTable(s *Schema) error {
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	t := s.Table(name)
+	if t == nil {
+		return fmt.Errorf("table %s does not exist", name)
+	}
+
+	switch {
+	case p.accept("ADD", "COLUMN"):
+		p.accept("IF", "NOT", "EXISTS")
+		c, err := p.column()
+		if err != nil {
+			return err
+		}
+		t.Columns = append(t.Columns, c)
+	case p.accept("ADD", "ROW", "DELETION", "POLICY"):
+		if t.RowDeletionPolicy, err = p.parenthesized(); err != nil {
+			return err
+		}
+	case p.accept("SET", "ON", "DELETE"):
+		p.pos -= 2
+		if t.OnDelete, err = p.onDelete(); err != nil {
+			return err
+		}
+	case p.accept("ADD"):
+		if err := p.tableElement(t); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported ALTER TABLE %s", p.peek())
+	}
+	return p.end()
+}
+
+// ddlParser consumes the tokens of a DDL statement.
+type ddlParser struct {
+	sql    string
+	tokens []parser.Token
+	pos    int
+}
+
+func (p *ddlParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].Text
+	}
+	return "end of statement"
+}
+
+// is reports whether the next tokens are words, ignoring case.
+func (p *ddlParser) is(words ...string) bool {
+	for i, word := range words {
+		if p.pos+i >= len(p.tokens) || !strings.EqualFold(p.tokens[p.pos+i].Text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// accept consumes words if they are the next tokens.
+func (p *ddlParser) accept(words ...string) bool {
+	if !p.is(words...) {
+		return false
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *ddlParser) expect(words ...string) error {
+	if !p.accept(words...) {
+		return fmt.Errorf("expected %s, got %s", strings.Join(words, " "), p.peek())
+	}
+	return nil
+}
+
+func (p *ddlParser) end() error {
+	if p.pos < len(p.tokens) {
+		return fmt.Errorf("unsupported %s", p.peek())
+	}
+	return nil
+}
+
+// name consumes a possibly qualified, possibly quoted name.
+func (p *ddlParser) name() (string, error) {
+	var parts []string
+	for {
+		t := p.peek()
+		switch {
+		case strings.HasPrefix(t, "`") && len(t) > 1:
+			parts = append(parts, strings.Trim(t, "`"))
+		case p.pos < len(p.tokens) && isName(t):
+			parts = append(parts, t)
+		default:
+			return "", fmt.Errorf("expected a name, got %s", t)
+		}
+		p.pos++
+		if !p.accept(".") {
+			return strings.Join(parts, "."), nil
+		}
+	}
+}
+
+// names consumes a parenthesized list of names.
+func (p *ddlParser) names() ([]string, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var names []string
+	for !p.accept(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if !p.accept(",") {
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return names, nil
+}
+
+// keyParts consumes a parenthesized list of key columns, each optionally
+// followed by ASC or DESC, and returns the columns and the descending ones.
+func (p *ddlParser) keyParts() (columns, descending []string, err error) {
+	if err := p.expect("("); err != nil {
+		return nil, nil, err
+	}
+	for !p.accept(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, nil, err
+		}
+		columns = append(columns, name)
+		if p.accept("DESC") {
+			descending = append(descending, name)
+		} else {
+			p.accept("ASC")
+		}
+		if !p.accept(",") {
+			if err := p.expect(")"); err != nil {
+				return nil, nil, err
+			}
+			break
+		}
+	}
+	return columns, descending, nil
+}
+
+// parenthesized consumes a parenthesized expression and returns the text
+// between the parentheses.
+func (p *ddlParser) parenthesized() (string, error) {
+	if err := p.expect("("); err != nil {
+		return "", err
+	}
+	start := p.pos
+	for depth := 1; ; p.pos++ {
+		if p.pos >= len(p.tokens) {
+			return "", fmt.Errorf("unbalanced parentheses")
+		}
+		switch p.tokens[p.pos].Text {
+		case "(":
+			depth++
+		case ")":
+			if depth--; depth == 0 {
+				text := p.text(start, p.pos)
+				p.pos++
+				return text, nil
+			}
+		}
+	}
+}
+
+// until consumes tokens up to one of the stop tokens outside parentheses,
+// or the end of the statement, and returns their text.
+func (p *ddlParser) until(stop ...string) string {
+	start := p.pos
+	depth := 0
+	for ; p.pos < len(p.tokens); p.pos++ {
+		t := p.tokens[p.pos].Text
+		if depth == 0 {
+			for _, s := range stop {
+				if strings.EqualFold(t, s) {
+					return p.text(start, p.pos)
+				}
+			}
+		}
+		switch t {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+	}
+	return p.text(start, p.pos)
+}
+
+// text returns the source text of the tokens from start up to end.
+func (p *ddlParser) text(start, end int) string {
+	if start >= end {
+		return ""
+	}
+	last := p.tokens[end-1]
+	return strings.TrimSpace(p.sql[p.tokens[start].Offset : last.Offset+len(last.Text)])
+}
+
+// isName reports whether a token is an unquoted name.
+func isName(t string) bool {
+	if t == "" || t[0] >= '0' && t[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(t); i++ {
+		c := t[i]
+		if c != '_' && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(s string, limit int) string {
+	if len(s) < limit {
+		return s
+	}
+	return s[:limit]
+}
@@ -0,0 +1,105 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDDL(t *testing.T) {
+	s, err := ParseDDL([]string{
+		`CREATE TABLE Singers (
+  SingerId INT64 NOT NULL,
+  Name STRING(MAX) DEFAULT ("unknown"),
+  Tags ARRAY<STRING(10)>,
+  UpdatedAt TIMESTAMP OPTIONS (allow_commit_timestamp = true),
+  NameLength INT64 AS (CHAR_LENGTH(Name)) STORED,
+  CONSTRAINT name_not_empty CHECK (Name != ''),
+) PRIMARY KEY (SingerId DESC)`,
+		`CREATE TABLE Albums (
+  SingerId INT64 NOT NULL,
+  AlbumId INT64 NOT NULL,
+  FOREIGN KEY (SingerId) REFERENCES Singers (SingerId) ON DELETE CASCADE,
+) PRIMARY KEY (SingerId, AlbumId),
+  INTERLEAVE IN PARENT Singers ON DELETE CASCADE,
+  ROW DELETION POLICY (OLDER_THAN(CreatedAt, INTERVAL 30 DAY))`,
+		"CREATE UNIQUE NULL_FILTERED INDEX AlbumsById ON Albums (AlbumId DESC) STORING (SingerId), INTERLEAVE IN Singers",
+		"CREATE CHANGE STREAM Everything FOR ALL OPTIONS (retention_period = '7d')",
+		"ALTER TABLE Albums ADD COLUMN `Order` INT64",
+		"CREATE VIEW SingerNames SQL SECURITY INVOKER AS SELECT Name FROM Singers",
+	})
+	if err != nil {
+		t.Fatalf("ParseDDL() error = %v", err)
+	}
+
+	singers := s.Table("Singers")
+	if singers == nil || len(singers.Columns) != 5 {
+		t.Fatalf("ParseDDL() Singers = %+v, expected 5 columns", singers)
+	}
+	expected := []*Column{
+		{Name: "SingerId", Type: "INT64", NotNull: true},
+		{Name: "Name", Type: "STRING(MAX)", Default: `"unknown"`},
+		{Name: "Tags", Type: "ARRAY<STRING(10)>"},
+		{Name: "UpdatedAt", Type: "TIMESTAMP", Options: "allow_commit_timestamp = true"},
+		{Name: "NameLength", Type: "INT64", Generated: true, Expression: "CHAR_LENGTH(Name)", Stored: true},
+	}
+	if !reflect.DeepEqual(singers.Columns, expected) {
+		for i := range expected {
+			t.Errorf("column %d = %+v, expected %+v", i, singers.Columns[i], expected[i])
+		}
+	}
+	if !reflect.DeepEqual(singers.Descending, []string{"SingerId"}) {
+		t.Errorf("Descending = %v, expected [SingerId]", singers.Descending)
+	}
+	if !reflect.DeepEqual(singers.Checks, []*Check{{Name: "name_not_empty", Expression: "Name != ''"}}) {
+		t.Errorf("Checks = %+v", singers.Checks)
+	}
+
+	albums := s.Table("Albums")
+	if albums.Parent != "Singers" || albums.OnDelete != "CASCADE" || albums.RowDeletionPolicy != "OLDER_THAN(CreatedAt, INTERVAL 30 DAY)" {
+		t.Errorf("Albums = %+v", albums)
+	}
+	fk := &ForeignKey{Columns: []string{"SingerId"}, ReferencedTable: "Singers", ReferencedColumns: []string{"SingerId"}, OnDelete: "CASCADE"}
+	if !reflect.DeepEqual(albums.ForeignKeys, []*ForeignKey{fk}) {
+		t.Errorf("ForeignKeys = %+v", albums.ForeignKeys)
+	}
+	if albums.Column("Order") == nil {
+		t.Error("ALTER TABLE ADD COLUMN was not applied")
+	}
+
+	idx := &Index{Name: "AlbumsById", Table: "Albums", Unique: true, NullFiltered: true,
+		Columns: []string{"AlbumId DESC"}, Storing: []string{"SingerId"}, Parent: "Singers"}
+	if !reflect.DeepEqual(s.Indexes, []*Index{idx}) {
+		t.Errorf("Indexes = %+v", s.Indexes)
+	}
+	cs := &ChangeStream{Name: "Everything", For: "ALL", Options: "retention_period = '7d'"}
+	if !reflect.DeepEqual(s.ChangeStreams, []*ChangeStream{cs}) {
+		t.Errorf("ChangeStreams = %+v", s.ChangeStreams)
+	}
+	if len(s.Other) != 1 || !strings.HasPrefix(s.Other[0], "CREATE VIEW") {
+		t.Errorf("Other = %v", s.Other)
+	}
+}
+
+func TestParseDDL_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		ddl      string
+		expected string
+	}{
+		{"missing primary key", "CREATE TABLE t (id INT64)", "expected PRIMARY KEY"},
+		{"column without type", "CREATE TABLE t (id) PRIMARY KEY (id)", "column id has no type"},
+		{"unknown table", "ALTER TABLE t ADD COLUMN c INT64", "table t does not exist"},
+		{"unsupported alter", "CREATE TABLE t (id INT64) PRIMARY KEY (id); ALTER TABLE t DROP COLUMN id", "unsupported ALTER TABLE DROP"},
+		{"unbalanced", "CREATE TABLE t (id INT64 DEFAULT (1) PRIMARY KEY (id)", "expected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDDL(strings.Split(tt.ddl, "; "))
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("ParseDDL() error = %v, expected %q", err, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,398 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nu0ma/spemu/pkg/parser"
+)
+
+// Diff returns the DDL statements that change the schema from into the
+// schema to. Objects are matched by name, ignoring case, and expressions
+// are compared ignoring case and whitespace. Objects are dropped before
+// they are created, so that a changed index or generated column is
+// rebuilt. Changes Spanner cannot make in place, such as a different
+// primary key or parent of a table, are reported as errors.
+func Diff(from, to *Schema) ([]string, error) {
+	var errs []error
+	for _, t := range to.Tables {
+		old := from.Table(t.Name)
+		if old == nil {
+			continue
+		}
+		if !equalKeys(old, t) {
+			errs = append(errs, fmt.Errorf("cannot change the primary key of table %s", t.Name))
+		}
+		if !strings.EqualFold(old.Parent, t.Parent) {
+			errs = append(errs, fmt.Errorf("cannot change the parent of table %s", t.Name))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	d := &differ{from: from, to: to}
+	if err := d.drop(); err != nil {
+		return nil, err
+	}
+	if err := d.create(); err != nil {
+		return nil, err
+	}
+	return d.statements, nil
+}
+
+type differ struct {
+	from, to   *Schema
+	statements []string
+	// checks and foreignKeys hold the constraints of kept tables that are
+	// already in the database
+	checks      map[*Check]bool
+	foreignKeys map[*ForeignKey]bool
+}
+
+func (d *differ) add(format string, args ...interface{}) {
+	d.statements = append(d.statements, fmt.Sprintf(format, args...))
+}
+
+// drop adds the statements that drop the objects that are removed or must
+// be rebuilt, dependent objects first.
+func (d *differ) drop() error {
+	for _, cs := range d.from.ChangeStreams {
+		if d.to.ChangeStream(cs.Name) == nil {
+			d.add("DROP CHANGE STREAM %s", quote(cs.Name))
+		}
+	}
+
+	d.checks = make(map[*Check]bool)
+	d.foreignKeys = make(map[*ForeignKey]bool)
+	for _, old := range d.from.Tables {
+		t := d.to.Table(old.Name)
+		if t == nil {
+			continue
+		}
+		for _, check := range old.Checks {
+			if match := findCheck(t.Checks, check, d.checks); match != nil {
+				d.checks[match] = true
+			} else if err := d.dropConstraint(old, check.Name); err != nil {
+				return err
+			}
+		}
+		for _, fk := range old.ForeignKeys {
+			if match := findForeignKey(t.ForeignKeys, fk, d.foreignKeys); match != nil {
+				d.foreignKeys[match] = true
+			} else if err := d.dropConstraint(old, fk.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, idx := range d.from.Indexes {
+		if i := d.to.Index(idx.Name); i == nil || !equalIndexes(idx, i) {
+			d.add("DROP INDEX %s", quote(idx.Name))
+		}
+	}
+
+	for _, old := range d.from.Tables {
+		t := d.to.Table(old.Name)
+		if t == nil {
+			continue
+		}
+		for _, c := range old.Columns {
+			if n := t.Column(c.Name); n == nil || !equalGenerated(c, n) {
+				d.add("ALTER TABLE %s DROP COLUMN %s", quote(old.Name), quote(c.Name))
+			}
+		}
+	}
+
+	tables, err := d.from.Order()
+	if err != nil {
+		if tables, err = d.from.parentOrder(); err != nil {
+			return err
+		}
+	}
+	for i := len(tables) - 1; i >= 0; i-- {
+		if d.to.Table(tables[i].Name) == nil {
+			d.add("DROP TABLE %s", quote(tables[i].Name))
+		}
+	}
+	return nil
+}
+
+func (d *differ) dropConstraint(t *Table, name string) error {
+	if name == "" {
+		return fmt.Errorf("cannot drop an unnamed constraint of table %s", t.Name)
+	}
+	d.add("ALTER TABLE %s DROP CONSTRAINT %s", quote(t.Name), quote(name))
+	return nil
+}
+
+// create adds the statements that create and alter objects, the objects
+// they depend on first.
+func (d *differ) create() error {
+	// Tables are created with the foreign keys to tables that exist by
+	// then. The others, e.g. between tables referencing each other, are
+	// added once all tables are created.
+	tables, err := d.to.Order()
+	if err != nil {
+		if tables, err = d.to.parentOrder(); err != nil {
+			return err
+		}
+	}
+	exists := make(map[string]bool)
+	for _, t := range d.from.Tables {
+		exists[strings.ToLower(t.Name)] = true
+	}
+	var deferred []string
+	for _, t := range tables {
+		if exists[strings.ToLower(t.Name)] {
+			continue
+		}
+		exists[strings.ToLower(t.Name)] = true
+		table := *t
+		table.ForeignKeys = nil
+		for _, fk := range t.ForeignKeys {
+			if exists[strings.ToLower(fk.ReferencedTable)] {
+				table.ForeignKeys = append(table.ForeignKeys, fk)
+			} else {
+				deferred = append(deferred, "ALTER TABLE "+quote(t.Name)+" ADD "+fk.definition())
+			}
+		}
+		d.add("%s", table.DDL())
+	}
+	d.statements = append(d.statements, deferred...)
+
+	for _, t := range tables {
+		if old := d.from.Table(t.Name); old != nil {
+			d.alterTable(old, t)
+		}
+	}
+
+	for _, idx := range d.to.Indexes {
+		old := d.from.Index(idx.Name)
+		if old == nil || !equalIndexes(old, idx) {
+			d.add("%s", idx.DDL())
+			continue
+		}
+		for _, c := range idx.Storing {
+			if !containsFold(old.Storing, c) {
+				d.add("ALTER INDEX %s ADD STORED COLUMN %s", quote(idx.Name), quote(c))
+			}
+		}
+		for _, c := range old.Storing {
+			if !containsFold(idx.Storing, c) {
+				d.add("ALTER INDEX %s DROP STORED COLUMN %s", quote(idx.Name), quote(c))
+			}
+		}
+	}
+
+	for _, cs := range d.to.ChangeStreams {
+		old := d.from.ChangeStream(cs.Name)
+		if old == nil {
+			d.add("%s", cs.DDL())
+			continue
+		}
+		if norm(old.For) != norm(cs.For) {
+			if cs.For == "" {
+				d.add("ALTER CHANGE STREAM %s DROP FOR ALL", quote(cs.Name))
+			} else {
+				d.add("ALTER CHANGE STREAM %s SET FOR %s", quote(cs.Name), cs.For)
+			}
+		}
+		if options := setOptions(old.Options, cs.Options); options != "" {
+			d.add("ALTER CHANGE STREAM %s SET OPTIONS (%s)", quote(cs.Name), options)
+		}
+	}
+	return nil
+}
+
+// alterTable adds the statements that change the columns, options and
+// constraints of a table that exists in both schemas.
+func (d *differ) alterTable(old, t *Table) {
+	name := quote(t.Name)
+	for _, c := range t.Columns {
+		prev := old.Column(c.Name)
+		if prev == nil || !equalGenerated(prev, c) {
+			d.add("ALTER TABLE %s ADD COLUMN %s", name, c.definition())
+			continue
+		}
+
+		if norm(prev.Type) != norm(c.Type) || prev.NotNull != c.NotNull || prev.Hidden != c.Hidden {
+			altered := *c
+			altered.Options = ""
+			d.add("ALTER TABLE %s ALTER COLUMN %s", name, altered.definition())
+		} else if norm(prev.Default) != norm(c.Default) {
+			if c.Default == "" {
+				d.add("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT", name, quote(c.Name))
+			} else {
+				d.add("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT (%s)", name, quote(c.Name), c.Default)
+			}
+		}
+		if options := setOptions(prev.Options, c.Options); options != "" {
+			d.add("ALTER TABLE %s ALTER COLUMN %s SET OPTIONS (%s)", name, quote(c.Name), options)
+		}
+	}
+
+	if t.Parent != "" && onDelete(old.OnDelete) != onDelete(t.OnDelete) {
+		d.add("ALTER TABLE %s SET ON DELETE %s", name, onDelete(t.OnDelete))
+	}
+
+	switch {
+	case norm(old.RowDeletionPolicy) == norm(t.RowDeletionPolicy):
+	case old.RowDeletionPolicy == "":
+		d.add("ALTER TABLE %s ADD ROW DELETION POLICY (%s)", name, t.RowDeletionPolicy)
+	case t.RowDeletionPolicy == "":
+		d.add("ALTER TABLE %s DROP ROW DELETION POLICY", name)
+	default:
+		d.add("ALTER TABLE %s REPLACE ROW DELETION POLICY (%s)", name, t.RowDeletionPolicy)
+	}
+
+	for _, check := range t.Checks {
+		if !d.checks[check] {
+			d.add("ALTER TABLE %s ADD %s", name, check.definition())
+		}
+	}
+	for _, fk := range t.ForeignKeys {
+		if !d.foreignKeys[fk] {
+			d.add("ALTER TABLE %s ADD %s", name, fk.definition())
+		}
+	}
+}
+
+// findCheck returns the check constraint in checks that matches check and
+// is not yet used. Unnamed constraints match by expression only.
+func findCheck(checks []*Check, check *Check, used map[*Check]bool) *Check {
+	for _, c := range checks {
+		if !used[c] && (c.Name == "" || strings.EqualFold(c.Name, check.Name)) && norm(c.Expression) == norm(check.Expression) {
+			return c
+		}
+	}
+	return nil
+}
+
+// findForeignKey returns the foreign key in foreignKeys that matches fk and
+// is not yet used. Unnamed foreign keys match by their columns only.
+func findForeignKey(foreignKeys []*ForeignKey, fk *ForeignKey, used map[*ForeignKey]bool) *ForeignKey {
+	for _, f := range foreignKeys {
+		if !used[f] && (f.Name == "" || strings.EqualFold(f.Name, fk.Name)) &&
+			strings.EqualFold(f.ReferencedTable, fk.ReferencedTable) &&
+			equalFold(f.Columns, fk.Columns) && equalFold(f.ReferencedColumns, fk.ReferencedColumns) &&
+			onDelete(f.OnDelete) == onDelete(fk.OnDelete) {
+			return f
+		}
+	}
+	return nil
+}
+
+func equalKeys(a, b *Table) bool {
+	if !equalFold(a.PrimaryKey, b.PrimaryKey) {
+		return false
+	}
+	for _, key := range a.PrimaryKey {
+		if containsFold(a.Descending, key) != containsFold(b.Descending, key) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalIndexes reports whether two indexes are the same apart from their
+// stored columns, which can be changed in place.
+func equalIndexes(a, b *Index) bool {
+	return a.Unique == b.Unique && a.NullFiltered == b.NullFiltered &&
+		strings.EqualFold(a.Table, b.Table) && strings.EqualFold(a.Parent, b.Parent) &&
+		equalFold(a.Columns, b.Columns)
+}
+
+// equalGenerated reports whether two columns are both generated the same
+// way or both not generated. Generated columns cannot be altered.
+func equalGenerated(a, b *Column) bool {
+	return a.Generated == b.Generated && a.Stored == b.Stored && norm(a.Expression) == norm(b.Expression)
+}
+
+func equalFold(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func onDelete(action string) string {
+	if action == "" {
+		return "NO ACTION"
+	}
+	return action
+}
+
+// setOptions returns the options to set to change options from old to new,
+// setting removed options to null, or "" if they are the same.
+func setOptions(old, new string) string {
+	if norm(old) == norm(new) {
+		return ""
+	}
+	options := splitOptions(new)
+	for _, option := range splitOptions(old) {
+		key := optionKey(option)
+		removed := true
+		for _, o := range options {
+			if strings.EqualFold(optionKey(o), key) {
+				removed = false
+			}
+		}
+		if removed {
+			options = append(options, key+" = null")
+		}
+	}
+	return strings.Join(options, ", ")
+}
+
+// splitOptions splits an option list such as a = 1, b = 'x' into options.
+func splitOptions(options string) []string {
+	var split []string
+	start, depth := 0, 0
+	for _, t := range parser.Tokens(options) {
+		switch t.Text {
+		case "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+		case ",":
+			if depth == 0 {
+				split = append(split, strings.TrimSpace(options[start:t.Offset]))
+				start = t.Offset + 1
+			}
+		}
+	}
+	if rest := strings.TrimSpace(options[start:]); rest != "" {
+		split = append(split, rest)
+	}
+	return split
+}
+
+func optionKey(option string) string {
+	key, _, _ := strings.Cut(option, "=")
+	return strings.TrimSpace(key)
+}
+
+// norm normalizes an expression for comparison: tokens are separated by a
+// single space and names are upper-cased and unquoted.
+func norm(expr string) string {
+	tokens := parser.Tokens(expr)
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		switch {
+		case isName(t.Text):
+			words[i] = strings.ToUpper(t.Text)
+		case strings.HasPrefix(t.Text, "`"):
+			words[i] = strings.ToUpper(strings.Trim(t.Text, "`"))
+		default:
+			words[i] = t.Text
+		}
+	}
+	return strings.Join(words, " ")
+}
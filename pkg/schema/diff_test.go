@@ -0,0 +1,159 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustParseDDL(t *testing.T, statements ...string) *Schema {
+	t.Helper()
+	s, err := ParseDDL(statements)
+	if err != nil {
+		t.Fatalf("ParseDDL() error = %v", err)
+	}
+	return s
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     []string
+		to       []string
+		expected []string
+	}{
+		{
+			name: "up to date ignoring case and whitespace",
+			from: []string{"CREATE TABLE Users (Id INT64 NOT NULL, Name STRING(MAX)) PRIMARY KEY(Id)"},
+			to:   []string{"create table users (\n  id int64 not null,\n  name string(max),\n) primary key (id)"},
+		},
+		{
+			name: "create table and index",
+			from: []string{"CREATE TABLE Users (Id INT64 NOT NULL) PRIMARY KEY (Id)"},
+			to: []string{
+				"CREATE TABLE Users (Id INT64 NOT NULL) PRIMARY KEY (Id)",
+				"CREATE TABLE Posts (Id INT64 NOT NULL, UserId INT64, FOREIGN KEY (UserId) REFERENCES Users (Id)) PRIMARY KEY (Id)",
+				"CREATE INDEX PostsByUser ON Posts (UserId)",
+			},
+			expected: []string{
+				"CREATE TABLE Posts (\n  Id INT64 NOT NULL,\n  UserId INT64,\n  FOREIGN KEY (UserId) REFERENCES Users (Id),\n) PRIMARY KEY (Id)",
+				"CREATE INDEX PostsByUser ON Posts (UserId)",
+			},
+		},
+		{
+			name: "drop tables children first",
+			from: []string{
+				"CREATE TABLE Users (Id INT64 NOT NULL) PRIMARY KEY (Id)",
+				"CREATE TABLE Posts (Id INT64 NOT NULL, PostId INT64 NOT NULL) PRIMARY KEY (Id, PostId), INTERLEAVE IN PARENT Users",
+				"CREATE INDEX PostsById ON Posts (PostId)",
+			},
+			expected: []string{
+				"DROP INDEX PostsById",
+				"DROP TABLE Posts",
+				"DROP TABLE Users",
+			},
+		},
+		{
+			name: "columns",
+			from: []string{"CREATE TABLE t (id INT64 NOT NULL, a STRING(10), b INT64, c INT64 DEFAULT (1), d TIMESTAMP OPTIONS (allow_commit_timestamp = true), g INT64 AS (b + 1) STORED) PRIMARY KEY (id)"},
+			to:   []string{"CREATE TABLE t (id INT64 NOT NULL, a STRING(20) NOT NULL, c INT64 DEFAULT (2), d TIMESTAMP, g INT64 AS (c + 1) STORED, `Order` INT64) PRIMARY KEY (id)"},
+			expected: []string{
+				"ALTER TABLE t DROP COLUMN b",
+				"ALTER TABLE t DROP COLUMN g",
+				"ALTER TABLE t ALTER COLUMN a STRING(20) NOT NULL",
+				"ALTER TABLE t ALTER COLUMN c SET DEFAULT (2)",
+				"ALTER TABLE t ALTER COLUMN d SET OPTIONS (allow_commit_timestamp = null)",
+				"ALTER TABLE t ADD COLUMN g INT64 AS (c + 1) STORED",
+				"ALTER TABLE t ADD COLUMN `Order` INT64",
+			},
+		},
+		{
+			name: "constraints and policies",
+			from: []string{
+				"CREATE TABLE p (id INT64 NOT NULL) PRIMARY KEY (id)",
+				"CREATE TABLE t (id INT64 NOT NULL, pid INT64, CONSTRAINT FK_t_p FOREIGN KEY (pid) REFERENCES p (id), CONSTRAINT positive CHECK (id > 0)) PRIMARY KEY (id), ROW DELETION POLICY (OLDER_THAN(ts, INTERVAL 1 DAY))",
+			},
+			to: []string{
+				"CREATE TABLE p (id INT64 NOT NULL) PRIMARY KEY (id)",
+				"CREATE TABLE t (id INT64 NOT NULL, pid INT64, FOREIGN KEY (pid) REFERENCES p (id) ON DELETE CASCADE, CHECK (id > 0)) PRIMARY KEY (id)",
+			},
+			expected: []string{
+				"ALTER TABLE t DROP CONSTRAINT FK_t_p",
+				"ALTER TABLE t DROP ROW DELETION POLICY",
+				"ALTER TABLE t ADD FOREIGN KEY (pid) REFERENCES p (id) ON DELETE CASCADE",
+			},
+		},
+		{
+			name: "index storing and change streams",
+			from: []string{
+				"CREATE TABLE t (id INT64 NOT NULL, a INT64, b INT64) PRIMARY KEY (id)",
+				"CREATE INDEX ByA ON t (a) STORING (b)",
+				"CREATE UNIQUE INDEX ByB ON t (b)",
+				"CREATE CHANGE STREAM Old FOR ALL",
+				"CREATE CHANGE STREAM Changes FOR t OPTIONS (retention_period = '1d')",
+			},
+			to: []string{
+				"CREATE TABLE t (id INT64 NOT NULL, a INT64, b INT64) PRIMARY KEY (id)",
+				"CREATE INDEX ByA ON t (a)",
+				"CREATE INDEX ByB ON t (b)",
+				"CREATE CHANGE STREAM Changes FOR t(a) OPTIONS (retention_period = '7d')",
+			},
+			expected: []string{
+				"DROP CHANGE STREAM Old",
+				"DROP INDEX ByB",
+				"ALTER INDEX ByA DROP STORED COLUMN b",
+				"CREATE INDEX ByB ON t (b)",
+				"ALTER CHANGE STREAM Changes SET FOR t(a)",
+				"ALTER CHANGE STREAM Changes SET OPTIONS (retention_period = '7d')",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Diff(mustParseDDL(t, tt.from...), mustParseDDL(t, tt.to...))
+			if err != nil {
+				t.Fatalf("Diff() error = %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Diff() = %q\nexpected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDiff_Errors(t *testing.T) {
+	from := mustParseDDL(t,
+		"CREATE TABLE p (id INT64 NOT NULL) PRIMARY KEY (id)",
+		"CREATE TABLE t (id INT64 NOT NULL) PRIMARY KEY (id)",
+	)
+	to := mustParseDDL(t,
+		"CREATE TABLE p (id INT64 NOT NULL) PRIMARY KEY (id DESC)",
+		"CREATE TABLE t (id INT64 NOT NULL) PRIMARY KEY (id), INTERLEAVE IN PARENT p",
+	)
+
+	_, err := Diff(from, to)
+	for _, expected := range []string{"cannot change the primary key of table p", "cannot change the parent of table t"} {
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Diff() error = %v, expected %q", err, expected)
+		}
+	}
+}
+
+func TestDiff_CreatesWhatWasDropped(t *testing.T) {
+	ddl := []string{
+		"CREATE TABLE `Groups` (Id INT64 NOT NULL, Name STRING(MAX) NOT NULL DEFAULT ('x') OPTIONS (a = 1)) PRIMARY KEY (Id DESC), ROW DELETION POLICY (OLDER_THAN(ts, INTERVAL 1 DAY))",
+		"CREATE TABLE Members (Id INT64 NOT NULL, MemberId INT64 NOT NULL, CONSTRAINT ok CHECK (MemberId > 0)) PRIMARY KEY (Id, MemberId), INTERLEAVE IN PARENT `Groups` ON DELETE CASCADE",
+		"CREATE NULL_FILTERED INDEX ByName ON `Groups` (Name DESC) STORING (Id)",
+		"CREATE CHANGE STREAM Everything FOR ALL OPTIONS (value_capture_type = 'NEW_ROW')",
+	}
+
+	statements, err := Diff(&Schema{}, mustParseDDL(t, ddl...))
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	recreated := mustParseDDL(t, statements...)
+	if result, err := Diff(recreated, mustParseDDL(t, ddl...)); err != nil || len(result) != 0 {
+		t.Errorf("Diff() of the created schema = %q, %v, expected no changes", result, err)
+	}
+}
@@ -0,0 +1,196 @@
+package schema
+
+import (
+	"strings"
+
+	"github.com/nu0ma/spemu/pkg/parser"
+)
+
+// DDL returns the statements that create the schema in a stable order:
+// statements such as CREATE SEQUENCE that tables may depend on, tables with
+// parents before their children, indexes, foreign keys, change streams and
+// finally the other statements, e.g. views and grants. Foreign keys are
+// added after all tables, so tables referencing each other can be created.
+func (s *Schema) DDL() ([]string, error) {
+	var first, last []string
+	for _, stmt := range s.Other {
+		if isPrerequisite(stmt) {
+			first = append(first, stmt)
+		} else {
+			last = append(last, stmt)
+		}
+	}
+
+	ordered, err := s.parentOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	statements := first
+	for _, t := range ordered {
+		table := *t
+		table.ForeignKeys = nil
+		statements = append(statements, table.DDL())
+	}
+	for _, idx := range s.Indexes {
+		statements = append(statements, idx.DDL())
+	}
+	for _, t := range s.Tables {
+		for _, fk := range t.ForeignKeys {
+			statements = append(statements, "ALTER TABLE "+quote(t.Name)+" ADD "+fk.definition())
+		}
+	}
+	for _, cs := range s.ChangeStreams {
+		statements = append(statements, cs.DDL())
+	}
+	return append(statements, last...), nil
+}
+
+// isPrerequisite reports whether a statement creates an object that tables
+// may refer to.
+func isPrerequisite(stmt string) bool {
+	words := strings.Fields(strings.ToUpper(norm(stmt)))
+	if len(words) < 2 {
+		return false
+	}
+	switch words[0] + " " + words[1] {
+	case "ALTER DATABASE", "CREATE SEQUENCE", "CREATE PROTO":
+		return true
+	}
+	return false
+}
+
+// DDL returns the CREATE TABLE statement of the table.
+func (t *Table) DDL() string {
+	var b strings.Builder
+	b.WriteString("CREATE TABLE " + quote(t.Name) + " (\n")
+	for _, c := range t.Columns {
+		b.WriteString("  " + c.definition() + ",\n")
+	}
+	for _, fk := range t.ForeignKeys {
+		b.WriteString("  " + fk.definition() + ",\n")
+	}
+	for _, check := range t.Checks {
+		b.WriteString("  " + check.definition() + ",\n")
+	}
+	b.WriteString(") PRIMARY KEY (" + t.keyParts() + ")")
+	if t.Parent != "" {
+		b.WriteString(",\n  INTERLEAVE IN PARENT " + quote(t.Parent))
+		if t.OnDelete != "" {
+			b.WriteString(" ON DELETE " + t.OnDelete)
+		}
+	}
+	if t.RowDeletionPolicy != "" {
+		b.WriteString(",\n  ROW DELETION POLICY (" + t.RowDeletionPolicy + ")")
+	}
+	return b.String()
+}
+
+func (t *Table) keyParts() string {
+	parts := make([]string, len(t.PrimaryKey))
+	for i, key := range t.PrimaryKey {
+		parts[i] = quote(key)
+		if containsFold(t.Descending, key) {
+			parts[i] += " DESC"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// definition returns the column definition used in CREATE TABLE and ADD
+// COLUMN.
+func (c *Column) definition() string {
+	def := quote(c.Name) + " " + c.Type
+	if c.NotNull {
+		def += " NOT NULL"
+	}
+	if c.Default != "" {
+		def += " DEFAULT (" + c.Default + ")"
+	}
+	if c.Generated {
+		def += " AS (" + c.Expression + ")"
+		if c.Stored {
+			def += " STORED"
+		}
+	}
+	if c.Hidden {
+		def += " HIDDEN"
+	}
+	if c.Options != "" {
+		def += " OPTIONS (" + c.Options + ")"
+	}
+	return def
+}
+
+func (fk *ForeignKey) definition() string {
+	def := "FOREIGN KEY (" + quoteAll(fk.Columns) + ") REFERENCES " + quote(fk.ReferencedTable) +
+		" (" + quoteAll(fk.ReferencedColumns) + ")"
+	if fk.OnDelete != "" {
+		def += " ON DELETE " + fk.OnDelete
+	}
+	if fk.Name != "" {
+		def = "CONSTRAINT " + quote(fk.Name) + " " + def
+	}
+	return def
+}
+
+func (c *Check) definition() string {
+	def := "CHECK (" + c.Expression + ")"
+	if c.Name != "" {
+		def = "CONSTRAINT " + quote(c.Name) + " " + def
+	}
+	return def
+}
+
+// DDL returns the CREATE INDEX statement of the index.
+func (i *Index) DDL() string {
+	ddl := "CREATE "
+	if i.Unique {
+		ddl += "UNIQUE "
+	}
+	if i.NullFiltered {
+		ddl += "NULL_FILTERED "
+	}
+	keys := make([]string, len(i.Columns))
+	for n, key := range i.Columns {
+		name, desc := strings.CutSuffix(key, " DESC")
+		keys[n] = quote(name)
+		if desc {
+			keys[n] += " DESC"
+		}
+	}
+	ddl += "INDEX " + quote(i.Name) + " ON " + quote(i.Table) + " (" + strings.Join(keys, ", ") + ")"
+	if len(i.Storing) > 0 {
+		ddl += " STORING (" + quoteAll(i.Storing) + ")"
+	}
+	if i.Parent != "" {
+		ddl += ", INTERLEAVE IN " + quote(i.Parent)
+	}
+	return ddl
+}
+
+// DDL returns the CREATE CHANGE STREAM statement of the change stream.
+func (c *ChangeStream) DDL() string {
+	ddl := "CREATE CHANGE STREAM " + quote(c.Name)
+	if c.For != "" {
+		ddl += " FOR " + c.For
+	}
+	if c.Options != "" {
+		ddl += " OPTIONS (" + c.Options + ")"
+	}
+	return ddl
+}
+
+// quote quotes a name with backticks if it is reserved or not a plain
+// identifier (see parser.QuoteIdentifier).
+func quote(name string) string {
+	return parser.QuoteIdentifier(name)
+}
+
+func quoteAll(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quote(name)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,40 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchema_DDL(t *testing.T) {
+	s := mustParseDDL(t,
+		"CREATE VIEW Names SQL SECURITY INVOKER AS SELECT Name FROM Users",
+		"CREATE TABLE Posts (Id INT64 NOT NULL, PostId INT64 NOT NULL, Editor STRING(MAX)) PRIMARY KEY (Id, PostId), INTERLEAVE IN PARENT Users ON DELETE CASCADE",
+		"CREATE TABLE Users (Id INT64 NOT NULL, Name STRING(MAX), LastPost INT64, CONSTRAINT FK_Last FOREIGN KEY (Id, LastPost) REFERENCES Posts (Id, PostId)) PRIMARY KEY (Id)",
+		"CREATE SEQUENCE Ids OPTIONS (sequence_kind = 'bit_reversed_positive')",
+		"CREATE INDEX PostsByEditor ON Posts (Editor DESC) STORING (PostId)",
+		"CREATE CHANGE STREAM Everything FOR ALL",
+	)
+
+	result, err := s.DDL()
+	if err != nil {
+		t.Fatalf("DDL() error = %v", err)
+	}
+	expected := []string{
+		"CREATE SEQUENCE Ids OPTIONS (sequence_kind = 'bit_reversed_positive')",
+		"CREATE TABLE Users (\n  Id INT64 NOT NULL,\n  Name STRING(MAX),\n  LastPost INT64,\n) PRIMARY KEY (Id)",
+		"CREATE TABLE Posts (\n  Id INT64 NOT NULL,\n  PostId INT64 NOT NULL,\n  Editor STRING(MAX),\n) PRIMARY KEY (Id, PostId),\n  INTERLEAVE IN PARENT Users ON DELETE CASCADE",
+		"CREATE INDEX PostsByEditor ON Posts (Editor DESC) STORING (PostId)",
+		"ALTER TABLE Users ADD CONSTRAINT FK_Last FOREIGN KEY (Id, LastPost) REFERENCES Posts (Id, PostId)",
+		"CREATE CHANGE STREAM Everything FOR ALL",
+		"CREATE VIEW Names SQL SECURITY INVOKER AS SELECT Name FROM Users",
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("DDL() = %q\nexpected %q", result, expected)
+	}
+
+	// The statements create the same schema
+	recreated := mustParseDDL(t, result...)
+	if changes, err := Diff(recreated, s); err != nil || len(changes) != 0 {
+		t.Errorf("Diff() of the dumped schema = %q, %v, expected no changes", changes, err)
+	}
+}
@@ -0,0 +1,361 @@
+// Package schema describes the tables of a database.
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+)
+
+// Schema is the set of user tables in a database. Indexes, change streams
+// and other statements are only set for schemas parsed from DDL.
+type Schema struct {
+	Tables        []*Table
+	Indexes       []*Index
+	ChangeStreams []*ChangeStream
+	// Other holds the DDL statements of other objects, e.g. views and
+	// sequences, as written.
+	Other []string
+}
+
+// Table describes a table, its primary key and its relationships.
+type Table struct {
+	Name        string
+	Columns     []*Column
+	PrimaryKey  []string
+	ForeignKeys []*ForeignKey
+	// Parent is the table this table is interleaved in, if any.
+	Parent string
+
+	// The following are only set for schemas parsed from DDL.
+
+	// Descending lists the primary key columns sorted in descending order.
+	Descending []string
+	// OnDelete is the ON DELETE action of an interleaved table, CASCADE or
+	// NO ACTION.
+	OnDelete string
+	Checks   []*Check
+	// RowDeletionPolicy is the expression of the row deletion policy, e.g.
+	// OLDER_THAN(created_at, INTERVAL 30 DAY).
+	RowDeletionPolicy string
+}
+
+// Column describes a table column.
+type Column struct {
+	Name string
+	// Type is the Spanner type, e.g. STRING(100) or ARRAY<INT64>.
+	Type    string
+	NotNull bool
+	// Generated is set for generated columns, which cannot be written.
+	Generated bool
+
+	// The following are only set for schemas parsed from DDL.
+
+	// Default is the DEFAULT expression.
+	Default string
+	// Expression is the expression of a generated column, which is stored if
+	// Stored is set.
+	Expression string
+	Stored     bool
+	Hidden     bool
+	// Options are the column options, e.g. allow_commit_timestamp = true.
+	Options string
+}
+
+// ForeignKey describes a foreign key constraint.
+type ForeignKey struct {
+	Name              string
+	Columns           []string
+	ReferencedTable   string
+	ReferencedColumns []string
+	// OnDelete is the ON DELETE action, CASCADE or NO ACTION. It is only
+	// set for schemas parsed from DDL.
+	OnDelete string
+}
+
+// Check is a check constraint.
+type Check struct {
+	Name       string
+	Expression string
+}
+
+// Index is a secondary index.
+type Index struct {
+	Name         string
+	Table        string
+	Unique       bool
+	NullFiltered bool
+	// Columns are the key columns, followed by DESC for descending ones.
+	Columns []string
+	Storing []string
+	// Parent is the table the index is interleaved in, if any.
+	Parent string
+}
+
+// ChangeStream is a change stream.
+type ChangeStream struct {
+	Name string
+	// For is what the change stream watches, e.g. ALL or Users(name), empty
+	// if it watches nothing.
+	For string
+	// Options are the change stream options, e.g. retention_period = '7d'.
+	Options string
+}
+
+// BaseType returns the column type without a length, e.g. STRING for
+// STRING(100) and ARRAY<BYTES> for ARRAY<BYTES(MAX)>.
+func (c *Column) BaseType() string {
+	typ := strings.ToUpper(c.Type)
+	if idx := strings.IndexByte(typ, '('); idx != -1 {
+		end := strings.IndexByte(typ[idx:], ')')
+		if end != -1 {
+			typ = typ[:idx] + typ[idx+end+1:]
+		}
+	}
+	return typ
+}
+
+// MaxLength returns the declared length of a STRING or BYTES column (or of
+// the elements of an array column), or 0 for MAX or types without a length.
+func (c *Column) MaxLength() int {
+	start := strings.IndexByte(c.Type, '(')
+	end := strings.IndexByte(c.Type, ')')
+	if start == -1 || end < start {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(c.Type[start+1 : end]))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// Dependencies returns the names of the tables t depends on: its parent and
+// the tables referenced by its foreign keys, excluding t itself.
+func (t *Table) Dependencies() []string {
+	var deps []string
+	add := func(name string) {
+		if name == "" || strings.EqualFold(name, t.Name) {
+			return
+		}
+		for _, dep := range deps {
+			if strings.EqualFold(dep, name) {
+				return
+			}
+		}
+		deps = append(deps, name)
+	}
+
+	add(t.Parent)
+	for _, fk := range t.ForeignKeys {
+		add(fk.ReferencedTable)
+	}
+	return deps
+}
+
+// Order returns the tables sorted so that every table comes after the tables
+// it depends on. Tables without a dependency between them keep their order
+// in the schema. An error is returned if the dependencies form a cycle.
+func (s *Schema) Order() ([]*Table, error) {
+	var ordered []*Table
+	done := make(map[*Table]bool)
+	visiting := make(map[*Table]bool)
+
+	var visit func(t *Table, path []string) error
+	visit = func(t *Table, path []string) error {
+		if done[t] {
+			return nil
+		}
+		path = append(path, t.Name)
+		if visiting[t] {
+			return fmt.Errorf("dependency cycle between tables: %s", strings.Join(path, " -> "))
+		}
+		visiting[t] = true
+
+		for _, dep := range t.Dependencies() {
+			if parent := s.Table(dep); parent != nil {
+				if err := visit(parent, path); err != nil {
+					return err
+				}
+			}
+		}
+
+		visiting[t] = false
+		done[t] = true
+		ordered = append(ordered, t)
+		return nil
+	}
+
+	for _, t := range s.Tables {
+		if err := visit(t, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// parentOrder is like Order but ignores foreign keys, so that tables
+// referencing each other can be ordered.
+func (s *Schema) parentOrder() ([]*Table, error) {
+	tables := &Schema{}
+	originals := make(map[*Table]*Table)
+	for _, t := range s.Tables {
+		table := &Table{Name: t.Name, Parent: t.Parent}
+		originals[table] = t
+		tables.Tables = append(tables.Tables, table)
+	}
+	ordered, err := tables.Order()
+	if err != nil {
+		return nil, err
+	}
+	for i, t := range ordered {
+		ordered[i] = originals[t]
+	}
+	return ordered, nil
+}
+
+// Table returns the table with the given name, ignoring case, or nil.
+func (s *Schema) Table(name string) *Table {
+	for _, t := range s.Tables {
+		if strings.EqualFold(t.Name, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// Index returns the index with the given name, ignoring case, or nil.
+func (s *Schema) Index(name string) *Index {
+	for _, idx := range s.Indexes {
+		if strings.EqualFold(idx.Name, name) {
+			return idx
+		}
+	}
+	return nil
+}
+
+// ChangeStream returns the change stream with the given name, ignoring
+// case, or nil.
+func (s *Schema) ChangeStream(name string) *ChangeStream {
+	for _, cs := range s.ChangeStreams {
+		if strings.EqualFold(cs.Name, name) {
+			return cs
+		}
+	}
+	return nil
+}
+
+// Column returns the column with the given name, ignoring case, or nil.
+func (t *Table) Column(name string) *Column {
+	for _, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Load reads the schema of the database client is connected to from
+// INFORMATION_SCHEMA.
+func Load(ctx context.Context, client *spanner.Client) (*Schema, error) {
+	s := &Schema{}
+	tables := make(map[string]*Table)
+
+	txn := client.ReadOnlyTransaction()
+	defer txn.Close()
+
+	err := txn.Query(ctx, spanner.Statement{SQL: `SELECT TABLE_NAME, PARENT_TABLE_NAME
+FROM INFORMATION_SCHEMA.TABLES
+WHERE TABLE_SCHEMA = '' AND TABLE_TYPE = 'BASE TABLE'
+ORDER BY TABLE_NAME`}).Do(func(row *spanner.Row) error {
+		var name string
+		var parent spanner.NullString
+		if err := row.Columns(&name, &parent); err != nil {
+			return err
+		}
+		t := &Table{Name: name, Parent: parent.StringVal}
+		tables[name] = t
+		s.Tables = append(s.Tables, t)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tables: %w", err)
+	}
+
+	err = txn.Query(ctx, spanner.Statement{SQL: `SELECT TABLE_NAME, COLUMN_NAME, SPANNER_TYPE, IS_NULLABLE, IS_GENERATED
+FROM INFORMATION_SCHEMA.COLUMNS
+WHERE TABLE_SCHEMA = ''
+ORDER BY TABLE_NAME, ORDINAL_POSITION`}).Do(func(row *spanner.Row) error {
+		var table, name, typ, nullable, generated string
+		if err := row.Columns(&table, &name, &typ, &nullable, &generated); err != nil {
+			return err
+		}
+		if t, ok := tables[table]; ok {
+			t.Columns = append(t.Columns, &Column{
+				Name:      name,
+				Type:      typ,
+				NotNull:   nullable == "NO",
+				Generated: generated == "ALWAYS",
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	err = txn.Query(ctx, spanner.Statement{SQL: `SELECT TABLE_NAME, COLUMN_NAME
+FROM INFORMATION_SCHEMA.INDEX_COLUMNS
+WHERE TABLE_SCHEMA = '' AND INDEX_TYPE = 'PRIMARY_KEY'
+ORDER BY TABLE_NAME, ORDINAL_POSITION`}).Do(func(row *spanner.Row) error {
+		var table, name string
+		if err := row.Columns(&table, &name); err != nil {
+			return err
+		}
+		if t, ok := tables[table]; ok {
+			t.PrimaryKey = append(t.PrimaryKey, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read primary keys: %w", err)
+	}
+
+	foreignKeys := make(map[string]*ForeignKey)
+	err = txn.Query(ctx, spanner.Statement{SQL: `SELECT rc.CONSTRAINT_NAME, kcu.TABLE_NAME, kcu.COLUMN_NAME, ref.TABLE_NAME, ref.COLUMN_NAME
+FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc
+JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE kcu
+  ON kcu.CONSTRAINT_SCHEMA = rc.CONSTRAINT_SCHEMA AND kcu.CONSTRAINT_NAME = rc.CONSTRAINT_NAME
+JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE ref
+  ON ref.CONSTRAINT_SCHEMA = rc.UNIQUE_CONSTRAINT_SCHEMA AND ref.CONSTRAINT_NAME = rc.UNIQUE_CONSTRAINT_NAME
+  AND ref.ORDINAL_POSITION = kcu.POSITION_IN_UNIQUE_CONSTRAINT
+WHERE rc.CONSTRAINT_SCHEMA = ''
+ORDER BY rc.CONSTRAINT_NAME, kcu.ORDINAL_POSITION`}).Do(func(row *spanner.Row) error {
+		var name, table, column, refTable, refColumn string
+		if err := row.Columns(&name, &table, &column, &refTable, &refColumn); err != nil {
+			return err
+		}
+		t, ok := tables[table]
+		if !ok {
+			return nil
+		}
+		fk, ok := foreignKeys[name]
+		if !ok {
+			fk = &ForeignKey{Name: name, ReferencedTable: refTable}
+			foreignKeys[name] = fk
+			t.ForeignKeys = append(t.ForeignKeys, fk)
+		}
+		fk.Columns = append(fk.Columns, column)
+		fk.ReferencedColumns = append(fk.ReferencedColumns, refColumn)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read foreign keys: %w", err)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,103 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchema_Table(t *testing.T) {
+	s := &Schema{Tables: []*Table{
+		{Name: "Users", Columns: []*Column{{Name: "UserId", Type: "INT64", NotNull: true}}},
+		{Name: "posts"},
+	}}
+
+	if table := s.Table("users"); table == nil || table.Name != "Users" {
+		t.Errorf("Table(%q) = %v, expected Users", "users", table)
+	}
+	if table := s.Table("comments"); table != nil {
+		t.Errorf("Table(%q) = %v, expected nil", "comments", table)
+	}
+
+	users := s.Table("Users")
+	if column := users.Column("userid"); column == nil || column.Name != "UserId" {
+		t.Errorf("Column(%q) = %v, expected UserId", "userid", column)
+	}
+	if column := users.Column("name"); column != nil {
+		t.Errorf("Column(%q) = %v, expected nil", "name", column)
+	}
+}
+
+func TestColumn_BaseTypeAndMaxLength(t *testing.T) {
+	tests := []struct {
+		typ       string
+		baseType  string
+		maxLength int
+	}{
+		{"INT64", "INT64", 0},
+		{"STRING(100)", "STRING", 100},
+		{"STRING(MAX)", "STRING", 0},
+		{"BYTES(16)", "BYTES", 16},
+		{"ARRAY<STRING(10)>", "ARRAY<STRING>", 10},
+		{"ARRAY<INT64>", "ARRAY<INT64>", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			c := &Column{Name: "c", Type: tt.typ}
+			if result := c.BaseType(); result != tt.baseType {
+				t.Errorf("BaseType() = %q, expected %q", result, tt.baseType)
+			}
+			if result := c.MaxLength(); result != tt.maxLength {
+				t.Errorf("MaxLength() = %d, expected %d", result, tt.maxLength)
+			}
+		})
+	}
+}
+
+func tableNames(tables []*Table) []string {
+	var names []string
+	for _, t := range tables {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func TestSchema_Order(t *testing.T) {
+	s := &Schema{Tables: []*Table{
+		{Name: "comments", ForeignKeys: []*ForeignKey{
+			{Columns: []string{"post_id"}, ReferencedTable: "posts", ReferencedColumns: []string{"id"}},
+			{Columns: []string{"user_id"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}},
+		}},
+		{Name: "posts", ForeignKeys: []*ForeignKey{
+			{Columns: []string{"user_id"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}},
+		}},
+		{Name: "tags"},
+		{Name: "albums", Parent: "singers"},
+		{Name: "singers"},
+		{Name: "users", ForeignKeys: []*ForeignKey{
+			{Columns: []string{"manager_id"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}},
+		}},
+	}}
+
+	ordered, err := s.Order()
+	if err != nil {
+		t.Fatalf("Order() unexpected error: %v", err)
+	}
+
+	expected := "users,posts,comments,tags,singers,albums"
+	if result := strings.Join(tableNames(ordered), ","); result != expected {
+		t.Errorf("Order() = %s, expected %s", result, expected)
+	}
+}
+
+func TestSchema_OrderCycle(t *testing.T) {
+	s := &Schema{Tables: []*Table{
+		{Name: "a", ForeignKeys: []*ForeignKey{{ReferencedTable: "b"}}},
+		{Name: "b", ForeignKeys: []*ForeignKey{{ReferencedTable: "a"}}},
+	}}
+
+	_, err := s.Order()
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> a") {
+		t.Errorf("Order() error = %v, expected cycle a -> b -> a", err)
+	}
+}
@@ -0,0 +1,400 @@
+// Package shell implements an interactive SQL shell for the Spanner emulator.
+package shell
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/nu0ma/spemu/pkg/output"
+	"github.com/nu0ma/spemu/pkg/parser"
+)
+
+const (
+	prompt             = "spemu> "
+	continuationPrompt = "    -> "
+)
+
+const helpText = `Statements are terminated by ';' and may span multiple lines.
+
+  SELECT ...;                      Run a query
+  INSERT/UPDATE/DELETE ...;        Run DML (auto-committed outside a transaction)
+  BEGIN; COMMIT; ROLLBACK;         Control an explicit read-write transaction
+
+Meta-commands:
+  \dt                              List tables
+  \d <table>                       Describe a table
+  \history                         Show statement history
+  \?                               Show this help
+  \q                               Quit
+`
+
+// Shell reads statements and meta-commands and executes them against a
+// database.
+type Shell struct {
+	client *spanner.Client
+	out    io.Writer
+
+	// Interactive enables prompts.
+	Interactive bool
+	// Dialect is the SQL dialect input is split into statements by.
+	Dialect parser.Dialect
+	// HistoryFile is the file history is loaded from and appended to. History
+	// is kept in memory only when it is empty.
+	HistoryFile string
+
+	txn     *spanner.ReadWriteStmtBasedTransaction
+	history []string
+}
+
+// New creates a shell that executes statements with client and writes
+// results to out.
+func New(client *spanner.Client, out io.Writer) *Shell {
+	return &Shell{client: client, out: out}
+}
+
+// Run reads input until EOF or \q. Errors from individual statements are
+// reported and do not stop the shell. An open transaction is rolled back
+// when the shell exits.
+func (s *Shell) Run(in io.Reader) error {
+	s.loadHistory()
+	defer s.rollbackOnExit()
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var buf strings.Builder
+	s.prompt(false)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if buf.Len() == 0 && strings.HasPrefix(strings.TrimSpace(line), `\`) {
+			command := strings.TrimSpace(line)
+			s.addHistory(command)
+			quit, err := s.meta(command)
+			if err != nil {
+				fmt.Fprintf(s.out, "ERROR: %v\n", err)
+			}
+			if quit {
+				return nil
+			}
+			s.prompt(false)
+			continue
+		}
+
+		buf.WriteString(line)
+		buf.WriteString("\n")
+
+		statements, rest := s.Dialect.SplitStatements(buf.String())
+		for _, stmt := range statements {
+			s.addHistory(stmt + ";")
+			if err := s.execute(stmt); err != nil {
+				fmt.Fprintf(s.out, "ERROR: %v\n", err)
+			}
+		}
+
+		buf.Reset()
+		if strings.TrimSpace(rest) != "" {
+			buf.WriteString(rest)
+		}
+		s.prompt(buf.Len() > 0)
+	}
+
+	return scanner.Err()
+}
+
+func (s *Shell) prompt(continuation bool) {
+	if !s.Interactive {
+		return
+	}
+	if continuation {
+		fmt.Fprint(s.out, continuationPrompt)
+		return
+	}
+	fmt.Fprint(s.out, prompt)
+}
+
+// execute runs a single statement.
+func (s *Shell) execute(stmt string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	switch keyword(stmt) {
+	case "BEGIN", "START":
+		if s.txn != nil {
+			return fmt.Errorf("a transaction is already in progress")
+		}
+		txn, err := spanner.NewReadWriteStmtBasedTransaction(ctx, s.client)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		s.txn = txn
+		fmt.Fprintln(s.out, "BEGIN")
+	case "COMMIT":
+		if s.txn == nil {
+			return fmt.Errorf("no transaction in progress")
+		}
+		txn := s.txn
+		s.txn = nil
+		if _, err := txn.Commit(ctx); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		fmt.Fprintln(s.out, "COMMIT")
+	case "ROLLBACK":
+		if s.txn == nil {
+			return fmt.Errorf("no transaction in progress")
+		}
+		s.txn.Rollback(ctx)
+		s.txn = nil
+		fmt.Fprintln(s.out, "ROLLBACK")
+	case "SELECT", "WITH", "GRAPH":
+		return s.query(ctx, stmt)
+	case "INSERT", "UPDATE", "DELETE":
+		return s.update(ctx, stmt)
+	default:
+		return fmt.Errorf("unsupported statement: %s", firstLine(stmt))
+	}
+
+	return nil
+}
+
+func (s *Shell) query(ctx context.Context, stmt string) error {
+	var iter *spanner.RowIterator
+	if s.txn != nil {
+		iter = s.txn.Query(ctx, spanner.Statement{SQL: stmt})
+	} else {
+		iter = s.client.Single().Query(ctx, spanner.Statement{SQL: stmt})
+	}
+
+	res, err := output.Collect(iter)
+	if err != nil {
+		return err
+	}
+
+	return output.WriteTable(s.out, res)
+}
+
+func (s *Shell) update(ctx context.Context, stmt string) error {
+	var count int64
+	var err error
+	if s.txn != nil {
+		count, err = s.txn.Update(ctx, spanner.Statement{SQL: stmt})
+	} else {
+		_, err = s.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+			var err error
+			count, err = txn.Update(ctx, spanner.Statement{SQL: stmt})
+			return err
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	if count == 1 {
+		fmt.Fprintln(s.out, "1 row affected")
+	} else {
+		fmt.Fprintf(s.out, "%d rows affected\n", count)
+	}
+
+	return nil
+}
+
+func (s *Shell) rollbackOnExit() {
+	if s.txn == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	s.txn.Rollback(ctx)
+	s.txn = nil
+	fmt.Fprintln(s.out, "Open transaction rolled back")
+}
+
+// meta runs a meta-command and reports whether the shell should exit.
+func (s *Shell) meta(command string) (bool, error) {
+	fields := strings.Fields(command)
+
+	switch fields[0] {
+	case `\q`, `\quit`:
+		return true, nil
+	case `\?`, `\help`:
+		fmt.Fprint(s.out, helpText)
+	case `\history`:
+		for i, entry := range s.history {
+			fmt.Fprintf(s.out, "%5d  %s\n", i+1, entry)
+		}
+	case `\dt`:
+		return false, s.listTables()
+	case `\d`:
+		if len(fields) < 2 {
+			return false, s.listTables()
+		}
+		return false, s.describeTable(fields[1])
+	default:
+		return false, fmt.Errorf("unknown command %s, type \\? for help", fields[0])
+	}
+
+	return false, nil
+}
+
+// catalogQuery is a query of a meta-command on INFORMATION_SCHEMA.
+type catalogQuery int
+
+const (
+	tablesQuery catalogQuery = iota
+	columnsQuery
+	indexesQuery
+)
+
+// catalogQueries are the meta-command queries per dialect. PostgreSQL
+// databases keep the user's tables in the public schema, name the
+// INFORMATION_SCHEMA columns in lower case and bind parameters as $1.
+var catalogQueries = map[parser.Dialect][]string{
+	parser.GoogleSQL: {
+		tablesQuery: `SELECT TABLE_NAME, PARENT_TABLE_NAME
+FROM INFORMATION_SCHEMA.TABLES
+WHERE TABLE_SCHEMA = '' AND TABLE_TYPE = 'BASE TABLE'
+ORDER BY TABLE_NAME`,
+		columnsQuery: `SELECT COLUMN_NAME, SPANNER_TYPE, IS_NULLABLE, COLUMN_DEFAULT
+FROM INFORMATION_SCHEMA.COLUMNS
+WHERE TABLE_SCHEMA = '' AND TABLE_NAME = @table
+ORDER BY ORDINAL_POSITION`,
+		indexesQuery: `SELECT INDEX_NAME, INDEX_TYPE, IS_UNIQUE
+FROM INFORMATION_SCHEMA.INDEXES
+WHERE TABLE_SCHEMA = '' AND TABLE_NAME = @table
+ORDER BY INDEX_TYPE DESC, INDEX_NAME`,
+	},
+	parser.PostgreSQL: {
+		tablesQuery: `SELECT table_name, parent_table_name
+FROM information_schema.tables
+WHERE table_schema = 'public' AND table_type = 'BASE TABLE'
+ORDER BY table_name`,
+		columnsQuery: `SELECT column_name, spanner_type, is_nullable, column_default
+FROM information_schema.columns
+WHERE table_schema = 'public' AND table_name = $1
+ORDER BY ordinal_position`,
+		indexesQuery: `SELECT index_name, index_type, is_unique
+FROM information_schema.indexes
+WHERE table_schema = 'public' AND table_name = $1
+ORDER BY index_type DESC, index_name`,
+	},
+}
+
+// catalogStatement returns a meta-command query in the dialect of the shell,
+// bound to table if it takes one.
+func (s *Shell) catalogStatement(query catalogQuery, table string) spanner.Statement {
+	stmt := spanner.Statement{SQL: catalogQueries[s.Dialect][query]}
+	if query == tablesQuery {
+		return stmt
+	}
+	if s.Dialect == parser.PostgreSQL {
+		stmt.Params = map[string]interface{}{"p1": table}
+	} else {
+		stmt.Params = map[string]interface{}{"table": table}
+	}
+	return stmt
+}
+
+func (s *Shell) listTables() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	iter := s.client.Single().Query(ctx, s.catalogStatement(tablesQuery, ""))
+	res, err := output.Collect(iter)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	return output.WriteTable(s.out, res)
+}
+
+func (s *Shell) describeTable(table string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	iter := s.client.Single().Query(ctx, s.catalogStatement(columnsQuery, table))
+	columns, err := output.Collect(iter)
+	if err != nil {
+		return fmt.Errorf("failed to describe table %s: %w", table, err)
+	}
+	if len(columns.Rows) == 0 {
+		return fmt.Errorf("table %s not found", table)
+	}
+
+	iter = s.client.Single().Query(ctx, s.catalogStatement(indexesQuery, table))
+	indexes, err := output.Collect(iter)
+	if err != nil {
+		return fmt.Errorf("failed to describe table %s: %w", table, err)
+	}
+
+	fmt.Fprintf(s.out, "Table %s\n", table)
+	if err := output.WriteTable(s.out, columns); err != nil {
+		return err
+	}
+	if len(indexes.Rows) > 0 {
+		fmt.Fprintln(s.out, "Indexes:")
+		return output.WriteTable(s.out, indexes)
+	}
+
+	return nil
+}
+
+func (s *Shell) loadHistory() {
+	if s.HistoryFile == "" {
+		return
+	}
+
+	content, err := os.ReadFile(s.HistoryFile)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if line != "" {
+			s.history = append(s.history, line)
+		}
+	}
+}
+
+func (s *Shell) addHistory(entry string) {
+	// Multi-line statements are stored on a single line
+	entry = strings.Join(strings.Split(strings.TrimSpace(entry), "\n"), " ")
+	s.history = append(s.history, entry)
+
+	if s.HistoryFile == "" {
+		return
+	}
+
+	f, err := os.OpenFile(s.HistoryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, entry)
+}
+
+// keyword returns the first word of a statement in upper case.
+func keyword(stmt string) string {
+	fields := strings.FieldsFunc(stmt, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '('
+	})
+	if len(fields) == 0 {
+		return ""
+	}
+
+	return strings.ToUpper(fields[0])
+}
+
+func firstLine(stmt string) string {
+	if idx := strings.IndexByte(stmt, '\n'); idx != -1 {
+		return stmt[:idx] + "..."
+	}
+
+	return stmt
+}
@@ -0,0 +1,176 @@
+package shell
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nu0ma/spemu/pkg/parser"
+)
+
+func TestKeyword(t *testing.T) {
+	tests := []struct {
+		stmt     string
+		expected string
+	}{
+		{"SELECT * FROM users", "SELECT"},
+		{"select 1", "SELECT"},
+		{"\n  insert into users (id) values (1)", "INSERT"},
+		{"(SELECT 1) UNION ALL (SELECT 2)", "SELECT"},
+		{"begin", "BEGIN"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stmt, func(t *testing.T) {
+			if result := keyword(tt.stmt); result != tt.expected {
+				t.Errorf("keyword(%q) = %q, expected %q", tt.stmt, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRun_WithoutDatabase(t *testing.T) {
+	// Only input that does not need a database connection is used here
+	input := strings.Join([]string{
+		`\?`,
+		`CREATE TABLE t (`,
+		`  id INT64`,
+		`) PRIMARY KEY (id);`,
+		`COMMIT;`,
+		`\unknown`,
+		`\history`,
+		`\q`,
+		`ROLLBACK;`,
+	}, "\n")
+
+	var out bytes.Buffer
+	sh := New(nil, &out)
+	if err := sh.Run(strings.NewReader(input)); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	result := out.String()
+	for _, expected := range []string{
+		"Meta-commands:",
+		"ERROR: unsupported statement: CREATE TABLE t (...",
+		"ERROR: no transaction in progress",
+		`ERROR: unknown command \unknown`,
+		"    2  CREATE TABLE t (   id INT64 ) PRIMARY KEY (id);",
+	} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Run() output does not contain %q:\n%s", expected, result)
+		}
+	}
+
+	// Input after \q is not executed
+	if strings.Count(result, "no transaction in progress") != 1 {
+		t.Errorf("Run() executed input after \\q:\n%s", result)
+	}
+}
+
+func TestRun_Prompts(t *testing.T) {
+	var out bytes.Buffer
+	sh := New(nil, &out)
+	sh.Interactive = true
+
+	if err := sh.Run(strings.NewReader("COMMIT\n;\n")); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	expected := prompt + continuationPrompt + "ERROR: no transaction in progress\n" + prompt
+	if out.String() != expected {
+		t.Errorf("Run() output = %q, expected %q", out.String(), expected)
+	}
+}
+
+func TestRun_HistoryFile(t *testing.T) {
+	historyFile := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(historyFile, []byte("SELECT 1;\n"), 0600); err != nil {
+		t.Fatalf("Failed to create history file: %v", err)
+	}
+
+	var out bytes.Buffer
+	sh := New(nil, &out)
+	sh.HistoryFile = historyFile
+
+	if err := sh.Run(strings.NewReader("\\history\n")); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "    1  SELECT 1;") {
+		t.Errorf("Run() did not show loaded history:\n%s", out.String())
+	}
+
+	content, err := os.ReadFile(historyFile)
+	if err != nil {
+		t.Fatalf("Failed to read history file: %v", err)
+	}
+	if string(content) != "SELECT 1;\n\\history\n" {
+		t.Errorf("history file = %q, expected %q", content, "SELECT 1;\n\\history\n")
+	}
+}
+
+func TestCatalogStatement(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  parser.Dialect
+		query    catalogQuery
+		contains []string
+		params   map[string]interface{}
+	}{
+		{
+			name:     "googlesql tables",
+			dialect:  parser.GoogleSQL,
+			query:    tablesQuery,
+			contains: []string{"INFORMATION_SCHEMA.TABLES", "TABLE_SCHEMA = ''"},
+		},
+		{
+			name:     "googlesql columns",
+			dialect:  parser.GoogleSQL,
+			query:    columnsQuery,
+			contains: []string{"INFORMATION_SCHEMA.COLUMNS", "TABLE_SCHEMA = ''", "TABLE_NAME = @table"},
+			params:   map[string]interface{}{"table": "users"},
+		},
+		{
+			name:     "postgresql tables",
+			dialect:  parser.PostgreSQL,
+			query:    tablesQuery,
+			contains: []string{"information_schema.tables", "table_schema = 'public'"},
+		},
+		{
+			name:     "postgresql columns",
+			dialect:  parser.PostgreSQL,
+			query:    columnsQuery,
+			contains: []string{"information_schema.columns", "table_schema = 'public'", "table_name = $1"},
+			params:   map[string]interface{}{"p1": "users"},
+		},
+		{
+			name:     "postgresql indexes",
+			dialect:  parser.PostgreSQL,
+			query:    indexesQuery,
+			contains: []string{"information_schema.indexes", "table_schema = 'public'", "table_name = $1"},
+			params:   map[string]interface{}{"p1": "users"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sh := New(nil, &bytes.Buffer{})
+			sh.Dialect = tt.dialect
+
+			stmt := sh.catalogStatement(tt.query, "users")
+			for _, want := range tt.contains {
+				if !strings.Contains(stmt.SQL, want) {
+					t.Errorf("catalogStatement() SQL does not contain %q:\n%s", want, stmt.SQL)
+				}
+			}
+			if len(stmt.Params) != len(tt.params) || (tt.params != nil && !reflect.DeepEqual(stmt.Params, tt.params)) {
+				t.Errorf("catalogStatement() params = %v, expected %v", stmt.Params, tt.params)
+			}
+		})
+	}
+}
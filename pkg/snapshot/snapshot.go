@@ -0,0 +1,303 @@
+// Package snapshot saves the schema and rows of a database to a directory
+// and loads them back.
+//
+// A snapshot directory holds schema.sql with the DDL of the database, one
+// NDJSON file per table with a JSON object per row, and manifest.json
+// describing the tables in the order they are loaded.
+package snapshot
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/nu0ma/spemu/pkg/executor"
+	"github.com/nu0ma/spemu/pkg/schema"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// SchemaFile is the name of the DDL file in a snapshot directory.
+	SchemaFile   = "schema.sql"
+	manifestFile = "manifest.json"
+)
+
+// Manifest describes a snapshot.
+type Manifest struct {
+	Database  string    `json:"database"`
+	CreatedAt time.Time `json:"created_at"`
+	// Tables are sorted so that every table comes after the tables it
+	// depends on.
+	Tables []*Table `json:"tables"`
+}
+
+// Table describes the rows of a table in a snapshot.
+type Table struct {
+	Name string `json:"name"`
+	// File is the NDJSON file holding the rows, relative to the snapshot.
+	File    string   `json:"file"`
+	Columns []string `json:"columns"`
+	// Types are the Spanner types of the columns, encoded as JSON. They are
+	// empty for tables without rows.
+	Types []json.RawMessage `json:"types,omitempty"`
+	Rows  int64             `json:"rows"`
+}
+
+// Path returns the directory of the snapshot called name in dir. The name
+// must be a single path element, so that it cannot refer to a directory
+// outside dir.
+func Path(dir, name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// Save writes the schema given by ddl and the rows of all tables to dir,
+// replacing any snapshot in dir. Rows are read in a single read-only
+// transaction, so the snapshot is consistent. Generated columns are not
+// saved, since they are computed when the rows are loaded. If dir exists
+// but does not hold a snapshot, it is left alone and an error is returned.
+func Save(ctx context.Context, client *spanner.Client, database string, ddl []string, dir string, verbose bool) (*Manifest, error) {
+	if err := checkReplaceable(dir); err != nil {
+		return nil, err
+	}
+
+	s, err := schema.ParseDDL(ddl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database schema: %w", err)
+	}
+	statements, err := s.DDL()
+	if err != nil {
+		return nil, err
+	}
+	tables, err := s.Order()
+	if err != nil {
+		return nil, fmt.Errorf("failed to order tables: %w", err)
+	}
+
+	// Write to a temporary directory first, so a failed save keeps the
+	// previous snapshot
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	schemaSQL := strings.Join(statements, ";\n\n")
+	if len(statements) > 0 {
+		schemaSQL += ";\n"
+	}
+	if err := os.WriteFile(filepath.Join(tmp, SchemaFile), []byte(schemaSQL), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write schema: %w", err)
+	}
+
+	txn := client.ReadOnlyTransaction()
+	defer txn.Close()
+
+	m := &Manifest{Database: database, CreatedAt: time.Now().UTC()}
+	for _, t := range tables {
+		table := &Table{Name: t.Name, File: t.Name + ".ndjson"}
+		for _, c := range t.Columns {
+			if !c.Generated {
+				table.Columns = append(table.Columns, c.Name)
+			}
+		}
+
+		if err := saveTable(ctx, txn, table, filepath.Join(tmp, table.File)); err != nil {
+			return nil, err
+		}
+		if verbose {
+			fmt.Printf("Saved %d rows from %s\n", table.Rows, table.Name)
+		}
+		m.Tables = append(m.Tables, table)
+	}
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, manifestFile), append(manifest, '\n'), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return nil, fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+
+	return m, nil
+}
+
+// checkReplaceable returns an error if dir exists and is not a snapshot
+func checkReplaceable(dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to check snapshot directory: %w", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, manifestFile)); err != nil {
+		return fmt.Errorf("%s exists and is not a snapshot", dir)
+	}
+	return nil
+}
+
+func saveTable(ctx context.Context, txn *spanner.ReadOnlyTransaction, table *Table, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	err = txn.Read(ctx, table.Name, spanner.AllKeys(), table.Columns).Do(func(row *spanner.Row) error {
+		values := make([]spanner.GenericColumnValue, row.Size())
+		for i := range values {
+			if err := row.Column(i, &values[i]); err != nil {
+				return err
+			}
+		}
+		if table.Types == nil {
+			for _, v := range values {
+				typ, err := protojson.Marshal(v.Type)
+				if err != nil {
+					return err
+				}
+				table.Types = append(table.Types, typ)
+			}
+		}
+
+		line, err := encodeRow(table.Columns, values)
+		if err != nil {
+			return err
+		}
+		table.Rows++
+		_, err = w.Write(append(line, '\n'))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read rows from %s: %w", table.Name, err)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// Load reads the manifest of the snapshot in dir.
+func Load(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Join(dir, manifestFile), err)
+	}
+	return &m, nil
+}
+
+// Restore inserts the rows of the snapshot in dir with mutations, table by
+// table in the order of the manifest, committing in chunks that stay within
+// Spanner's mutation limit. The tables must exist and be empty, e.g. in a
+// database created from the schema of the snapshot.
+func Restore(ctx context.Context, client *spanner.Client, dir string, verbose bool) (*Manifest, error) {
+	m, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, table := range m.Tables {
+		if table.Rows == 0 {
+			continue
+		}
+		if err := restoreTable(ctx, client, table, filepath.Join(dir, table.File), verbose); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func restoreTable(ctx context.Context, client *spanner.Client, table *Table, path string, verbose bool) error {
+	if len(table.Types) != len(table.Columns) {
+		return fmt.Errorf("%s: expected %d column types, got %d", table.Name, len(table.Columns), len(table.Types))
+	}
+	types := make([]*spannerpb.Type, len(table.Types))
+	for i, raw := range table.Types {
+		types[i] = &spannerpb.Type{}
+		if err := protojson.Unmarshal(raw, types[i]); err != nil {
+			return fmt.Errorf("%s.%s: invalid type: %w", table.Name, table.Columns[i], err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	ins := executor.NewInserter(client, table.Name, table.Columns)
+	if verbose {
+		ins.OnCommit = func(inserted int64) {
+			fmt.Printf("Inserted %d/%d rows into %s\n", inserted, table.Rows, table.Name)
+		}
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for line := 1; ; line++ {
+		var row map[string]interface{}
+		if err := dec.Decode(&row); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("%s: row %d: %w", path, line, err)
+		}
+
+		values, err := decodeRow(row, table.Columns, types)
+		if err != nil {
+			return fmt.Errorf("%s: row %d: %w", path, line, err)
+		}
+		if err := ins.Add(ctx, values); err != nil {
+			return err
+		}
+	}
+	return ins.Flush(ctx)
+}
+
+// encodeRow encodes the values of a row as a JSON object. Values keep their
+// Spanner encoding, e.g. INT64 values are strings and BYTES values base64.
+func encodeRow(columns []string, values []spanner.GenericColumnValue) ([]byte, error) {
+	row := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		row[column] = values[i].Value.AsInterface()
+	}
+	return json.Marshal(row)
+}
+
+// decodeRow turns a row decoded from JSON into values for a mutation.
+// Missing columns are NULL.
+func decodeRow(row map[string]interface{}, columns []string, types []*spannerpb.Type) ([]interface{}, error) {
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		v, err := structpb.NewValue(row[column])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", column, err)
+		}
+		values[i] = spanner.GenericColumnValue{Type: types[i], Value: v}
+	}
+	return values, nil
+}
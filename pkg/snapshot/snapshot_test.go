@@ -0,0 +1,140 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestEncodeDecodeRow(t *testing.T) {
+	columns := []string{"id", "score", "data", "tags", "note"}
+	types := []*spannerpb.Type{
+		{Code: spannerpb.TypeCode_INT64},
+		{Code: spannerpb.TypeCode_FLOAT64},
+		{Code: spannerpb.TypeCode_BYTES},
+		{Code: spannerpb.TypeCode_ARRAY, ArrayElementType: &spannerpb.Type{Code: spannerpb.TypeCode_STRING}},
+		{Code: spannerpb.TypeCode_STRING},
+	}
+	values := []spanner.GenericColumnValue{
+		{Type: types[0], Value: structpb.NewStringValue("9007199254740993")},
+		{Type: types[1], Value: structpb.NewNumberValue(0.1)},
+		{Type: types[2], Value: structpb.NewStringValue("AP8=")},
+		{Type: types[3], Value: structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{
+			structpb.NewStringValue("a"), structpb.NewNullValue(),
+		}})},
+		{Type: types[4], Value: structpb.NewNullValue()},
+	}
+
+	line, err := encodeRow(columns, values)
+	if err != nil {
+		t.Fatalf("encodeRow() error = %v", err)
+	}
+	expected := `{"data":"AP8=","id":"9007199254740993","note":null,"score":0.1,"tags":["a",null]}`
+	if string(line) != expected {
+		t.Errorf("encodeRow() = %s, expected %s", line, expected)
+	}
+
+	var row map[string]interface{}
+	if err := json.Unmarshal(line, &row); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeRow(row, columns, types)
+	if err != nil {
+		t.Fatalf("decodeRow() error = %v", err)
+	}
+	for i, v := range decoded {
+		gcv := v.(spanner.GenericColumnValue)
+		if !proto.Equal(gcv.Type, values[i].Type) || !proto.Equal(gcv.Value, values[i].Value) {
+			t.Errorf("decodeRow() column %s = %v, expected %v", columns[i], gcv, values[i])
+		}
+	}
+
+	// Columns missing from the row are NULL
+	decoded, err = decodeRow(map[string]interface{}{}, columns[:1], types[:1])
+	if err != nil {
+		t.Fatalf("decodeRow() error = %v", err)
+	}
+	if v := decoded[0].(spanner.GenericColumnValue).Value; !proto.Equal(v, structpb.NewNullValue()) {
+		t.Errorf("decodeRow() missing column = %v, expected NULL", v)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Load(dir); err == nil || !strings.Contains(err.Error(), "failed to read snapshot") {
+		t.Errorf("Load() error = %v, expected a missing snapshot", err)
+	}
+
+	manifest := `{"database": "test-database", "created_at": "2024-01-01T00:00:00Z", "tables": [
+  {"name": "users", "file": "users.ndjson", "columns": ["id"], "types": [{"code": "INT64"}], "rows": 2}
+]}`
+	if err := os.WriteFile(filepath.Join(dir, manifestFile), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if m.Database != "test-database" || len(m.Tables) != 1 || m.Tables[0].Rows != 2 || m.Tables[0].File != "users.ndjson" {
+		t.Errorf("Load() = %+v", m)
+	}
+}
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		wantErr  bool
+	}{
+		{name: "baseline", expected: filepath.Join("snapshots", "baseline")},
+		{name: "v1.2", expected: filepath.Join("snapshots", "v1.2")},
+		{name: "", wantErr: true},
+		{name: ".", wantErr: true},
+		{name: "..", wantErr: true},
+		{name: "../../src", wantErr: true},
+		{name: "a/b", wantErr: true},
+		{name: `a\b`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := Path("snapshots", tt.name)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Path(%q) = %q, expected an error", tt.name, path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Path(%q) error = %v", tt.name, err)
+			}
+			if path != tt.expected {
+				t.Errorf("Path(%q) = %q, expected %q", tt.name, path, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSave_KeepsOtherDirectories(t *testing.T) {
+	dir := t.TempDir()
+	keep := filepath.Join(dir, "keep.txt")
+	if err := os.WriteFile(keep, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Save(context.Background(), nil, "test-database", nil, dir, false)
+	if err == nil || !strings.Contains(err.Error(), "is not a snapshot") {
+		t.Errorf("Save() error = %v, expected a refusal to replace the directory", err)
+	}
+	if _, err := os.Stat(keep); err != nil {
+		t.Errorf("Save() removed a directory that is not a snapshot: %v", err)
+	}
+}
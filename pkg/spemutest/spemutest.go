@@ -0,0 +1,230 @@
+// Package spemutest provides helpers for running Go tests against isolated
+// databases on the Spanner emulator.
+//
+// Each call to New creates a uniquely named database, so tests using it can
+// call t.Parallel() without sharing state:
+//
+//	func TestUsers(t *testing.T) {
+//		t.Parallel()
+//		client := spemutest.New(t,
+//			spemutest.Schema("testdata/schema.sql"),
+//			spemutest.Seed("testdata/seed.sql"),
+//		)
+//		// use client ...
+//	}
+package spemutest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/nu0ma/spemu/pkg/config"
+	"github.com/nu0ma/spemu/pkg/executor"
+	"github.com/nu0ma/spemu/pkg/parser"
+)
+
+const (
+	defaultProjectID    = "test-project"
+	defaultInstanceID   = "test-instance"
+	defaultEmulatorHost = "localhost:9010"
+)
+
+type options struct {
+	projectID    string
+	instanceID   string
+	emulatorHost string
+	schema       *source
+	seeds        []source
+}
+
+// source is a file path or glob, read from fsys or from the OS when fsys is nil.
+type source struct {
+	fsys    fs.FS
+	pattern string
+}
+
+// Option configures the database created by New.
+type Option func(*options)
+
+// Schema initializes the database with the DDL statements in the given file.
+func Schema(path string) Option {
+	return func(o *options) {
+		o.schema = &source{pattern: path}
+	}
+}
+
+// SchemaFS initializes the database with the DDL statements in the files in
+// fsys matching pattern, e.g. an embed.FS.
+func SchemaFS(fsys fs.FS, pattern string) Option {
+	return func(o *options) {
+		o.schema = &source{fsys: fsys, pattern: pattern}
+	}
+}
+
+// Seed loads the DML statements in the given files after the schema has been
+// applied. Files are executed in order, each in its own transaction.
+func Seed(paths ...string) Option {
+	return func(o *options) {
+		for _, path := range paths {
+			o.seeds = append(o.seeds, source{pattern: path})
+		}
+	}
+}
+
+// SeedFS loads the DML statements in the files in fsys matching the given
+// patterns, e.g. an embed.FS. Seeds are executed in the order the Seed and
+// SeedFS options are given.
+func SeedFS(fsys fs.FS, patterns ...string) Option {
+	return func(o *options) {
+		for _, pattern := range patterns {
+			o.seeds = append(o.seeds, source{fsys: fsys, pattern: pattern})
+		}
+	}
+}
+
+// Project sets the emulator project ID (default: test-project).
+func Project(id string) Option {
+	return func(o *options) {
+		o.projectID = id
+	}
+}
+
+// Instance sets the emulator instance ID (default: test-instance).
+func Instance(id string) Option {
+	return func(o *options) {
+		o.instanceID = id
+	}
+}
+
+// EmulatorHost sets the emulator address. It defaults to the
+// SPANNER_EMULATOR_HOST environment variable, or localhost:9010.
+func EmulatorHost(host string) Option {
+	return func(o *options) {
+		o.emulatorHost = host
+	}
+}
+
+// New creates a database with a unique name on the emulator, applies the
+// schema and seed files and returns a client connected to it. The client is
+// closed and the database dropped when the test finishes.
+func New(t testing.TB, opts ...Option) *spanner.Client {
+	t.Helper()
+
+	o := &options{
+		projectID:    defaultProjectID,
+		instanceID:   defaultInstanceID,
+		emulatorHost: os.Getenv("SPANNER_EMULATOR_HOST"),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.emulatorHost == "" {
+		o.emulatorHost = defaultEmulatorHost
+	}
+
+	cfg := &config.Config{
+		ProjectID:    o.projectID,
+		InstanceID:   o.instanceID,
+		DatabaseID:   databaseID(t.Name()),
+		EmulatorHost: o.emulatorHost,
+	}
+
+	var err error
+	switch {
+	case o.schema == nil:
+		err = executor.InitializeSchema(cfg, "", false)
+	case o.schema.fsys != nil:
+		err = executor.InitializeSchemaFS(cfg, o.schema.fsys, o.schema.pattern, false)
+	default:
+		err = executor.InitializeSchema(cfg, o.schema.pattern, false)
+	}
+	if err != nil {
+		t.Fatalf("spemutest: failed to initialize database %s: %v", cfg.DatabaseID, err)
+	}
+	t.Cleanup(func() {
+		if err := executor.DropDatabase(cfg); err != nil {
+			t.Logf("spemutest: failed to drop database %s: %v", cfg.DatabaseID, err)
+		}
+	})
+
+	if len(o.seeds) > 0 {
+		if err := seed(cfg, o.seeds); err != nil {
+			t.Fatalf("spemutest: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client, err := spanner.NewClient(ctx, cfg.DatabasePath())
+	if err != nil {
+		t.Fatalf("spemutest: failed to create Spanner client: %v", err)
+	}
+	// Registered after the drop so that it runs first
+	t.Cleanup(client.Close)
+
+	return client
+}
+
+func seed(cfg *config.Config, seeds []source) error {
+	exec, err := executor.New(cfg)
+	if err != nil {
+		return err
+	}
+	defer exec.Close()
+
+	for _, src := range seeds {
+		var statements []string
+		if src.fsys != nil {
+			statements, err = parser.ParseDMLFS(src.fsys, src.pattern)
+		} else {
+			statements, err = parser.ParseDMLFile(src.pattern)
+		}
+		if err != nil {
+			return err
+		}
+		if err := exec.ExecuteStatements(statements, false); err != nil {
+			return fmt.Errorf("failed to load seed %s: %w", src.pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// databaseID derives a valid, unique database ID from a test name. Database
+// IDs must start with a letter, contain only lowercase letters, digits,
+// underscores and hyphens, and be at most 30 characters long.
+func databaseID(testName string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(testName) {
+		if b.Len() >= 16 {
+			break
+		}
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "_"):
+			b.WriteByte('_')
+		}
+	}
+
+	prefix := strings.TrimSuffix(b.String(), "_")
+	if prefix == "" || prefix[0] < 'a' || prefix[0] > 'z' {
+		prefix = "t" + prefix
+	}
+
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		panic(err)
+	}
+
+	return prefix + "-" + hex.EncodeToString(suffix)
+}
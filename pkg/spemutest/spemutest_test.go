@@ -0,0 +1,50 @@
+package spemutest
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var validDatabaseID = regexp.MustCompile(`^[a-z][a-z0-9_\-]*[a-z0-9]$`)
+
+func TestDatabaseID(t *testing.T) {
+	tests := []struct {
+		name     string
+		testName string
+		prefix   string
+	}{
+		{"simple name", "TestUsers", "testusers-"},
+		{"subtest", "TestUsers/insert_one", "testusers_insert-"},
+		{"long name", "TestAVeryLongTestNameThatExceedsTheLimit", "testaverylongtes-"},
+		{"leading digit", "123", "t123-"},
+		{"only symbols", "///", "t-"},
+		{"empty", "", "t-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := databaseID(tt.testName)
+			if !strings.HasPrefix(result, tt.prefix) {
+				t.Errorf("databaseID(%q) = %q, expected prefix %q", tt.testName, result, tt.prefix)
+			}
+			if len(result) > 30 {
+				t.Errorf("databaseID(%q) = %q is longer than 30 characters", tt.testName, result)
+			}
+			if !validDatabaseID.MatchString(result) {
+				t.Errorf("databaseID(%q) = %q is not a valid database ID", tt.testName, result)
+			}
+		})
+	}
+}
+
+func TestDatabaseID_Unique(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := databaseID("TestUnique")
+		if seen[id] {
+			t.Fatalf("databaseID() returned duplicate ID %q", id)
+		}
+		seen[id] = true
+	}
+}
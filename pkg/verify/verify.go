@@ -0,0 +1,435 @@
+// Package verify checks the contents of a database against declared
+// expectations.
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/nu0ma/spemu/pkg/output"
+	"github.com/nu0ma/spemu/pkg/parser"
+	"github.com/nu0ma/spemu/pkg/schema"
+	"google.golang.org/protobuf/types/known/structpb"
+	"gopkg.in/yaml.v3"
+)
+
+// Expectations describe the expected state of a database.
+type Expectations struct {
+	Tables map[string]TableExpectation `yaml:"tables"`
+	// Assertions are boolean SQL expressions that must evaluate to true,
+	// optionally written as ASSERT <expr> [AS 'message'].
+	Assertions []string `yaml:"assertions"`
+}
+
+// TableExpectation describes the expected contents of a table.
+type TableExpectation struct {
+	// Count is the expected number of rows, if set.
+	Count *int64 `yaml:"count"`
+	// Rows must exist in the table. Rows are matched by primary key when all
+	// key columns are given, and by all given columns otherwise. Columns that
+	// are not listed are not compared.
+	Rows []map[string]interface{} `yaml:"rows"`
+}
+
+// Report is the outcome of a verification.
+type Report struct {
+	Checks   int
+	Failures []string
+}
+
+// OK reports whether all checks passed.
+func (r *Report) OK() bool {
+	return len(r.Failures) == 0
+}
+
+func (r *Report) fail(format string, args ...interface{}) {
+	r.Failures = append(r.Failures, fmt.Sprintf(format, args...))
+}
+
+// LoadFile reads expectations from a YAML file, or from a SQL file (.sql)
+// containing ASSERT statements.
+func LoadFile(path string) (*Expectations, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".sql") {
+		assertions, err := ParseAssertions(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return &Expectations{Assertions: assertions}, nil
+	}
+
+	exp := &Expectations{}
+	decoder := yaml.NewDecoder(strings.NewReader(string(content)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(exp); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return exp, nil
+}
+
+// ParseAssertions parses SQL content consisting of ASSERT statements.
+func ParseAssertions(content string) ([]string, error) {
+	statements, rest := parser.SplitStatements(content)
+	if rest = strings.TrimSpace(rest); rest != "" {
+		statements = append(statements, rest)
+	}
+
+	for _, stmt := range statements {
+		if !strings.EqualFold(firstWord(stmt), "ASSERT") {
+			return nil, fmt.Errorf("expected an ASSERT statement: %s", stmt)
+		}
+	}
+
+	return statements, nil
+}
+
+// Verify checks the database client is connected to against exp.
+func Verify(ctx context.Context, client *spanner.Client, exp *Expectations) (*Report, error) {
+	report := &Report{}
+
+	if len(exp.Tables) > 0 {
+		s, err := schema.Load(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, 0, len(exp.Tables))
+		for name := range exp.Tables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			table := s.Table(name)
+			if table == nil {
+				report.Checks++
+				report.fail("%s: table does not exist", name)
+				continue
+			}
+			if err := verifyTable(ctx, client, table, exp.Tables[name], report); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, assertion := range exp.Assertions {
+		if err := verifyAssertion(ctx, client, assertion, report); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+func verifyTable(ctx context.Context, client *spanner.Client, table *schema.Table, exp TableExpectation, report *Report) error {
+	if exp.Count != nil {
+		report.Checks++
+		var count int64
+		err := queryRow(ctx, client, spanner.Statement{SQL: fmt.Sprintf("SELECT COUNT(*) FROM `%s`", table.Name)}, &count)
+		if err != nil {
+			return fmt.Errorf("failed to count rows in %s: %w", table.Name, err)
+		}
+		if count != *exp.Count {
+			report.fail("%s: expected %d rows, got %d", table.Name, *exp.Count, count)
+		}
+	}
+
+	if len(exp.Rows) == 0 {
+		return nil
+	}
+
+	// Read the primary key and every column mentioned in the expected rows
+	columns := append([]string{}, table.PrimaryKey...)
+	for _, row := range exp.Rows {
+		for name := range row {
+			column := table.Column(name)
+			if column == nil {
+				return fmt.Errorf("%s: unknown column %s", table.Name, name)
+			}
+			if !slices.ContainsFunc(columns, func(c string) bool { return strings.EqualFold(c, column.Name) }) {
+				columns = append(columns, column.Name)
+			}
+		}
+	}
+
+	quoted := make([]string, len(columns))
+	for i, name := range columns {
+		quoted[i] = "`" + name + "`"
+	}
+	iter := client.Single().Query(ctx, spanner.Statement{
+		SQL: fmt.Sprintf("SELECT %s FROM `%s`", strings.Join(quoted, ", "), table.Name),
+	})
+	actual, err := output.Collect(iter)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", table.Name, err)
+	}
+
+	for _, row := range exp.Rows {
+		report.Checks++
+		verifyRow(table, columns, row, actual, report)
+	}
+
+	return nil
+}
+
+func verifyRow(table *schema.Table, columns []string, expected map[string]interface{}, actual *output.Result, report *Report) {
+	index := make(map[string]int, len(columns))
+	for i, name := range columns {
+		index[strings.ToLower(name)] = i
+	}
+
+	// Expected column names in a stable order for messages
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return index[strings.ToLower(names[i])] < index[strings.ToLower(names[j])]
+	})
+
+	byKey := len(table.PrimaryKey) > 0
+	for _, key := range table.PrimaryKey {
+		if _, ok := lookup(expected, key); !ok {
+			byKey = false
+		}
+	}
+
+	if byKey {
+		label := rowLabel(table.Name, table.PrimaryKey, expected)
+		for _, row := range actual.Rows {
+			if !rowMatches(row, index, expected, table.PrimaryKey) {
+				continue
+			}
+			var diffs []string
+			for _, name := range names {
+				v := row[index[strings.ToLower(name)]]
+				if !valueMatches(expected[name], v) {
+					diffs = append(diffs, fmt.Sprintf("%s: expected %s, got %s", name, describeExpected(expected[name]), describeActual(v)))
+				}
+			}
+			if len(diffs) > 0 {
+				report.fail("%s: %s", label, strings.Join(diffs, "; "))
+			}
+			return
+		}
+		report.fail("%s: row not found", label)
+		return
+	}
+
+	for _, row := range actual.Rows {
+		if rowMatches(row, index, expected, names) {
+			return
+		}
+	}
+	report.fail("%s: no row matching %s", table.Name, rowLabel("", names, expected))
+}
+
+func rowMatches(row []spanner.GenericColumnValue, index map[string]int, expected map[string]interface{}, columns []string) bool {
+	for _, name := range columns {
+		value, _ := lookup(expected, name)
+		if !valueMatches(value, row[index[strings.ToLower(name)]]) {
+			return false
+		}
+	}
+	return true
+}
+
+func verifyAssertion(ctx context.Context, client *spanner.Client, assertion string, report *Report) error {
+	report.Checks++
+
+	expr, message := parseAssertion(assertion)
+	var result spanner.NullBool
+	err := queryRow(ctx, client, spanner.Statement{SQL: "SELECT " + expr}, &result)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate assertion %s: %w", expr, err)
+	}
+
+	if !result.Valid || !result.Bool {
+		if message != "" {
+			report.fail("assertion failed: %s (%s)", message, expr)
+		} else {
+			report.fail("assertion failed: %s", expr)
+		}
+	}
+
+	return nil
+}
+
+var assertionMessage = regexp.MustCompile(`(?is)\s+AS\s+('(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*")\s*$`)
+
+// parseAssertion splits ASSERT <expr> [AS 'message'] into the expression
+// and the message. The ASSERT keyword is optional.
+func parseAssertion(assertion string) (string, string) {
+	expr := strings.TrimSpace(assertion)
+	if strings.EqualFold(firstWord(expr), "ASSERT") {
+		expr = strings.TrimSpace(expr[len("ASSERT"):])
+	}
+
+	var message string
+	if m := assertionMessage.FindStringSubmatchIndex(expr); m != nil {
+		quoted := expr[m[2]:m[3]]
+		message = quoted[1 : len(quoted)-1]
+		expr = strings.TrimSpace(expr[:m[0]])
+	}
+
+	return expr, message
+}
+
+func queryRow(ctx context.Context, client *spanner.Client, stmt spanner.Statement, dest interface{}) error {
+	iter := client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err != nil {
+		return err
+	}
+	return row.Columns(dest)
+}
+
+// valueMatches compares a value decoded from YAML with a column value.
+func valueMatches(expected interface{}, actual spanner.GenericColumnValue) bool {
+	return matches(expected, actual.Type, actual.Value)
+}
+
+func matches(expected interface{}, t *spannerpb.Type, v *structpb.Value) bool {
+	if _, isNull := v.GetKind().(*structpb.Value_NullValue); isNull || v == nil {
+		return expected == nil
+	}
+	if expected == nil {
+		return false
+	}
+
+	switch t.GetCode() {
+	case spannerpb.TypeCode_ARRAY:
+		elems, ok := expected.([]interface{})
+		actual := v.GetListValue().GetValues()
+		if !ok || len(elems) != len(actual) {
+			return false
+		}
+		for i := range elems {
+			if !matches(elems[i], t.ArrayElementType, actual[i]) {
+				return false
+			}
+		}
+		return true
+	case spannerpb.TypeCode_JSON:
+		var want, got interface{}
+		if s, ok := expected.(string); ok {
+			if json.Unmarshal([]byte(s), &want) != nil {
+				return false
+			}
+		} else {
+			encoded, err := json.Marshal(expected)
+			if err != nil || json.Unmarshal(encoded, &want) != nil {
+				return false
+			}
+		}
+		if json.Unmarshal([]byte(v.GetStringValue()), &got) != nil {
+			return false
+		}
+		return fmt.Sprint(want) == fmt.Sprint(got)
+	case spannerpb.TypeCode_FLOAT64, spannerpb.TypeCode_FLOAT32, spannerpb.TypeCode_NUMERIC:
+		want, ok := new(big.Rat).SetString(scalarText(expected))
+		got, ok2 := new(big.Rat).SetString(output.FormatValue(spanner.GenericColumnValue{Type: t, Value: v}))
+		if !ok || !ok2 {
+			return scalarText(expected) == output.FormatValue(spanner.GenericColumnValue{Type: t, Value: v})
+		}
+		return want.Cmp(got) == 0
+	case spannerpb.TypeCode_TIMESTAMP:
+		want, err := time.Parse(time.RFC3339Nano, scalarText(expected))
+		if err != nil {
+			return false
+		}
+		got, err := time.Parse(time.RFC3339Nano, v.GetStringValue())
+		return err == nil && want.Equal(got)
+	case spannerpb.TypeCode_DATE:
+		if date, ok := expected.(time.Time); ok {
+			return date.Format("2006-01-02") == v.GetStringValue()
+		}
+		return scalarText(expected) == v.GetStringValue()
+	default:
+		return scalarText(expected) == output.FormatValue(spanner.GenericColumnValue{Type: t, Value: v})
+	}
+}
+
+// scalarText renders a value decoded from YAML the way column values are
+// rendered by output.FormatValue.
+func scalarText(v interface{}) string {
+	switch value := v.(type) {
+	case time.Time:
+		// Unquoted YAML timestamps and dates
+		return value.UTC().Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func describeExpected(v interface{}) string {
+	if v == nil {
+		return "NULL"
+	}
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return scalarText(v)
+}
+
+func describeActual(v spanner.GenericColumnValue) string {
+	text := output.FormatValue(v)
+	if v.Type.GetCode() == spannerpb.TypeCode_STRING && text != "NULL" {
+		return strconv.Quote(text)
+	}
+	return text
+}
+
+func rowLabel(table string, columns []string, row map[string]interface{}) string {
+	parts := make([]string, len(columns))
+	for i, name := range columns {
+		value, _ := lookup(row, name)
+		parts[i] = fmt.Sprintf("%s=%s", name, describeExpected(value))
+	}
+	if table == "" {
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+	return fmt.Sprintf("%s[%s]", table, strings.Join(parts, ", "))
+}
+
+// lookup finds a column value in an expected row, ignoring case.
+func lookup(row map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := row[name]; ok {
+		return v, true
+	}
+	for key, v := range row {
+		if strings.EqualFold(key, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func firstWord(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
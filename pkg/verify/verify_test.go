@@ -0,0 +1,208 @@
+package verify
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/nu0ma/spemu/pkg/output"
+	"github.com/nu0ma/spemu/pkg/schema"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestParseAssertion(t *testing.T) {
+	tests := []struct {
+		name      string
+		assertion string
+		expr      string
+		message   string
+	}{
+		{"with keyword", "ASSERT (SELECT COUNT(*) FROM users) = 3", "(SELECT COUNT(*) FROM users) = 3", ""},
+		{"without keyword", "(SELECT COUNT(*) FROM users) = 3", "(SELECT COUNT(*) FROM users) = 3", ""},
+		{"lowercase", "assert true", "true", ""},
+		{"with message", "ASSERT (SELECT COUNT(*) FROM users) = 3 AS 'three users'", "(SELECT COUNT(*) FROM users) = 3", "three users"},
+		{"alias inside expression", "ASSERT (SELECT COUNT(*) AS c FROM users) = 3", "(SELECT COUNT(*) AS c FROM users) = 3", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, message := parseAssertion(tt.assertion)
+			if expr != tt.expr || message != tt.message {
+				t.Errorf("parseAssertion() = (%q, %q), expected (%q, %q)", expr, message, tt.expr, tt.message)
+			}
+		})
+	}
+}
+
+func TestParseAssertions(t *testing.T) {
+	content := `-- checks
+ASSERT (SELECT COUNT(*) FROM users) = 3;
+ASSERT (SELECT COUNT(*) FROM posts WHERE title = 'a;b') = 0 AS 'no posts'`
+
+	assertions, err := ParseAssertions(content)
+	if err != nil {
+		t.Fatalf("ParseAssertions() unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"ASSERT (SELECT COUNT(*) FROM users) = 3",
+		"ASSERT (SELECT COUNT(*) FROM posts WHERE title = 'a;b') = 0 AS 'no posts'",
+	}
+	if !reflect.DeepEqual(assertions, expected) {
+		t.Errorf("ParseAssertions() = %q, expected %q", assertions, expected)
+	}
+
+	if _, err := ParseAssertions("SELECT 1;"); err == nil {
+		t.Error("ParseAssertions() expected error for non-ASSERT statement")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlFile := filepath.Join(dir, "expect.yaml")
+	err := os.WriteFile(yamlFile, []byte(`tables:
+  users:
+    count: 3
+    rows:
+      - id: 1
+        name: John Doe
+assertions:
+  - ASSERT (SELECT COUNT(*) FROM posts) = 4
+`), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	exp, err := LoadFile(yamlFile)
+	if err != nil {
+		t.Fatalf("LoadFile() unexpected error: %v", err)
+	}
+	users := exp.Tables["users"]
+	if users.Count == nil || *users.Count != 3 {
+		t.Errorf("LoadFile() users count = %v, expected 3", users.Count)
+	}
+	if len(users.Rows) != 1 || users.Rows[0]["name"] != "John Doe" {
+		t.Errorf("LoadFile() users rows = %v", users.Rows)
+	}
+	if len(exp.Assertions) != 1 {
+		t.Errorf("LoadFile() assertions = %v, expected 1", exp.Assertions)
+	}
+
+	sqlFile := filepath.Join(dir, "checks.sql")
+	if err := os.WriteFile(sqlFile, []byte("ASSERT true;\nASSERT 1 = 1;"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	exp, err = LoadFile(sqlFile)
+	if err != nil {
+		t.Fatalf("LoadFile() unexpected error: %v", err)
+	}
+	if len(exp.Assertions) != 2 {
+		t.Errorf("LoadFile() assertions = %v, expected 2", exp.Assertions)
+	}
+
+	badFile := filepath.Join(dir, "bad.yaml")
+	if err := os.WriteFile(badFile, []byte("tabels: {}\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if _, err := LoadFile(badFile); err == nil {
+		t.Error("LoadFile() expected error for unknown field")
+	}
+}
+
+func value(code spannerpb.TypeCode, v *structpb.Value) spanner.GenericColumnValue {
+	return spanner.GenericColumnValue{Type: &spannerpb.Type{Code: code}, Value: v}
+}
+
+func TestValueMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected interface{}
+		actual   spanner.GenericColumnValue
+		want     bool
+	}{
+		{"int", 1, value(spannerpb.TypeCode_INT64, structpb.NewStringValue("1")), true},
+		{"int mismatch", 2, value(spannerpb.TypeCode_INT64, structpb.NewStringValue("1")), false},
+		{"string", "John", value(spannerpb.TypeCode_STRING, structpb.NewStringValue("John")), true},
+		{"null", nil, value(spannerpb.TypeCode_STRING, structpb.NewNullValue()), true},
+		{"null mismatch", "NULL", value(spannerpb.TypeCode_STRING, structpb.NewNullValue()), false},
+		{"float", 1.5, value(spannerpb.TypeCode_FLOAT64, structpb.NewNumberValue(1.5)), true},
+		{"numeric scale", "1.5", value(spannerpb.TypeCode_NUMERIC, structpb.NewStringValue("1.500000000")), true},
+		{"bool", true, value(spannerpb.TypeCode_BOOL, structpb.NewBoolValue(true)), true},
+		{"timestamp string", "2024-01-01T00:00:00Z", value(spannerpb.TypeCode_TIMESTAMP, structpb.NewStringValue("2024-01-01T00:00:00Z")), true},
+		{
+			"timestamp from yaml", time.Date(2024, 1, 1, 9, 0, 0, 0, time.FixedZone("JST", 9*3600)),
+			value(spannerpb.TypeCode_TIMESTAMP, structpb.NewStringValue("2024-01-01T00:00:00Z")), true,
+		},
+		{"date from yaml", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), value(spannerpb.TypeCode_DATE, structpb.NewStringValue("2024-01-02")), true},
+		{"json string", `{"b": 1, "a": [true]}`, value(spannerpb.TypeCode_JSON, structpb.NewStringValue(`{"a":[true],"b":1}`)), true},
+		{"json object", map[string]interface{}{"a": 1}, value(spannerpb.TypeCode_JSON, structpb.NewStringValue(`{"a":1}`)), true},
+		{
+			"array",
+			[]interface{}{1, nil},
+			spanner.GenericColumnValue{
+				Type:  &spannerpb.Type{Code: spannerpb.TypeCode_ARRAY, ArrayElementType: &spannerpb.Type{Code: spannerpb.TypeCode_INT64}},
+				Value: structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("1"), structpb.NewNullValue()}}),
+			},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := valueMatches(tt.expected, tt.actual); result != tt.want {
+				t.Errorf("valueMatches(%v, %v) = %v, expected %v", tt.expected, tt.actual.Value, result, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRow(t *testing.T) {
+	table := &schema.Table{
+		Name:       "users",
+		PrimaryKey: []string{"id"},
+		Columns:    []*schema.Column{{Name: "id", Type: "INT64"}, {Name: "name", Type: "STRING(100)"}},
+	}
+	columns := []string{"id", "name"}
+	actual := &output.Result{
+		Columns: columns,
+		Rows: [][]spanner.GenericColumnValue{
+			{value(spannerpb.TypeCode_INT64, structpb.NewStringValue("1")), value(spannerpb.TypeCode_STRING, structpb.NewStringValue("John Doe"))},
+			{value(spannerpb.TypeCode_INT64, structpb.NewStringValue("2")), value(spannerpb.TypeCode_STRING, structpb.NewStringValue("Jane Smith"))},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		expected map[string]interface{}
+		failure  string
+	}{
+		{"match by key", map[string]interface{}{"id": 1, "name": "John Doe"}, ""},
+		{"key only", map[string]interface{}{"id": 2}, ""},
+		{"mismatch", map[string]interface{}{"id": 1, "name": "John"}, `users[id=1]: name: expected "John", got "John Doe"`},
+		{"missing row", map[string]interface{}{"id": 3, "name": "Bob"}, "users[id=3]: row not found"},
+		{"match without key", map[string]interface{}{"name": "Jane Smith"}, ""},
+		{"no match without key", map[string]interface{}{"name": "Bob"}, `users: no row matching {name="Bob"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &Report{}
+			verifyRow(table, columns, tt.expected, actual, report)
+
+			if tt.failure == "" {
+				if !report.OK() {
+					t.Errorf("verifyRow() unexpected failures: %v", report.Failures)
+				}
+				return
+			}
+			if len(report.Failures) != 1 || report.Failures[0] != tt.failure {
+				t.Errorf("verifyRow() failures = %q, expected %q", report.Failures, tt.failure)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/nu0ma/spemu/pkg/executor"
+	"github.com/nu0ma/spemu/pkg/output"
+	"github.com/nu0ma/spemu/pkg/params"
+	"github.com/nu0ma/spemu/pkg/parser"
+)
+
+// queryFlags are the flags of spemu query
+type queryFlags struct {
+	conn   *connectionFlags
+	file   *string
+	format *string
+	params paramFlags
+}
+
+func addQueryFlags(fs *flag.FlagSet) *queryFlags {
+	f := &queryFlags{
+		conn:   addConnectionFlags(fs),
+		file:   fs.String("f", "", "Read the query from a file"),
+		format: fs.String("format", "table", "Output format: table, csv, json or ndjson"),
+		params: paramFlags{},
+	}
+	fs.Var(f.params, "param", "Query parameter as name=value[:TYPE] (repeatable)")
+	return f
+}
+
+func runQuery(args []string) {
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	f := addQueryFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: spemu query [options] "SELECT ..."
+       spemu query [options] -f query.sql
+
+Run a query and print the result.
+
+Options:
+`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	outputFormat, err := output.ParseFormat(*f.format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := f.conn.config()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	dialect, _ := parser.ParseDialect(cfg.Dialect)
+	sql, err := querySQL(*f.file, fs.Args(), dialect)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	exec, err := executor.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create executor: %v", err)
+	}
+	defer exec.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	bound := map[string]interface{}(f.params)
+	if dialect == parser.PostgreSQL {
+		// $1, $2, ... are bound to the parameters p1, p2, ...
+		for name, value := range bound {
+			bound[name] = params.PostgreSQL(value)
+		}
+	}
+
+	iter := exec.Client().Single().Query(ctx, spanner.Statement{SQL: sql, Params: bound})
+	res, err := output.Collect(iter)
+	if err != nil {
+		log.Fatalf("Query failed: %v", err)
+	}
+
+	if err := output.Write(os.Stdout, res, outputFormat); err != nil {
+		log.Fatalf("Failed to write output: %v", err)
+	}
+}
+
+// querySQL returns the single query given either as an argument or in a file
+func querySQL(file string, args []string, dialect parser.Dialect) (string, error) {
+	var content string
+	switch {
+	case file != "" && len(args) > 0:
+		return "", fmt.Errorf("specify either a query or -f, not both")
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read query file: %w", err)
+		}
+		content = string(data)
+	case len(args) == 1:
+		content = args[0]
+	default:
+		return "", fmt.Errorf("expected a single query argument or -f <file>")
+	}
+
+	statements, rest := dialect.SplitStatements(content)
+	if rest = strings.TrimSpace(rest); rest != "" {
+		statements = append(statements, rest)
+	}
+	if len(statements) != 1 {
+		return "", fmt.Errorf("expected exactly one query, got %d", len(statements))
+	}
+
+	return statements[0], nil
+}
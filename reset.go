@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/nu0ma/spemu/pkg/executor"
+)
+
+// resetFlags are the flags of spemu reset
+type resetFlags struct {
+	conn       *connectionFlags
+	schemaFile *string
+	verbose    *bool
+}
+
+func addResetFlags(fs *flag.FlagSet) *resetFlags {
+	return &resetFlags{
+		conn:       addConnectionFlags(fs),
+		schemaFile: fs.String("schema", "", "Drop the database and recreate it from this schema file (DDL)"),
+		verbose:    fs.Bool("verbose", false, "Enable verbose output"),
+	}
+}
+
+func runReset(args []string) {
+	fs := flag.NewFlagSet("reset", flag.ExitOnError)
+	f := addResetFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: spemu reset [options]
+
+Delete all rows from all tables, children before their parents, keeping
+the schema. With --schema the database is dropped and recreated from the
+schema file instead, which also works for a schema that changed.
+
+Options:
+`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cfg, err := f.conn.config()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *f.schemaFile != "" {
+		if err := executor.RecreateDatabase(cfg, *f.schemaFile, *f.verbose); err != nil {
+			log.Fatalf("Failed to recreate database: %v", err)
+		}
+		fmt.Printf("Successfully recreated database %s from %s\n", cfg.DatabaseID, *f.schemaFile)
+		return
+	}
+
+	exec, err := executor.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create executor: %v", err)
+	}
+	defer exec.Close()
+
+	if err := exec.ResetDatabase(*f.verbose); err != nil {
+		log.Fatalf("Failed to reset database: %v", err)
+	}
+	fmt.Printf("Successfully deleted all rows from database %s\n", cfg.DatabaseID)
+}
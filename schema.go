@@ -0,0 +1,255 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/nu0ma/spemu/pkg/config"
+	"github.com/nu0ma/spemu/pkg/executor"
+	"github.com/nu0ma/spemu/pkg/parser"
+	"github.com/nu0ma/spemu/pkg/schema"
+)
+
+// schemaInitFlags are the flags of spemu schema init
+type schemaInitFlags struct {
+	conn    *connectionFlags
+	verbose *bool
+}
+
+func addSchemaInitFlags(fs *flag.FlagSet) *schemaInitFlags {
+	return &schemaInitFlags{
+		conn:    addConnectionFlags(fs),
+		verbose: fs.Bool("verbose", false, "Enable verbose output"),
+	}
+}
+
+func runSchemaInit(args []string) {
+	fs := flag.NewFlagSet("schema init", flag.ExitOnError)
+	f := addSchemaInitFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: spemu schema init [options] <schema-file>
+
+Create the instance and the database if they do not exist and apply the
+DDL statements of the schema file.
+
+Options:
+`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cfg, err := f.conn.config()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	initSchema(cfg, fs.Arg(0), *f.verbose)
+}
+
+// initSchema creates the database of cfg from a schema file
+func initSchema(cfg *config.Config, schemaFile string, verbose bool) {
+	if verbose {
+		fmt.Printf("Initializing schema from: %s\n", schemaFile)
+		fmt.Printf("Configuration: %+v\n", cfg)
+	}
+
+	if err := executor.InitializeSchema(cfg, schemaFile, verbose); err != nil {
+		log.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	fmt.Printf("Schema initialization completed successfully\n")
+}
+
+// schemaDiffFlags are the flags of spemu schema diff
+type schemaDiffFlags struct {
+	conn       *connectionFlags
+	schemaFile *string
+	apply      *bool
+	verbose    *bool
+}
+
+func addSchemaDiffFlags(fs *flag.FlagSet) *schemaDiffFlags {
+	return &schemaDiffFlags{
+		conn:       addConnectionFlags(fs),
+		schemaFile: fs.String("schema", "", "Schema file (DDL) describing the desired schema (required)"),
+		apply:      fs.Bool("apply", false, "Apply the statements to the database"),
+		verbose:    fs.Bool("verbose", false, "Enable verbose output"),
+	}
+}
+
+func runSchemaDiff(args []string) {
+	fs := flag.NewFlagSet("schema diff", flag.ExitOnError)
+	f := addSchemaDiffFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: spemu schema diff [options] --schema schema.sql
+
+Compare the schema of the database with a schema file and print the DDL
+statements that make the database match the file. Tables, columns,
+indexes, foreign keys, check constraints and change streams are compared.
+
+Options:
+`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *f.schemaFile == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cfg, err := f.conn.config()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg.Dialect == parser.PostgreSQL.String() {
+		fmt.Fprintf(os.Stderr, "Error: schema diff does not support the PostgreSQL dialect\n")
+		os.Exit(1)
+	}
+
+	content, err := os.ReadFile(*f.schemaFile)
+	if err != nil {
+		log.Fatalf("Failed to read schema file: %v", err)
+	}
+	statements, err := parser.ParseDDL(string(content), *f.schemaFile)
+	if err != nil {
+		log.Fatalf("Failed to parse schema file: %v", err)
+	}
+	desired, err := schema.ParseDDL(parser.SQL(statements))
+	if err != nil {
+		log.Fatalf("Failed to parse schema file: %v", err)
+	}
+
+	ddl, err := executor.GetDatabaseDDL(cfg)
+	if err != nil {
+		log.Fatalf("Failed to read database schema: %v", err)
+	}
+	current, err := schema.ParseDDL(ddl)
+	if err != nil {
+		log.Fatalf("Failed to parse database schema: %v", err)
+	}
+	if *f.verbose {
+		fmt.Printf("Database has %d DDL statements, %s has %d\n", len(ddl), *f.schemaFile, len(statements))
+	}
+
+	if !sameStatements(current.Other, desired.Other) {
+		fmt.Fprintf(os.Stderr, "Warning: views, sequences and other statements differ but are not compared\n")
+	}
+
+	changes, err := schema.Diff(current, desired)
+	if err != nil {
+		log.Fatalf("Failed to compare schemas: %v", err)
+	}
+	if len(changes) == 0 {
+		fmt.Println("Schema is up to date")
+		return
+	}
+
+	for _, change := range changes {
+		fmt.Printf("%s;\n", change)
+	}
+
+	if *f.apply {
+		if err := executor.UpdateDDL(cfg, changes, *f.verbose); err != nil {
+			log.Fatalf("Failed to apply schema changes: %v", err)
+		}
+		fmt.Printf("Successfully applied %d schema changes\n", len(changes))
+	}
+}
+
+// schemaDumpFlags are the flags of spemu schema dump
+type schemaDumpFlags struct {
+	conn       *connectionFlags
+	outputFile *string
+	verbose    *bool
+}
+
+func addSchemaDumpFlags(fs *flag.FlagSet) *schemaDumpFlags {
+	return &schemaDumpFlags{
+		conn:       addConnectionFlags(fs),
+		outputFile: fs.String("output", "", "Write the DDL to this file instead of standard output"),
+		verbose:    fs.Bool("verbose", false, "Enable verbose output"),
+	}
+}
+
+func runSchemaDump(args []string) {
+	fs := flag.NewFlagSet("schema dump", flag.ExitOnError)
+	f := addSchemaDumpFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: spemu schema dump [options]
+
+Write the schema of the database as a DDL file that spemu schema init
+accepts. Statements are formatted consistently and ordered so that tables
+come before their children, indexes and foreign keys.
+
+Options:
+`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	cfg, err := f.conn.config()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg.Dialect == parser.PostgreSQL.String() {
+		fmt.Fprintf(os.Stderr, "Error: schema dump does not support the PostgreSQL dialect\n")
+		os.Exit(1)
+	}
+
+	ddl, err := executor.GetDatabaseDDL(cfg)
+	if err != nil {
+		log.Fatalf("Failed to read database schema: %v", err)
+	}
+	s, err := schema.ParseDDL(ddl)
+	if err != nil {
+		log.Fatalf("Failed to parse database schema: %v", err)
+	}
+	statements, err := s.DDL()
+	if err != nil {
+		log.Fatalf("Failed to order database schema: %v", err)
+	}
+
+	var b strings.Builder
+	for i, stmt := range statements {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(stmt + ";\n")
+	}
+
+	if *f.outputFile == "" {
+		fmt.Print(b.String())
+		return
+	}
+	if err := os.WriteFile(*f.outputFile, []byte(b.String()), 0644); err != nil {
+		log.Fatalf("Failed to write schema file: %v", err)
+	}
+	if *f.verbose {
+		fmt.Printf("Successfully wrote %d DDL statements to %s\n", len(statements), *f.outputFile)
+	}
+}
+
+// sameStatements reports whether two lists hold the same statements,
+// ignoring case and whitespace
+func sameStatements(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(strings.Join(strings.Fields(a[i]), " "), strings.Join(strings.Fields(b[i]), " ")) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nu0ma/spemu/pkg/executor"
+	"github.com/nu0ma/spemu/pkg/params"
+	"github.com/nu0ma/spemu/pkg/parser"
+	"github.com/nu0ma/spemu/pkg/render"
+)
+
+// seedFlags are the flags of spemu seed, which the flag form of earlier
+// versions accepts as well
+type seedFlags struct {
+	conn       *connectionFlags
+	schemaFile *string
+	dryRun     *bool
+	verbose    *bool
+	tmpl       *bool
+	vars       varFlags
+	paramsFile *string
+	params     paramFlags
+	parallel   *int
+	attempts   *int
+	backoff    *time.Duration
+	format     *string
+	pdml       *bool
+	mode       *string
+	tags       listFlags
+	continueOn *bool
+	reorder    *bool
+	watch      *bool
+}
+
+func addSeedFlags(fs *flag.FlagSet) *seedFlags {
+	f := &seedFlags{
+		conn:       addConnectionFlags(fs),
+		schemaFile: fs.String("schema", "", "Schema file (DDL) to recreate the database from on every reload (requires --watch)"),
+		dryRun:     fs.Bool("dry-run", false, "Parse and validate DML without executing"),
+		verbose:    fs.Bool("verbose", false, "Enable verbose output"),
+		tmpl:       fs.Bool("template", false, "Expand the DML file as a template before parsing"),
+		vars:       varFlags{},
+		paramsFile: fs.String("params-file", "", "YAML or JSON file with values for @name parameters"),
+		params:     paramFlags{},
+		parallel:   fs.Int("parallel", 0, "Load files in separate transactions with up to N running concurrently"),
+		attempts:   fs.Int("max-attempts", executor.DefaultRetryPolicy().MaxAttempts, "Maximum attempts for transactions aborted by a busy emulator"),
+		backoff:    fs.Duration("retry-backoff", executor.DefaultRetryPolicy().InitialBackoff, "Initial delay between transaction attempts"),
+		format:     fs.String("format", "text", "Output format for the execution summary: text or json"),
+		pdml:       fs.Bool("partitioned", false, "Execute UPDATE and DELETE statements as partitioned DML"),
+		mode:       fs.String("mode", "dml", "Execute INSERT statements as dml or apply them as mutations"),
+		continueOn: fs.Bool("continue-on-error", false, "Execute each statement in its own transaction and report all failures"),
+		reorder:    fs.Bool("reorder", false, "Sort INSERT statements by the foreign keys and interleaving of their tables"),
+		watch:      fs.Bool("watch", false, "Reload the database whenever the schema or DML files change"),
+	}
+	fs.Var(f.vars, "var", "Template variable as key=value (repeatable)")
+	fs.Var(f.params, "param", "Value for an @name parameter as name=value[:TYPE] (repeatable)")
+	fs.Var(&f.tags, "tag", "Only execute statements annotated with -- @tag name (repeatable)")
+	return f
+}
+
+func runSeed(args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	f := addSeedFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: spemu seed [options] <dml-file>...
+
+Execute the DML statements of the files in a single transaction, or in one
+transaction per file or group with --parallel. With --watch the database
+is reloaded whenever a file changes.
+
+Options:
+`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 && !(*f.watch && *f.schemaFile != "") {
+		fs.Usage()
+		os.Exit(1)
+	}
+	seed(f, fs.Args())
+}
+
+// seed executes the DML files with the options given by f
+func seed(f *seedFlags, args []string) {
+	cfg, err := f.conn.config()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	dialect, _ := parser.ParseDialect(cfg.Dialect)
+
+	mode, err := executor.ParseMode(*f.mode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *f.format != "text" && *f.format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (expected text or json)\n", *f.format)
+		os.Exit(1)
+	}
+
+	if *f.schemaFile != "" && !*f.watch {
+		fmt.Fprintf(os.Stderr, "Error: --schema requires --watch; use 'spemu schema init' or 'spemu reset --schema' to recreate the database once\n")
+		os.Exit(1)
+	}
+
+	if *f.verbose {
+		fmt.Printf("Configuration: %+v\n", cfg)
+		fmt.Printf("DML files: %s\n", strings.Join(args, ", "))
+	}
+
+	statementParams := map[string]interface{}{}
+	if *f.paramsFile != "" {
+		statementParams, err = params.LoadFile(*f.paramsFile)
+		if err != nil {
+			log.Fatalf("Failed to load parameters: %v", err)
+		}
+	}
+	// --param values take precedence over the parameters file
+	for name, value := range f.params {
+		statementParams[name] = value
+	}
+
+	configure := func(exec *executor.Executor) {
+		exec.Params = statementParams
+		exec.Retry.MaxAttempts = *f.attempts
+		exec.Retry.InitialBackoff = *f.backoff
+		exec.Partitioned = *f.pdml
+		exec.Mode = mode
+		exec.Tags = f.tags
+		exec.ContinueOnError = *f.continueOn
+		exec.Reorder = *f.reorder
+	}
+
+	if *f.watch {
+		if *f.dryRun || *f.format != "text" {
+			fmt.Fprintf(os.Stderr, "Error: --watch cannot be combined with --dry-run or --format=json\n")
+			os.Exit(1)
+		}
+		if dialect == parser.PostgreSQL && *f.schemaFile == "" {
+			fmt.Fprintf(os.Stderr, "Error: --watch with the PostgreSQL dialect requires --schema, since rows can only be reset in GoogleSQL databases\n")
+			os.Exit(1)
+		}
+		w := &watcher{
+			cfg:        cfg,
+			dialect:    dialect,
+			schemaFile: *f.schemaFile,
+			seeds:      args,
+			tmpl:       *f.tmpl,
+			vars:       f.vars,
+			parallel:   *f.parallel,
+			configure:  configure,
+			verbose:    *f.verbose,
+		}
+		w.run()
+		return
+	}
+
+	var files []*parser.File
+	for _, path := range args {
+		file, err := loadSeedFile(path, dialect, *f.tmpl, f.vars, *f.verbose)
+		if err != nil {
+			log.Fatalf("Failed to parse DML file: %v", err)
+		}
+		files = append(files, file)
+	}
+
+	units, err := buildUnits(files)
+	if err != nil {
+		log.Fatalf("Failed to parse DML file: %v", err)
+	}
+	units, err = executor.OrderUnits(units)
+	if err != nil {
+		log.Fatalf("Failed to order DML files: %v", err)
+	}
+
+	var statements []parser.Statement
+	for _, unit := range units {
+		statements = append(statements, unit.Statements...)
+	}
+
+	if *f.verbose {
+		fmt.Printf("Parsed %d DML statements\n", len(statements))
+	}
+
+	if *f.dryRun {
+		if *f.reorder {
+			// The order depends on the schema of the database
+			exec, err := executor.New(cfg)
+			if err != nil {
+				log.Fatalf("Failed to create executor: %v", err)
+			}
+			statements, err = exec.ReorderInserts(statements)
+			exec.Close()
+			if err != nil {
+				log.Fatalf("Failed to reorder statements: %v", err)
+			}
+		}
+		fmt.Printf("Dry run: %d statements would be executed\n", len(statements))
+		for i, stmt := range statements {
+			annotations, err := executor.ParseAnnotations(stmt)
+			if err != nil {
+				log.Fatalf("Statement %d: %v", i+1, err)
+			}
+			partitioned, err := executor.IsPartitioned(stmt, dialect, *f.pdml)
+			if err != nil {
+				log.Fatalf("Statement %d (%s): %v", i+1, stmt.Position(), err)
+			}
+			limit := 50
+			if len(stmt.SQL) < limit {
+				limit = len(stmt.SQL)
+			}
+			mode := ""
+			switch {
+			case !annotations.Selected(f.tags):
+				mode = " [skipped]"
+			case partitioned:
+				mode = " [partitioned]"
+			case annotations.ExpectError:
+				mode = " [expect error]"
+			}
+			fmt.Printf("Statement %d (%s)%s: %s\n", i+1, stmt.Position(), mode, stmt.SQL[:limit]+"...")
+		}
+		return
+	}
+
+	exec, err := executor.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create executor: %v", err)
+	}
+	defer exec.Close()
+	configure(exec)
+
+	// Files are loaded in a single transaction unless --parallel is given
+	var result *executor.Result
+	if *f.parallel > 0 {
+		result, err = exec.ExecuteParallel(units, *f.parallel, *f.verbose)
+	} else {
+		result, err = exec.Execute(statements, *f.verbose)
+	}
+
+	if *f.format == "json" {
+		if writeErr := writeExecutionJSON(os.Stdout, result, err); writeErr != nil {
+			log.Fatalf("Failed to write summary: %v", writeErr)
+		}
+		if err != nil {
+			exec.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err != nil && result != nil && len(result.Failures) > 0 {
+		printFailures(result)
+		if *f.parallel > 0 {
+			// Also report files skipped because of failures they depend on
+			fmt.Fprintln(os.Stderr, err)
+		}
+		exec.Close()
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatalf("Failed to execute statements: %v", err)
+	}
+
+	if mode == executor.ModeMutations {
+		printPaths(result)
+	}
+	if *f.verbose && result.Retries > 0 {
+		fmt.Printf("Transactions were retried %d times\n", result.Retries)
+	}
+	executed, skipped := countExecuted(result)
+	if skipped > 0 {
+		fmt.Printf("Successfully executed %d statements (%d skipped)\n", executed, skipped)
+		return
+	}
+	fmt.Printf("Successfully executed %d statements\n", executed)
+}
+
+// loadSeedFile reads and parses a DML file. Templates are expanded when
+// requested explicitly, when variables are given or for .tmpl files.
+func loadSeedFile(path string, dialect parser.Dialect, tmpl bool, vars map[string]string, verbose bool) (*parser.File, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+
+	if tmpl || len(vars) > 0 || render.IsTemplate(path) {
+		rendered, err := render.Render(path, string(content), vars)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		content = []byte(rendered)
+
+		if verbose {
+			fmt.Printf("Rendered template %s\n", path)
+		}
+	}
+
+	return dialect.LoadContent(string(content), path)
+}
+
+// buildUnits groups seed files into units that are executed in their own
+// transactions. Files declaring the same -- @group form one unit. A file's
+// -- @depends-on directives name files, relative to the declaring file, or
+// groups that must be loaded first.
+func buildUnits(files []*parser.File) ([]executor.Unit, error) {
+	var units []executor.Unit
+	unitOf := make(map[string]int)
+	groups := make(map[string]int)
+	fileUnit := make([]int, len(files))
+
+	for i, f := range files {
+		group := ""
+		if names := f.Directive("group"); len(names) > 0 {
+			group = strings.TrimSpace(names[len(names)-1])
+		}
+
+		if group != "" {
+			if u, ok := groups[group]; ok {
+				units[u].Statements = append(units[u].Statements, f.Statements...)
+				fileUnit[i] = u
+				unitOf[filepath.Clean(f.Path)] = u
+				continue
+			}
+			groups[group] = len(units)
+		}
+
+		name := f.Path
+		if group != "" {
+			name = group
+		}
+		fileUnit[i] = len(units)
+		unitOf[filepath.Clean(f.Path)] = len(units)
+		units = append(units, executor.Unit{Name: name, Statements: f.Statements})
+	}
+
+	for i, f := range files {
+		u := fileUnit[i]
+		for _, arg := range f.Directive("depends-on") {
+			for _, dep := range strings.FieldsFunc(arg, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+				target, ok := groups[dep]
+				if !ok {
+					target, ok = unitOf[filepath.Join(filepath.Dir(f.Path), dep)]
+				}
+				if !ok {
+					return nil, fmt.Errorf("%s: @depends-on %s: no such file or group is being loaded", f.Path, dep)
+				}
+				if target != u && !slices.Contains(units[u].DependsOn, units[target].Name) {
+					units[u].DependsOn = append(units[u].DependsOn, units[target].Name)
+				}
+			}
+		}
+	}
+
+	return units, nil
+}
+
+// executionSummary is the JSON form of an execution result
+type executionSummary struct {
+	Status       string             `json:"status"`
+	Statements   int                `json:"statements"`
+	RowsAffected int64              `json:"rowsAffected"`
+	Retries      int                `json:"retries"`
+	Error        string             `json:"error,omitempty"`
+	ErrorKind    string             `json:"errorKind,omitempty"`
+	Results      []statementSummary `json:"results"`
+	Failures     []failureSummary   `json:"failures,omitempty"`
+}
+
+type failureSummary struct {
+	File  string `json:"file,omitempty"`
+	Line  int    `json:"line,omitempty"`
+	Code  string `json:"code"`
+	Error string `json:"error"`
+}
+
+type statementSummary struct {
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	RowCount int64  `json:"rowCount"`
+	// Path is dml, mutations or partitioned. Partitioned statements report
+	// a lower bound of the modified rows.
+	Path     string `json:"path"`
+	Fallback string `json:"fallback,omitempty"`
+	// Error is the error of a statement annotated with -- @expect-error
+	Error string `json:"error,omitempty"`
+}
+
+// writeExecutionJSON writes the outcome of an execution as JSON. errorKind
+// is "busy" when the emulator kept aborting transactions and "error" for
+// other failures such as invalid statements.
+func writeExecutionJSON(w io.Writer, result *executor.Result, err error) error {
+	summary := executionSummary{Status: "ok", Results: []statementSummary{}}
+	if result != nil {
+		summary.Statements = len(result.Statements)
+		summary.RowsAffected = result.RowCount()
+		summary.Retries = result.Retries
+		for _, stmt := range result.Statements {
+			summary.Results = append(summary.Results, statementSummary{
+				File:     stmt.Statement.File,
+				Line:     stmt.Statement.Line,
+				RowCount: stmt.RowCount,
+				Path:     string(stmt.Path),
+				Fallback: stmt.Fallback,
+				Error:    stmt.Error,
+			})
+		}
+		for _, f := range result.Failures {
+			summary.Failures = append(summary.Failures, failureSummary{
+				File:  f.Statement.File,
+				Line:  f.Statement.Line,
+				Code:  f.Code.String(),
+				Error: f.Err.Error(),
+			})
+		}
+	}
+	if err != nil {
+		summary.Status = "failed"
+		summary.Error = err.Error()
+		summary.ErrorKind = "error"
+		if executor.IsBusy(err) {
+			summary.ErrorKind = "busy"
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(summary)
+}
+
+// printPaths prints how each statement was executed and why INSERT
+// statements fell back to DML.
+func printPaths(result *executor.Result) {
+	counts := make(map[executor.Path]int)
+	for i, stmt := range result.Statements {
+		counts[stmt.Path]++
+		line := fmt.Sprintf("Statement %d (%s): %s, %d rows", i+1, stmt.Statement.Position(), stmt.Path, stmt.RowCount)
+		if stmt.Fallback != "" {
+			line += " (fallback: " + stmt.Fallback + ")"
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("%d statements applied as mutations, %d executed as DML, %d as partitioned DML, %d skipped\n",
+		counts[executor.PathMutations], counts[executor.PathDML], counts[executor.PathPartitioned], counts[executor.PathSkipped])
+}
+
+// countExecuted returns the number of executed and skipped statements.
+func countExecuted(result *executor.Result) (executed, skipped int) {
+	for _, stmt := range result.Statements {
+		if stmt.Path == executor.PathSkipped {
+			skipped++
+			continue
+		}
+		executed++
+	}
+	return executed, skipped
+}
+
+// printFailures prints the statements that failed with --continue-on-error,
+// one line each, followed by a count.
+func printFailures(result *executor.Result) {
+	fmt.Fprintln(os.Stderr, "Failed statements:")
+	for _, f := range result.Failures {
+		message, _, _ := strings.Cut(f.Err.Error(), "\n")
+		// Errors name the statement and its position
+		fmt.Fprintf(os.Stderr, "  [%s] %s\n", f.Code, message)
+	}
+	executed, _ := countExecuted(result)
+	fmt.Fprintf(os.Stderr, "%d statements failed, %d succeeded\n", len(result.Failures), executed)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/nu0ma/spemu/pkg/executor"
+	"github.com/nu0ma/spemu/pkg/shell"
+)
+
+func runShell(args []string) {
+	fs := flag.NewFlagSet("shell", flag.ExitOnError)
+	conn := addConnectionFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: spemu shell [options]\n\nStart an interactive SQL shell.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	cfg, err := conn.config()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	exec, err := executor.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create executor: %v", err)
+	}
+	defer exec.Close()
+
+	sh := shell.New(exec.Client(), os.Stdout)
+	sh.Dialect = exec.Dialect
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		sh.Interactive = true
+		fmt.Printf("Connected to %s\nType \\? for help, \\q to quit.\n", cfg.DatabasePath())
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		sh.HistoryFile = filepath.Join(home, ".spemu_history")
+	}
+
+	if err := sh.Run(os.Stdin); err != nil {
+		log.Fatalf("Shell failed: %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nu0ma/spemu/pkg/config"
+	"github.com/nu0ma/spemu/pkg/executor"
+	"github.com/nu0ma/spemu/pkg/parser"
+	"github.com/nu0ma/spemu/pkg/snapshot"
+)
+
+// snapshotFlags are the flags shared by the snapshot commands
+type snapshotFlags struct {
+	conn    *connectionFlags
+	dir     *string
+	verbose *bool
+}
+
+func addSnapshotFlags(fs *flag.FlagSet) *snapshotFlags {
+	return &snapshotFlags{
+		conn:    addConnectionFlags(fs),
+		dir:     fs.String("dir", ".spemu/snapshots", "Directory holding the snapshots"),
+		verbose: fs.Bool("verbose", false, "Enable verbose output"),
+	}
+}
+
+func runSnapshotSave(args []string) {
+	fs := flag.NewFlagSet("snapshot save", flag.ExitOnError)
+	flags := addSnapshotFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: spemu snapshot save [options] <name>
+
+Save the schema and the rows of all tables to <dir>/<name>: the DDL in
+schema.sql, one NDJSON file per table and a manifest. An existing snapshot
+with the same name is replaced.
+
+Options:
+`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	cfg, path := flags.parse(fs)
+
+	ddl, err := executor.GetDatabaseDDL(cfg)
+	if err != nil {
+		log.Fatalf("Failed to read database schema: %v", err)
+	}
+
+	exec, err := executor.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create executor: %v", err)
+	}
+	defer exec.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	m, err := snapshot.Save(ctx, exec.Client(), cfg.DatabaseID, ddl, path, *flags.verbose)
+	if err != nil {
+		log.Fatalf("Failed to save snapshot: %v", err)
+	}
+	fmt.Printf("Successfully saved %d rows from %d tables to %s\n", countRows(m), len(m.Tables), path)
+}
+
+func runSnapshotRestore(args []string) {
+	fs := flag.NewFlagSet("snapshot restore", flag.ExitOnError)
+	flags := addSnapshotFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: spemu snapshot restore [options] <name>
+
+Recreate the database from the snapshot in <dir>/<name>: the database is
+dropped, created with the saved schema and the rows are inserted with
+mutations. All data in the database is replaced.
+
+Options:
+`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	cfg, path := flags.parse(fs)
+	if _, err := snapshot.Load(path); err != nil {
+		log.Fatalf("Failed to load snapshot: %v", err)
+	}
+
+	if err := executor.RecreateDatabase(cfg, filepath.Join(path, snapshot.SchemaFile), *flags.verbose); err != nil {
+		log.Fatalf("Failed to recreate database: %v", err)
+	}
+
+	exec, err := executor.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create executor: %v", err)
+	}
+	defer exec.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	m, err := snapshot.Restore(ctx, exec.Client(), path, *flags.verbose)
+	if err != nil {
+		log.Fatalf("Failed to restore snapshot: %v", err)
+	}
+	fmt.Printf("Successfully restored %d rows into %d tables from %s\n", countRows(m), len(m.Tables), path)
+}
+
+// parse validates the flags and returns the configuration and the path of
+// the snapshot named by the argument
+func (f *snapshotFlags) parse(fs *flag.FlagSet) (*config.Config, string) {
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cfg, err := f.conn.config()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg.Dialect == parser.PostgreSQL.String() {
+		fmt.Fprintf(os.Stderr, "Error: snapshot does not support the PostgreSQL dialect\n")
+		os.Exit(1)
+	}
+
+	path, err := snapshot.Path(*f.dir, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return cfg, path
+}
+
+func countRows(m *snapshot.Manifest) int64 {
+	var rows int64
+	for _, t := range m.Tables {
+		rows += t.Rows
+	}
+	return rows
+}
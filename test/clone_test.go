@@ -0,0 +1,54 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/nu0ma/spemu/pkg/executor"
+)
+
+func TestIntegration_CloneDatabase(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	source, cfg := newCopyFixture(t)
+	expected := tableRows(t, source)
+
+	targetCfg := *cfg
+	targetCfg.DatabaseID = fmt.Sprintf("clone-%d", time.Now().UnixNano()%1e9)
+	if err := executor.CloneDatabase(cfg, targetCfg.DatabaseID, false); err != nil {
+		t.Fatalf("CloneDatabase() error = %v", err)
+	}
+	t.Cleanup(func() {
+		if err := executor.DropDatabase(&targetCfg); err != nil {
+			t.Logf("Failed to drop clone %s: %v", targetCfg.DatabaseID, err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	target, err := spanner.NewClient(ctx, targetCfg.DatabasePath())
+	if err != nil {
+		t.Fatalf("Failed to create Spanner client: %v", err)
+	}
+	defer target.Close()
+
+	if got := tableRows(t, target); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Cloned rows differ:\ngot:      %v\nexpected: %v", got, expected)
+	}
+
+	// An existing target is neither overwritten nor dropped
+	err = executor.CloneDatabase(cfg, targetCfg.DatabaseID, false)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("CloneDatabase() onto an existing database error = %v, expected already exists", err)
+	}
+	if got := tableRows(t, target); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Existing clone changed after a failed clone:\ngot: %v", got)
+	}
+}
@@ -0,0 +1,186 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/nu0ma/spemu/pkg/config"
+	"github.com/nu0ma/spemu/pkg/executor"
+	"github.com/nu0ma/spemu/pkg/snapshot"
+	"github.com/nu0ma/spemu/pkg/spemutest"
+)
+
+// copyFixture has interleaved tables, foreign keys, a generated column and
+// values of most column types, to check that copies of a database keep all
+// rows intact
+var copyFixture = fstest.MapFS{
+	"schema.sql": {Data: []byte(`
+CREATE TABLE Singers (
+  SingerId INT64 NOT NULL,
+  FirstName STRING(100),
+  LastName STRING(100),
+  FullName STRING(MAX) AS (ARRAY_TO_STRING([FirstName, LastName], " ")) STORED,
+) PRIMARY KEY (SingerId);
+
+CREATE TABLE Albums (
+  SingerId INT64 NOT NULL,
+  AlbumId INT64 NOT NULL,
+  Title STRING(MAX),
+  Price NUMERIC,
+  ReleasedOn DATE,
+  Cover BYTES(MAX),
+  Tags ARRAY<STRING(MAX)>,
+) PRIMARY KEY (SingerId, AlbumId),
+  INTERLEAVE IN PARENT Singers ON DELETE CASCADE;
+
+CREATE TABLE Labels (
+  LabelId INT64 NOT NULL,
+  Name STRING(100) NOT NULL,
+) PRIMARY KEY (LabelId);
+
+CREATE TABLE Contracts (
+  ContractId INT64 NOT NULL,
+  SingerId INT64 NOT NULL,
+  LabelId INT64 NOT NULL,
+  SignedAt TIMESTAMP,
+  Royalty FLOAT64,
+  CONSTRAINT FK_ContractSinger FOREIGN KEY (SingerId) REFERENCES Singers (SingerId),
+  CONSTRAINT FK_ContractLabel FOREIGN KEY (LabelId) REFERENCES Labels (LabelId),
+) PRIMARY KEY (ContractId);
+`)},
+	"seed.sql": {Data: []byte(`
+INSERT INTO Singers (SingerId, FirstName, LastName) VALUES (1, 'Marc', 'Richards'), (2, 'Catalina', 'Smith');
+INSERT INTO Albums (SingerId, AlbumId, Title, Price, ReleasedOn, Cover, Tags) VALUES
+  (1, 1, 'Total Junk', NUMERIC '9.99', DATE '2020-01-01', b'\x00\xff', ['rock', NULL]),
+  (1, 2, 'Go, Go, Go', NULL, NULL, NULL, NULL),
+  (2, 1, 'Green', NUMERIC '-0.5', DATE '1999-12-31', b'', []);
+INSERT INTO Labels (LabelId, Name) VALUES (1, 'Indie');
+INSERT INTO Contracts (ContractId, SingerId, LabelId, SignedAt, Royalty) VALUES
+  (1, 1, 1, TIMESTAMP '2020-01-01T12:34:56.789Z', 0.1),
+  (2, 2, 1, NULL, NULL);
+`)},
+}
+
+// copyQueries read all rows of the fixture tables in a stable order
+var copyQueries = map[string]string{
+	"Singers":   "SELECT * FROM Singers ORDER BY SingerId",
+	"Albums":    "SELECT * FROM Albums ORDER BY SingerId, AlbumId",
+	"Labels":    "SELECT * FROM Labels ORDER BY LabelId",
+	"Contracts": "SELECT * FROM Contracts ORDER BY ContractId",
+}
+
+// newCopyFixture creates a database with the fixture and returns a client
+// and the configuration of the database
+func newCopyFixture(t *testing.T) (*spanner.Client, *config.Config) {
+	t.Helper()
+
+	client := spemutest.New(t,
+		spemutest.SchemaFS(copyFixture, "schema.sql"),
+		spemutest.SeedFS(copyFixture, "seed.sql"),
+	)
+	return client, databaseConfig(client)
+}
+
+// databaseConfig returns the configuration of the database of client
+func databaseConfig(client *spanner.Client) *config.Config {
+	parts := strings.Split(client.DatabaseName(), "/")
+	return &config.Config{
+		ProjectID:    parts[1],
+		InstanceID:   parts[3],
+		DatabaseID:   parts[5],
+		EmulatorHost: os.Getenv("SPANNER_EMULATOR_HOST"),
+	}
+}
+
+// tableRows returns the rows of each fixture table, formatted as strings
+func tableRows(t *testing.T, client *spanner.Client) map[string][]string {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tables := make(map[string][]string)
+	for table, query := range copyQueries {
+		err := client.Single().Query(ctx, spanner.Statement{SQL: query}).Do(func(row *spanner.Row) error {
+			values := make([]string, row.Size())
+			for i := range values {
+				var v spanner.GenericColumnValue
+				if err := row.Column(i, &v); err != nil {
+					return err
+				}
+				values[i] = fmt.Sprintf("%s=%v", row.ColumnName(i), v.Value.AsInterface())
+			}
+			tables[table] = append(tables[table], strings.Join(values, ", "))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", table, err)
+		}
+	}
+	return tables
+}
+
+func TestIntegration_SnapshotRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	source, cfg := newCopyFixture(t)
+	expected := tableRows(t, source)
+	if len(expected["Albums"]) != 3 || len(expected["Contracts"]) != 2 {
+		t.Fatalf("Unexpected fixture rows: %v", expected)
+	}
+
+	ddl, err := executor.GetDatabaseDDL(cfg)
+	if err != nil {
+		t.Fatalf("Failed to read DDL: %v", err)
+	}
+	dir := filepath.Join(t.TempDir(), "baseline")
+	m, err := snapshot.Save(ctx, source, cfg.DatabaseID, ddl, dir, false)
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Parents and referenced tables come first, generated columns are not
+	// saved
+	var order []string
+	for _, table := range m.Tables {
+		order = append(order, table.Name)
+		if table.Name == "Singers" && !reflect.DeepEqual(table.Columns, []string{"SingerId", "FirstName", "LastName"}) {
+			t.Errorf("Singers columns = %v, expected the generated column to be skipped", table.Columns)
+		}
+	}
+	position := func(name string) int {
+		for i, table := range order {
+			if table == name {
+				return i
+			}
+		}
+		t.Fatalf("Table %s missing from the manifest: %v", name, order)
+		return -1
+	}
+	if position("Singers") > position("Albums") || position("Singers") > position("Contracts") || position("Labels") > position("Contracts") {
+		t.Errorf("Manifest tables are not in dependency order: %v", order)
+	}
+
+	t.Run("restore", func(t *testing.T) {
+		target := spemutest.New(t, spemutest.Schema(filepath.Join(dir, snapshot.SchemaFile)))
+		if _, err := snapshot.Restore(ctx, target, dir, false); err != nil {
+			t.Fatalf("Restore() error = %v", err)
+		}
+		if got := tableRows(t, target); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Restored rows differ:\ngot:      %v\nexpected: %v", got, expected)
+		}
+	})
+}
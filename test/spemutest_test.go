@@ -0,0 +1,53 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/nu0ma/spemu/pkg/spemutest"
+)
+
+func TestIntegration_Spemutest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	// Each subtest gets its own database, so they can run concurrently and
+	// modify data without affecting each other
+	for _, name := range []string{"first", "second", "third"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			client := spemutest.New(t,
+				spemutest.Schema("schema.sql"),
+				spemutest.Seed("../examples/seed.sql"),
+			)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			_, err := client.Apply(ctx, []*spanner.Mutation{
+				spanner.Delete("comments", spanner.Key{1}),
+			})
+			if err != nil {
+				t.Fatalf("Failed to delete comment: %v", err)
+			}
+
+			iter := client.Single().Query(ctx, spanner.Statement{SQL: "SELECT COUNT(*) FROM comments"})
+			defer iter.Stop()
+			row, err := iter.Next()
+			if err != nil {
+				t.Fatalf("Failed to query comments count: %v", err)
+			}
+			var count int64
+			if err := row.Columns(&count); err != nil {
+				t.Fatalf("Failed to scan comments count: %v", err)
+			}
+			if count != 3 {
+				t.Errorf("Expected 3 comments, got %d", count)
+			}
+		})
+	}
+}
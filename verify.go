@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nu0ma/spemu/pkg/executor"
+	"github.com/nu0ma/spemu/pkg/parser"
+	"github.com/nu0ma/spemu/pkg/verify"
+)
+
+// verifyFlags are the flags of spemu verify
+type verifyFlags struct {
+	conn    *connectionFlags
+	verbose *bool
+}
+
+func addVerifyFlags(fs *flag.FlagSet) *verifyFlags {
+	return &verifyFlags{
+		conn:    addConnectionFlags(fs),
+		verbose: fs.Bool("verbose", false, "Enable verbose output"),
+	}
+}
+
+func runVerify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	f := addVerifyFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: spemu verify [options] <expect.yaml|checks.sql>...
+
+Check the database contents against expected table row counts, rows and
+SQL assertions. Exits with status 1 if any check fails.
+
+Options:
+`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cfg, err := f.conn.config()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg.Dialect == parser.PostgreSQL.String() {
+		fmt.Fprintf(os.Stderr, "Error: verify does not support the PostgreSQL dialect\n")
+		os.Exit(1)
+	}
+
+	var expectations []*verify.Expectations
+	for _, file := range fs.Args() {
+		exp, err := verify.LoadFile(file)
+		if err != nil {
+			log.Fatalf("Failed to load expectations: %v", err)
+		}
+		expectations = append(expectations, exp)
+	}
+
+	exec, err := executor.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create executor: %v", err)
+	}
+	defer exec.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	checks, failures := 0, 0
+	for i, exp := range expectations {
+		if *f.verbose {
+			fmt.Printf("Verifying: %s\n", fs.Arg(i))
+		}
+
+		report, err := verify.Verify(ctx, exec.Client(), exp)
+		if err != nil {
+			log.Fatalf("Failed to verify %s: %v", fs.Arg(i), err)
+		}
+
+		checks += report.Checks
+		failures += len(report.Failures)
+		for _, failure := range report.Failures {
+			fmt.Printf("FAIL: %s\n", failure)
+		}
+	}
+
+	if failures > 0 {
+		fmt.Printf("Verification failed: %d of %d checks failed\n", failures, checks)
+		exec.Close()
+		os.Exit(1)
+	}
+
+	fmt.Printf("Verification passed: %d checks\n", checks)
+}
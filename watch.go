@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nu0ma/spemu/pkg/config"
+	"github.com/nu0ma/spemu/pkg/executor"
+	"github.com/nu0ma/spemu/pkg/parser"
+)
+
+// watchInterval is how often watched files are checked for changes
+const watchInterval = 500 * time.Millisecond
+
+// watcher reloads the database whenever the schema file, the DML files or
+// the files they include change
+type watcher struct {
+	cfg        *config.Config
+	dialect    parser.Dialect
+	schemaFile string
+	seeds      []string
+	tmpl       bool
+	vars       map[string]string
+	parallel   int
+	verbose    bool
+	// configure sets the execution options of an executor
+	configure func(*executor.Executor)
+
+	// includes are the files included by the seeds when they were last
+	// parsed successfully
+	includes []string
+	// needsRecreate is set while the database may be missing because
+	// recreating it failed
+	needsRecreate bool
+}
+
+// run loads the database and reloads it on every change until the process
+// is interrupted. Errors are printed and do not stop watching.
+func (w *watcher) run() {
+	stamps := statFiles(w.files())
+	w.reload(true)
+	stamps = w.track(stamps)
+	fmt.Printf("Watching %d files for changes (Ctrl+C to stop)\n", len(w.files()))
+
+	for {
+		time.Sleep(watchInterval)
+		changed := changedFiles(stamps, statFiles(w.files()))
+		if len(changed) == 0 {
+			continue
+		}
+
+		// Let editors finish writing before reading the files
+		time.Sleep(watchInterval)
+		stamps = statFiles(w.files())
+		fmt.Printf("Changed: %s\n", strings.Join(changed, ", "))
+		w.reload(slices.Contains(changed, w.schemaFile))
+		stamps = w.track(stamps)
+	}
+}
+
+// files returns the files to watch
+func (w *watcher) files() []string {
+	var files []string
+	if w.schemaFile != "" {
+		files = append(files, w.schemaFile)
+	}
+	files = append(files, w.seeds...)
+	for _, include := range w.includes {
+		if !slices.Contains(files, include) {
+			files = append(files, include)
+		}
+	}
+	return files
+}
+
+// track adds the files that started being watched, e.g. new includes, to
+// stamps
+func (w *watcher) track(stamps map[string]fileStamp) map[string]fileStamp {
+	for path, stamp := range statFiles(w.files()) {
+		if _, ok := stamps[path]; !ok {
+			stamps[path] = stamp
+		}
+	}
+	return stamps
+}
+
+// reload loads the database and prints a one-line result
+func (w *watcher) reload(schemaChanged bool) {
+	start := time.Now()
+	summary, err := w.load(w.recreate(schemaChanged))
+	now := time.Now().Format("15:04:05")
+	if err != nil {
+		fmt.Printf("[%s] Failed: %v\n", now, err)
+		return
+	}
+	fmt.Printf("[%s] %s in %s\n", now, summary, time.Since(start).Round(time.Millisecond))
+}
+
+// recreate reports whether a reload recreates the database from the schema
+// file: when the schema changed, when the last recreation failed, and always
+// with the PostgreSQL dialect, whose rows cannot be reset.
+func (w *watcher) recreate(schemaChanged bool) bool {
+	if w.schemaFile == "" {
+		return false
+	}
+	return schemaChanged || w.needsRecreate || w.dialect == parser.PostgreSQL
+}
+
+// load parses the seeds, recreates the database from the schema file or
+// deletes all rows, and executes the seeds. The database is left alone if
+// the seeds cannot be parsed.
+func (w *watcher) load(recreate bool) (string, error) {
+	var files []*parser.File
+	var includes []string
+	for _, path := range w.seeds {
+		f, err := loadSeedFile(path, w.dialect, w.tmpl, w.vars, w.verbose)
+		if err != nil {
+			return "", err
+		}
+		files = append(files, f)
+		includes = append(includes, f.Includes...)
+	}
+	w.includes = includes
+
+	units, err := buildUnits(files)
+	if err != nil {
+		return "", err
+	}
+	units, err = executor.OrderUnits(units)
+	if err != nil {
+		return "", err
+	}
+	var statements []parser.Statement
+	for _, unit := range units {
+		statements = append(statements, unit.Statements...)
+	}
+
+	if recreate {
+		w.needsRecreate = true
+		if err := executor.RecreateDatabase(w.cfg, w.schemaFile, w.verbose); err != nil {
+			return "", err
+		}
+		w.needsRecreate = false
+	}
+
+	exec, err := executor.New(w.cfg)
+	if err != nil {
+		return "", err
+	}
+	defer exec.Close()
+	w.configure(exec)
+
+	if !recreate {
+		if err := exec.ResetDatabase(w.verbose); err != nil {
+			return "", err
+		}
+	}
+
+	var result *executor.Result
+	if w.parallel > 0 {
+		result, err = exec.ExecuteParallel(units, w.parallel, w.verbose)
+	} else {
+		result, err = exec.Execute(statements, w.verbose)
+	}
+	if err != nil && result != nil && len(result.Failures) > 0 {
+		printFailures(result)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	summary := "Database recreated"
+	if !recreate {
+		summary = "Database reset"
+	}
+	executed, skipped := countExecuted(result)
+	summary += fmt.Sprintf(", executed %d statements", executed)
+	if skipped > 0 {
+		summary += fmt.Sprintf(" (%d skipped)", skipped)
+	}
+	return summary, nil
+}
+
+// fileStamp identifies a version of a file
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	missing bool
+}
+
+func statFiles(paths []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			stamps[path] = fileStamp{missing: true}
+			continue
+		}
+		stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps
+}
+
+// changedFiles returns the files in current whose stamp differs from before,
+// in a stable order
+func changedFiles(before, current map[string]fileStamp) []string {
+	var changed []string
+	for path, stamp := range current {
+		if old, ok := before[path]; !ok || old != stamp {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nu0ma/spemu/pkg/parser"
+)
+
+func TestChangedFiles(t *testing.T) {
+	now := time.Now()
+	stamp := fileStamp{modTime: now, size: 10}
+
+	tests := []struct {
+		name     string
+		before   map[string]fileStamp
+		current  map[string]fileStamp
+		expected []string
+	}{
+		{
+			name:    "unchanged",
+			before:  map[string]fileStamp{"seed.sql": stamp},
+			current: map[string]fileStamp{"seed.sql": stamp},
+		},
+		{
+			name:     "modified time",
+			before:   map[string]fileStamp{"seed.sql": stamp},
+			current:  map[string]fileStamp{"seed.sql": {modTime: now.Add(time.Second), size: 10}},
+			expected: []string{"seed.sql"},
+		},
+		{
+			name:     "modified size",
+			before:   map[string]fileStamp{"seed.sql": stamp},
+			current:  map[string]fileStamp{"seed.sql": {modTime: now, size: 11}},
+			expected: []string{"seed.sql"},
+		},
+		{
+			name:     "file went missing",
+			before:   map[string]fileStamp{"seed.sql": stamp},
+			current:  map[string]fileStamp{"seed.sql": {missing: true}},
+			expected: []string{"seed.sql"},
+		},
+		{
+			name:     "missing file reappeared",
+			before:   map[string]fileStamp{"seed.sql": {missing: true}},
+			current:  map[string]fileStamp{"seed.sql": stamp},
+			expected: []string{"seed.sql"},
+		},
+		{
+			name:    "still missing",
+			before:  map[string]fileStamp{"seed.sql": {missing: true}},
+			current: map[string]fileStamp{"seed.sql": {missing: true}},
+		},
+		{
+			name:     "new include",
+			before:   map[string]fileStamp{"seed.sql": stamp},
+			current:  map[string]fileStamp{"seed.sql": stamp, "users.sql": stamp},
+			expected: []string{"users.sql"},
+		},
+		{
+			name:    "file no longer watched",
+			before:  map[string]fileStamp{"seed.sql": stamp, "users.sql": stamp},
+			current: map[string]fileStamp{"seed.sql": stamp},
+		},
+		{
+			name:   "sorted",
+			before: map[string]fileStamp{},
+			current: map[string]fileStamp{
+				"schema.sql": stamp, "b.sql": stamp, "a.sql": stamp,
+			},
+			expected: []string{"a.sql", "b.sql", "schema.sql"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := changedFiles(tt.before, tt.current)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("changedFiles() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStatFiles(t *testing.T) {
+	dir := t.TempDir()
+	seed := filepath.Join(dir, "seed.sql")
+	if err := os.WriteFile(seed, []byte("SELECT 1;"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.sql")
+
+	stamps := statFiles([]string{seed, missing})
+	if s := stamps[seed]; s.missing || s.size != 9 || s.modTime.IsZero() {
+		t.Errorf("statFiles() %s = %+v", seed, s)
+	}
+	if s := stamps[missing]; !s.missing {
+		t.Errorf("statFiles() %s = %+v, expected missing", missing, s)
+	}
+}
+
+func TestWatcher_Track(t *testing.T) {
+	dir := t.TempDir()
+	seed := filepath.Join(dir, "seed.sql")
+	include := filepath.Join(dir, "users.sql")
+	for _, path := range []string{seed, include} {
+		if err := os.WriteFile(path, []byte("SELECT 1;"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := &watcher{seeds: []string{seed}}
+	stamps := statFiles(w.files())
+	old := fileStamp{size: 1}
+	stamps[seed] = old
+
+	// The seed started including another file
+	w.includes = []string{include}
+	stamps = w.track(stamps)
+
+	if stamps[seed] != old {
+		t.Errorf("track() replaced the stamp of %s, changes to it would be missed", seed)
+	}
+	if s, ok := stamps[include]; !ok || s.missing {
+		t.Errorf("track() %s = %+v, %v, expected the new include to be tracked", include, s, ok)
+	}
+	if changed := changedFiles(stamps, statFiles(w.files())); !reflect.DeepEqual(changed, []string{seed}) {
+		t.Errorf("changedFiles() after track() = %v, expected %v", changed, []string{seed})
+	}
+}
+
+func TestWatcher_Recreate(t *testing.T) {
+	tests := []struct {
+		name          string
+		watcher       watcher
+		schemaChanged bool
+		expected      bool
+	}{
+		{name: "no schema", watcher: watcher{}, schemaChanged: true},
+		{name: "schema changed", watcher: watcher{schemaFile: "schema.sql"}, schemaChanged: true, expected: true},
+		{name: "seeds changed", watcher: watcher{schemaFile: "schema.sql"}},
+		{name: "last recreation failed", watcher: watcher{schemaFile: "schema.sql", needsRecreate: true}, expected: true},
+		{name: "postgresql", watcher: watcher{schemaFile: "schema.sql", dialect: parser.PostgreSQL}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.watcher.recreate(tt.schemaChanged); got != tt.expected {
+				t.Errorf("recreate(%v) = %v, expected %v", tt.schemaChanged, got, tt.expected)
+			}
+		})
+	}
+}